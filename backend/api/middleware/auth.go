@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/user"
+	"trpg-sync/backend/infrastructure/auth"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// currentUserKey gin.Context 中保存当前用户的键
+const currentUserKey = "currentUser"
+
+// lastUsedInterval API 令牌最近使用时间的更新间隔，避免每个请求都写数据库
+const lastUsedInterval = time.Minute
+
+// Auth 解析访问令牌并把当前用户写入 gin.Context
+// 令牌从 Authorization: Bearer 头读取；WebSocket 和 SSE 无法设置请求头，只有这两个路由也接受 access_token 查询参数，
+// 且查询参数只能是登录令牌，API 令牌长期有效，放在地址中容易被日志和浏览器历史记录下来
+// 以 tpat_ 开头的令牌为用户创建的 API 令牌，按权限范围限制可访问的路由，见 requiredScope
+// 携带了令牌但无效时总是返回 401；未携带令牌时只有 required 为 true 才返回 401，否则以匿名身份继续（单人本地模式）
+func Auth(db *gorm.DB, issuer *auth.Issuer, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, fromQuery := bearerToken(c)
+		if fromQuery && user.IsAPIToken(token) {
+			abort(c, apperror.New(apperror.CodeUnauthorized, "API tokens must be sent in the Authorization header"))
+			return
+		}
+		if token == "" {
+			if required {
+				abort(c, apperror.New(apperror.CodeUnauthorized, ""))
+				return
+			}
+			c.Next()
+			return
+		}
+		if user.IsAPIToken(token) {
+			authenticateAPIToken(c, db, token)
+			return
+		}
+
+		claims, err := issuer.Parse(token, auth.TokenAccess)
+		if errors.Is(err, auth.ErrTokenExpired) {
+			abort(c, apperror.New(apperror.CodeTokenExpired, ""))
+			return
+		}
+		if err != nil {
+			abort(c, apperror.New(apperror.CodeUnauthorized, ""))
+			return
+		}
+		userID, _ := claims.UserID()
+
+		current, ok := loadUser(c, db, userID)
+		if !ok {
+			return
+		}
+		c.Set(currentUserKey, current)
+		c.Next()
+	}
+}
+
+// authenticateAPIToken 按摘要查找 API 令牌，校验撤销、有效期和本次请求所需的权限范围
+func authenticateAPIToken(c *gin.Context, db *gorm.DB, plain string) {
+	var token user.APIToken
+	if err := db.Where("hash = ?", user.HashAPIToken(plain)).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			abort(c, apperror.New(apperror.CodeUnauthorized, ""))
+		} else {
+			abort(c, apperror.Wrap(apperror.CodeInternal, "Failed to load API token", err))
+		}
+		return
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil {
+		abort(c, apperror.New(apperror.CodeUnauthorized, "API token has been revoked"))
+		return
+	}
+	if token.Expired(now) {
+		abort(c, apperror.New(apperror.CodeTokenExpired, "API token has expired"))
+		return
+	}
+
+	current, ok := loadUser(c, db, token.UserID)
+	if !ok {
+		return
+	}
+
+	scope, ok := requiredScope(c.Request.Method, c.FullPath())
+	if !ok {
+		abort(c, apperror.New(apperror.CodeInsufficientScope, "API tokens cannot access this endpoint"))
+		return
+	}
+	if !token.HasScope(scope) {
+		abort(c, apperror.New(apperror.CodeInsufficientScope, fmt.Sprintf("API token requires the %s scope", scope)))
+		return
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedInterval {
+		// 最近使用时间仅供用户参考，写入失败不影响本次请求
+		if db.Model(&token).UpdateColumn("last_used_at", now).Error == nil {
+			token.LastUsedAt = &now
+		}
+	}
+
+	c.Set(currentUserKey, current)
+	c.Next()
+}
+
+// loadUser 加载令牌所属的用户，失败时已写入错误响应
+func loadUser(c *gin.Context, db *gorm.DB, userID uint) (*user.User, bool) {
+	var current user.User
+	if err := db.First(&current, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 用户已被删除，令牌随之失效
+			abort(c, apperror.New(apperror.CodeUnauthorized, ""))
+		} else {
+			abort(c, apperror.Wrap(apperror.CodeInternal, "Failed to load user", err))
+		}
+		return nil, false
+	}
+	return &current, true
+}
+
+// CurrentUser 返回当前登录用户，匿名访问时返回 false
+func CurrentUser(c *gin.Context) (*user.User, bool) {
+	value, ok := c.Get(currentUserKey)
+	if !ok {
+		return nil, false
+	}
+	current, ok := value.(*user.User)
+	return current, ok
+}
+
+// SetCurrentUser 写入当前用户，供测试和其它认证方式使用
+func SetCurrentUser(c *gin.Context, current *user.User) {
+	c.Set(currentUserKey, current)
+}
+
+// queryTokenRoutes 接受 access_token 查询参数的路由模板后缀，即房间的 WebSocket 和 SSE 连接
+var queryTokenRoutes = []string{"/rooms/:id/ws", "/rooms/:id/events"}
+
+// bearerToken 读取请求携带的令牌，fromQuery 表示令牌来自查询参数
+func bearerToken(c *gin.Context) (token string, fromQuery bool) {
+	header := c.GetHeader("Authorization")
+	if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token), false
+	}
+	for _, route := range queryTokenRoutes {
+		if strings.HasSuffix(c.FullPath(), route) {
+			token = c.Query("access_token")
+			return token, token != ""
+		}
+	}
+	return "", false
+}
+
+func abort(c *gin.Context, err *apperror.Error) {
+	response.Error(c, err)
+	c.Abort()
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"log"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,7 +12,7 @@ func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		query := redactQuery(c.Request.URL.RawQuery)
 
 		c.Next()
 
@@ -27,3 +28,23 @@ func Logger() gin.HandlerFunc {
 		log.Printf("[%s] %s %s %d %v %s", method, path, ip, status, latency, c.Request.UserAgent())
 	}
 }
+
+// redactedParams 不能写入日志的查询参数
+var redactedParams = []string{"access_token"}
+
+// redactQuery 把查询字符串中的令牌替换为 REDACTED，其余参数保持原样
+func redactQuery(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	params := strings.Split(raw, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		for _, name := range redactedParams {
+			if key == name {
+				params[i] = key + "=REDACTED"
+			}
+		}
+	}
+	return strings.Join(params, "&")
+}
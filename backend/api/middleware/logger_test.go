@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactQuery(t *testing.T) {
+	assert.Equal(t, "", redactQuery(""))
+	assert.Equal(t, "since=3", redactQuery("since=3"))
+	assert.Equal(t, "since=3&access_token=REDACTED", redactQuery("since=3&access_token=eyJhbGci.secret"))
+	assert.Equal(t, "access_token=REDACTED&x=1", redactQuery("access_token=tpat_secret&x=1"))
+}
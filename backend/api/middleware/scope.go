@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"trpg-sync/backend/domain/user"
+)
+
+// scopeRule 路由对应的权限范围；method 为空时匹配所有方法，exact 为 true 时不匹配子路径
+// scope 不为空时直接使用，否则按请求方法取 resource:read 或 resource:write
+type scopeRule struct {
+	method   string
+	prefix   string
+	exact    bool
+	resource string
+	scope    string
+}
+
+// scopeRules 按路由模板确定 API 令牌访问的资源，同一路径下越具体的规则越靠前
+// 未匹配的路由（令牌管理、个人资料、全文搜索等）不接受 API 令牌，避免令牌自行创建新令牌
+var scopeRules = []scopeRule{
+	{prefix: "/rooms/:id/rolls", resource: "rolls"},
+	{prefix: "/rooms/:id/encounters", resource: "encounters"},
+	{prefix: "/rooms/:id/encounter-difficulty", resource: "encounters"},
+	{prefix: "/rooms/:id/statblocks", resource: "encounters"},
+	{prefix: "/rooms/:id/party", resource: "characters"},
+	{prefix: "/rooms/:id/character-options", resource: "compendium"},
+	// 查看成员、加入和退出房间需要 members 权限；踢出成员、转移 DM、密码、邀请码和解散房间需要 rooms:admin
+	{prefix: "/rooms/:id/members", resource: "members"},
+	{prefix: "/rooms/:id/join", resource: "members"},
+	{prefix: "/rooms/:id/leave", resource: "members"},
+	{method: http.MethodGet, prefix: "/rooms/:id/transfer", resource: "members"},
+	{prefix: "/rooms/:id/transfer", scope: user.ScopeRoomsAdmin},
+	{prefix: "/rooms/:id/kick", scope: user.ScopeRoomsAdmin},
+	{prefix: "/rooms/:id/invite", scope: user.ScopeRoomsAdmin},
+	{prefix: "/rooms/:id/password", scope: user.ScopeRoomsAdmin},
+	{method: http.MethodDelete, prefix: "/rooms/:id", exact: true, scope: user.ScopeRoomsAdmin},
+	{prefix: "/rooms", resource: "rooms"},
+	{prefix: "/templates", resource: "rooms"},
+	{prefix: "/characters", resource: "characters"},
+	{prefix: "/compendium", resource: "compendium"},
+	{prefix: "/homebrew", resource: "compendium"},
+	{prefix: "/audit", resource: "audit"},
+}
+
+// matches 判断规则是否适用于请求
+func (r scopeRule) matches(method, path string) bool {
+	if r.method != "" && r.method != method {
+		return false
+	}
+	if path == r.prefix {
+		return true
+	}
+	return !r.exact && strings.HasPrefix(path, r.prefix+"/")
+}
+
+// requiredScope 返回访问路由所需的权限范围，GET 请求需要 资源:read，其它请求需要 资源:write
+// fullPath 为 gin 的路由模板，忽略 /api/v1 前缀
+func requiredScope(method, fullPath string) (string, bool) {
+	path := strings.TrimPrefix(fullPath, "/api/v1")
+	for _, rule := range scopeRules {
+		if !rule.matches(method, path) {
+			continue
+		}
+		if rule.scope != "" {
+			return rule.scope, true
+		}
+		if method == http.MethodGet || method == http.MethodHead {
+			return rule.resource + ":read", true
+		}
+		return rule.resource + ":write", true
+	}
+	return "", false
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		scope  string
+	}{
+		{"GET", "/api/v1/rooms/:id", "rooms:read"},
+		{"PATCH", "/api/v1/rooms/:id", "rooms:write"},
+		{"DELETE", "/api/v1/rooms/:id", "rooms:admin"},
+		{"DELETE", "/api/v1/rooms/:id/statblocks/:blockId", "encounters:write"},
+		{"GET", "/api/v1/rooms/:id/members", "members:read"},
+		{"POST", "/api/v1/rooms/:id/join", "members:write"},
+		{"POST", "/api/v1/rooms/:id/leave", "members:write"},
+		{"POST", "/api/v1/rooms/:id/kick/:userId", "rooms:admin"},
+		{"GET", "/api/v1/rooms/:id/transfer", "members:read"},
+		{"POST", "/api/v1/rooms/:id/transfer", "rooms:admin"},
+		{"POST", "/api/v1/rooms/:id/transfer/accept", "rooms:admin"},
+		{"DELETE", "/api/v1/rooms/:id/transfer", "rooms:admin"},
+		{"GET", "/api/v1/rooms/:id/invite", "rooms:admin"},
+		{"POST", "/api/v1/rooms/:id/invite/rotate", "rooms:admin"},
+		{"PUT", "/api/v1/rooms/:id/password", "rooms:admin"},
+		{"POST", "/api/v1/rooms/:id/rolls", "rolls:write"},
+	}
+	for _, tt := range tests {
+		scope, ok := requiredScope(tt.method, tt.path)
+		assert.True(t, ok, tt.method+" "+tt.path)
+		assert.Equal(t, tt.scope, scope, tt.method+" "+tt.path)
+	}
+
+	_, ok := requiredScope("POST", "/api/v1/user/tokens")
+	assert.False(t, ok)
+}
@@ -0,0 +1,52 @@
+package response
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"trpg-sync/backend/domain/apperror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Body 统一响应结构：code 为 HTTP 状态码，error_code 为应用错误码（成功时省略）
+type Body struct {
+	Code      int           `json:"code"`
+	ErrorCode apperror.Code `json:"error_code,omitempty"`
+	Message   string        `json:"message"`
+	Data      interface{}   `json:"data"`
+}
+
+// Success 返回 200 和默认消息
+func Success(c *gin.Context, data interface{}) {
+	OK(c, "Success", data)
+}
+
+// OK 返回 200 和指定消息
+func OK(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusOK, Body{
+		Code:    http.StatusOK,
+		Message: message,
+		Data:    data,
+	})
+}
+
+// Error 按应用错误码返回错误响应；非应用错误一律视为内部错误，不向客户端暴露细节
+func Error(c *gin.Context, err error) {
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) {
+		appErr = apperror.Wrap(apperror.CodeInternal, "", err)
+	}
+
+	status := appErr.Status()
+	if status >= http.StatusInternalServerError && appErr.Err != nil {
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, appErr)
+	}
+
+	c.JSON(status, Body{
+		Code:      status,
+		ErrorCode: appErr.Code,
+		Message:   appErr.Message,
+		Data:      appErr.Data,
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/audit"
+	"trpg-sync/backend/domain/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AuditHandler struct {
+	db *gorm.DB
+}
+
+func NewAuditHandler(db *gorm.DB) *AuditHandler {
+	return &AuditHandler{db: db}
+}
+
+// GetAuditLog 查询审计日志，按时间倒序分页；只返回当前用户可以查看的记录
+// room_id、actor_id、entity_id 为 ID 精确匹配，entity_type 为 room、member 或 character，
+// action 为事件名称精确匹配（如 character.updated），since、until 为 RFC 3339 时间，限定记录时间范围
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	page, errs := parsePagination(c)
+
+	ids := map[string]uint{}
+	for _, name := range []string{"room_id", "actor_id", "entity_id"} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			errs = append(errs, validation.NewError(name, validation.CodeInvalidType, map[string]interface{}{"type": "integer"}))
+			continue
+		}
+		ids[name] = uint(id)
+	}
+
+	entityType := strings.TrimSpace(c.Query("entity_type"))
+	if entityType != "" && !audit.IsEntityType(entityType) {
+		errs = append(errs, validation.NewError("entity_type", validation.CodeInvalidChoice, map[string]interface{}{
+			"choices": strings.Join(audit.EntityTypes, ", "),
+		}))
+	}
+
+	times := map[string]time.Time{}
+	for _, name := range []string{"since", "until"} {
+		raw := c.Query(name)
+		if raw == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			errs = append(errs, validation.NewError(name, validation.CodeInvalidType, map[string]interface{}{"type": "RFC 3339 time"}))
+			continue
+		}
+		times[name] = at
+	}
+	if len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+
+	filters := func(db *gorm.DB) *gorm.DB {
+		db = db.Model(&audit.Entry{}).Scopes(visibleAudit(currentUserID(c)))
+		for name, id := range ids {
+			db = db.Where("audit_log."+name+" = ?", id)
+		}
+		if entityType != "" {
+			db = db.Where("audit_log.entity_type = ?", entityType)
+		}
+		if action := strings.TrimSpace(c.Query("action")); action != "" {
+			db = db.Where("audit_log.action = ?", action)
+		}
+		if since, ok := times["since"]; ok {
+			db = db.Where("audit_log.created_at >= ?", since)
+		}
+		if until, ok := times["until"]; ok {
+			db = db.Where("audit_log.created_at < ?", until)
+		}
+		return db
+	}
+
+	var total int64
+	if err := h.db.Scopes(filters).Count(&total).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to query audit log", err))
+		return
+	}
+
+	var entries []audit.Entry
+	if err := h.db.Scopes(filters).
+		Order("audit_log.id DESC").
+		Limit(page.PageSize).
+		Offset(page.Offset).
+		Find(&entries).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to query audit log", err))
+		return
+	}
+
+	response.Success(c, newPageResult(entries, total, page))
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/audit"
+	"trpg-sync/backend/domain/room"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditPage 以 as 的身份查询审计日志
+func (s *memberTestServer) auditPage(t *testing.T, as, query string) PageResult[audit.Entry] {
+	rec, _ := s.do(t, as, "GET", "/audit"+query, nil)
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	var resp struct {
+		Data PageResult[audit.Entry] `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.Data
+}
+
+func actions(entries []audit.Entry) []string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Action
+	}
+	return names
+}
+
+func TestAuditHandler_RecordsMutations(t *testing.T) {
+	s := setupMemberServer(t, "dm", "alice", "mallory")
+
+	roomID := uint(330)
+	path := NewCharacterHandler(s.db).storage.GetRoomCharactersPath(roomID)
+	require.NoError(t, os.RemoveAll(path))
+	defer os.RemoveAll(path)
+	// 新建的房间 ID 接着已有的最大 ID，避免人物卡目录与其它测试冲突
+	require.NoError(t, s.db.Create(&room.Room{ID: roomID - 1, Name: "Seed Room"}).Error)
+	target := s.createRoom(t, "dm", map[string]interface{}{"name": "Vox Machina", "ability_method": room.AbilityMethodFree})
+	require.Equal(t, roomID, target.ID)
+	s.joinAll(t, roomID, "alice")
+
+	card := map[string]interface{}{
+		"name": "Grog", "level": 5, "hp": 52, "max_hp": 52,
+		"strength": 18, "dexterity": 12, "constitution": 16, "intelligence": 6, "wisdom": 8, "charisma": 10,
+	}
+	rec, _ := s.do(t, "alice", "POST", fmt.Sprintf("/characters/%d", roomID), card)
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	card["hp"] = 12
+	rec, _ = s.do(t, "alice", "PUT", fmt.Sprintf("/characters/%d/1", roomID), card)
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+
+	t.Run("character diff", func(t *testing.T) {
+		page := s.auditPage(t, "dm", "?entity_type=character&entity_id=1")
+		require.Equal(t, []string{"character.updated", "character.created"}, actions(page.Items))
+
+		updated := page.Items[0]
+		assert.Equal(t, s.users["alice"], updated.ActorID)
+		assert.Equal(t, roomID, updated.RoomID)
+		assert.Equal(t, "192.0.2.1", updated.IP)
+		assert.Equal(t, map[string]audit.Change{"hp": {Before: float64(52), After: float64(12)}}, updated.Changes)
+		assert.WithinDuration(t, time.Now(), updated.CreatedAt, time.Minute)
+
+		created := page.Items[1]
+		assert.Nil(t, created.Changes["name"].Before)
+		assert.Equal(t, "Grog", created.Changes["name"].After)
+	})
+
+	t.Run("visibility", func(t *testing.T) {
+		// DM 看到房间内的全部记录，玩家只看到自己的操作，非成员看不到
+		assert.Equal(t, []string{"character.updated", "character.created", "member.joined", "room.created"},
+			actions(s.auditPage(t, "dm", fmt.Sprintf("?room_id=%d", roomID)).Items))
+		assert.Equal(t, []string{"character.updated", "character.created", "member.joined"},
+			actions(s.auditPage(t, "alice", fmt.Sprintf("?room_id=%d", roomID)).Items))
+		assert.Empty(t, s.auditPage(t, "mallory", fmt.Sprintf("?room_id=%d", roomID)).Items)
+	})
+
+	t.Run("room deletion", func(t *testing.T) {
+		rec, _ := s.do(t, "dm", "DELETE", roomURL(roomID, ""), nil)
+		require.Equal(t, 200, rec.Code)
+
+		page := s.auditPage(t, "dm", "?action=room.deleted")
+		require.Len(t, page.Items, 1)
+		deleted := page.Items[0]
+		assert.Equal(t, s.users["dm"], deleted.ActorID)
+		assert.Equal(t, "Vox Machina", deleted.Changes["name"].Before)
+		assert.Nil(t, deleted.Changes["name"].After)
+
+		// 房间删除后，其他成员只能看到自己的操作
+		assert.Empty(t, s.auditPage(t, "alice", "?action=room.deleted").Items)
+		assert.Empty(t, s.auditPage(t, "mallory", "").Items)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		page := s.auditPage(t, "dm", fmt.Sprintf("?actor_id=%d&page_size=2", s.users["dm"]))
+		assert.Equal(t, int64(2), page.Total)
+		assert.Equal(t, []string{"room.deleted", "room.created"}, actions(page.Items))
+
+		future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		assert.Empty(t, s.auditPage(t, "dm", "?since="+future).Items)
+		assert.Empty(t, s.auditPage(t, "dm", "?until="+past).Items)
+		assert.Len(t, s.auditPage(t, "dm", "?since="+past+"&until="+future).Items, 2)
+
+		for _, query := range []string{"?room_id=abc", "?entity_type=dragon", "?since=yesterday"} {
+			rec, resp := s.do(t, "dm", "GET", "/audit"+query, nil)
+			assert.Equal(t, 400, rec.Code, query)
+			assert.Equal(t, apperror.CodeValidationFailed, resp.ErrorCode, query)
+		}
+	})
+}
+
+func TestAuditHandler_OpenRoomVisibility(t *testing.T) {
+	s := setupMemberServer(t, "alice", "mallory")
+
+	// 没有 DM 的房间只对成员开放审计日志，匿名用户看不到 actor_id 为 0 的记录
+	open := room.Room{ID: 340, Name: "Open Table"}
+	require.NoError(t, s.db.Create(&open).Error)
+	require.NoError(t, s.db.Create(&room.Member{RoomID: open.ID, UserID: s.users["alice"], Role: room.RolePlayer, JoinedAt: time.Now()}).Error)
+	require.NoError(t, s.db.Create(&audit.Entry{RoomID: open.ID, Action: "room.updated", EntityType: audit.EntityRoom, EntityID: open.ID}).Error)
+
+	assert.Equal(t, []string{"room.updated"}, actions(s.auditPage(t, "alice", "").Items))
+	assert.Empty(t, s.auditPage(t, "mallory", "").Items)
+	assert.Empty(t, s.auditPage(t, "", "").Items)
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"trpg-sync/backend/api/middleware"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/user"
+	"trpg-sync/backend/infrastructure/auth"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AuthHandler struct {
+	db     *gorm.DB
+	tokens *auth.Issuer
+}
+
+func NewAuthHandler(db *gorm.DB, tokens *auth.Issuer) *AuthHandler {
+	return &AuthHandler{db: db, tokens: tokens}
+}
+
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+	Nickname string `json:"nickname" binding:"required"`
+	Avatar   string `json:"avatar"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// AuthResponse 注册和登录返回当前用户和令牌
+type AuthResponse struct {
+	User   *user.User     `json:"user"`
+	Tokens auth.TokenPair `json:"tokens"`
+}
+
+// Register 注册账号，成功后直接返回令牌，无需再登录
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req RegisterRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	newUser := user.User{
+		Email:    user.NormalizeEmail(req.Email),
+		Nickname: strings.TrimSpace(req.Nickname),
+		Avatar:   req.Avatar,
+	}
+	errs := newUser.Validate()
+	errs = append(errs, user.ValidatePassword("password", req.Password)...)
+	if len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+
+	var count int64
+	if err := h.db.Model(&user.User{}).Where("email = ?", newUser.Email).Count(&count).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to register", err))
+		return
+	}
+	if count > 0 {
+		response.Error(c, apperror.New(apperror.CodeEmailTaken, ""))
+		return
+	}
+
+	if err := newUser.SetPassword(req.Password); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to register", err))
+		return
+	}
+	if err := h.db.Create(&newUser).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to register", err))
+		return
+	}
+
+	h.respondTokens(c, "Registered successfully", &newUser)
+}
+
+// Login 邮箱密码登录，邮箱不存在和密码错误返回同一个错误码
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	var existing user.User
+	err := h.db.Where("email = ?", user.NormalizeEmail(req.Email)).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user.CompareDummy(req.Password)
+		response.Error(c, apperror.New(apperror.CodeInvalidCredentials, ""))
+		return
+	}
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to login", err))
+		return
+	}
+	if !existing.CheckPassword(req.Password) {
+		response.Error(c, apperror.New(apperror.CodeInvalidCredentials, ""))
+		return
+	}
+
+	h.respondTokens(c, "Logged in successfully", &existing)
+}
+
+// Refresh 用刷新令牌换取新的访问令牌和刷新令牌
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, err := h.tokens.Parse(req.RefreshToken, auth.TokenRefresh)
+	if errors.Is(err, auth.ErrTokenExpired) {
+		response.Error(c, apperror.New(apperror.CodeTokenExpired, ""))
+		return
+	}
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeUnauthorized, ""))
+		return
+	}
+	userID, _ := claims.UserID()
+
+	var existing user.User
+	if err := h.db.First(&existing, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.Error(c, apperror.New(apperror.CodeUnauthorized, ""))
+			return
+		}
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to refresh token", err))
+		return
+	}
+
+	h.respondTokens(c, "Token refreshed successfully", &existing)
+}
+
+// GetProfile 返回当前登录用户，未登录（包括单人本地模式下未携带令牌）时返回 401
+func (h *AuthHandler) GetProfile(c *gin.Context) {
+	current, ok := middleware.CurrentUser(c)
+	if !ok {
+		response.Error(c, apperror.New(apperror.CodeUnauthorized, ""))
+		return
+	}
+	response.Success(c, current)
+}
+
+func (h *AuthHandler) respondTokens(c *gin.Context, message string, u *user.User) {
+	tokens, err := h.tokens.Issue(u.ID)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to issue token", err))
+		return
+	}
+	response.OK(c, message, AuthResponse{User: u, Tokens: tokens})
+}
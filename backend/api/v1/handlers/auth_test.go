@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"trpg-sync/backend/api/middleware"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/user"
+	"trpg-sync/backend/infrastructure/auth"
+	"trpg-sync/backend/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAuthRouter 注册认证路由，/protected 回显当前用户（匿名时为 nil）
+func setupAuthRouter(t *testing.T, required bool) (*gin.Engine, *auth.Issuer) {
+	db := testutil.SetupMigratedDB(t)
+
+	tokens := auth.NewIssuer([]byte("test-secret"), time.Hour, 24*time.Hour)
+	handler := NewAuthHandler(db, tokens)
+	router := testutil.SetupTestRouter()
+	router.POST("/auth/register", handler.Register)
+	router.POST("/auth/login", handler.Login)
+	router.POST("/auth/refresh", handler.Refresh)
+
+	protected := router.Group("", middleware.Auth(db, tokens, required))
+	protected.GET("/user/profile", handler.GetProfile)
+	whoami := func(c *gin.Context) {
+		current, _ := middleware.CurrentUser(c)
+		c.JSON(http.StatusOK, gin.H{"user": current})
+	}
+	protected.GET("/protected", whoami)
+	protected.GET("/rooms/:id/events", whoami)
+	return router, tokens
+}
+
+type authResult struct {
+	Code      int           `json:"code"`
+	ErrorCode apperror.Code `json:"error_code"`
+	Data      AuthResponse  `json:"data"`
+}
+
+func postAuth(t *testing.T, router *gin.Engine, path string, body interface{}) (*httptest.ResponseRecorder, authResult) {
+	req, err := testutil.MakeJSONRequest("POST", path, body)
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var result authResult
+	require.NoError(t, testutil.ParseResponse(rec, &result))
+	return rec, result
+}
+
+func getWithToken(router *gin.Engine, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthHandler_RegisterAndLogin(t *testing.T) {
+	router, _ := setupAuthRouter(t, true)
+
+	rec, registered := postAuth(t, router, "/auth/register", gin.H{
+		"email": " Player@Example.com ", "password": "correct horse", "nickname": "Player",
+	})
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	assert.Equal(t, "player@example.com", registered.Data.User.Email)
+	assert.NotEmpty(t, registered.Data.Tokens.AccessToken)
+	assert.NotContains(t, rec.Body.String(), "correct horse")
+	assert.NotContains(t, rec.Body.String(), `"password"`)
+
+	t.Run("duplicate email", func(t *testing.T) {
+		rec, result := postAuth(t, router, "/auth/register", gin.H{
+			"email": "PLAYER@example.com", "password": "another horse", "nickname": "Copy",
+		})
+		assert.Equal(t, 409, rec.Code)
+		assert.Equal(t, apperror.CodeEmailTaken, result.ErrorCode)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		rec, result := postAuth(t, router, "/auth/register", gin.H{
+			"email": "not-an-email", "password": "short", "nickname": "Player",
+		})
+		assert.Equal(t, 400, rec.Code)
+		assert.Equal(t, apperror.CodeValidationFailed, result.ErrorCode)
+		assert.Contains(t, rec.Body.String(), `"field":"email"`)
+		assert.Contains(t, rec.Body.String(), `"field":"password"`)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		rec, result := postAuth(t, router, "/auth/login", gin.H{"email": "player@example.com", "password": "wrong horse"})
+		assert.Equal(t, 401, rec.Code)
+		assert.Equal(t, apperror.CodeInvalidCredentials, result.ErrorCode)
+	})
+
+	t.Run("unknown email", func(t *testing.T) {
+		rec, result := postAuth(t, router, "/auth/login", gin.H{"email": "nobody@example.com", "password": "correct horse"})
+		assert.Equal(t, 401, rec.Code)
+		assert.Equal(t, apperror.CodeInvalidCredentials, result.ErrorCode)
+	})
+
+	rec, loggedIn := postAuth(t, router, "/auth/login", gin.H{"email": "PLAYER@example.com", "password": "correct horse"})
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	assert.Equal(t, registered.Data.User.ID, loggedIn.Data.User.ID)
+
+	rec = getWithToken(router, "/user/profile", loggedIn.Data.Tokens.AccessToken)
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"nickname":"Player"`)
+}
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	router, _ := setupAuthRouter(t, true)
+	_, registered := postAuth(t, router, "/auth/register", gin.H{
+		"email": "player@example.com", "password": "correct horse", "nickname": "Player",
+	})
+	tokens := registered.Data.Tokens
+
+	rec, refreshed := postAuth(t, router, "/auth/refresh", gin.H{"refresh_token": tokens.RefreshToken})
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	assert.Equal(t, 200, getWithToken(router, "/user/profile", refreshed.Data.Tokens.AccessToken).Code)
+
+	// 访问令牌不能用于刷新，刷新令牌也不能访问接口
+	rec, result := postAuth(t, router, "/auth/refresh", gin.H{"refresh_token": tokens.AccessToken})
+	assert.Equal(t, 401, rec.Code)
+	assert.Equal(t, apperror.CodeUnauthorized, result.ErrorCode)
+	assert.Equal(t, 401, getWithToken(router, "/user/profile", tokens.RefreshToken).Code)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	t.Run("required", func(t *testing.T) {
+		router, tokens := setupAuthRouter(t, true)
+
+		rec := getWithToken(router, "/protected", "")
+		assert.Equal(t, 401, rec.Code)
+		assert.Contains(t, rec.Body.String(), string(apperror.CodeUnauthorized))
+
+		assert.Equal(t, 401, getWithToken(router, "/protected", "garbage").Code)
+
+		// 令牌有效但用户不存在
+		pair, err := tokens.Issue(99)
+		require.NoError(t, err)
+		assert.Equal(t, 401, getWithToken(router, "/protected", pair.AccessToken).Code)
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		router, _ := setupAuthRouter(t, false)
+
+		rec := getWithToken(router, "/protected", "")
+		assert.Equal(t, 200, rec.Code)
+		assert.JSONEq(t, `{"user":null}`, rec.Body.String())
+
+		// 单人本地模式下个人信息仍需要登录
+		assert.Equal(t, 401, getWithToken(router, "/user/profile", "").Code)
+		// 携带了无效令牌时不会降级为匿名访问
+		assert.Equal(t, 401, getWithToken(router, "/protected", "garbage").Code)
+
+		_, registered := postAuth(t, router, "/auth/register", gin.H{
+			"email": "player@example.com", "password": "correct horse", "nickname": "Player",
+		})
+		query := func(path string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+			return rec
+		}
+
+		// 只有 WebSocket 和 SSE 路由接受查询参数中的令牌，其它路由忽略它
+		rec = query("/rooms/1/events?access_token=" + registered.Data.Tokens.AccessToken)
+		assert.Equal(t, 200, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"email":"player@example.com"`)
+		rec = query("/protected?access_token=" + registered.Data.Tokens.AccessToken)
+		assert.Equal(t, 200, rec.Code)
+		assert.JSONEq(t, `{"user":null}`, rec.Body.String())
+
+		// API 令牌不能放在查询参数中
+		assert.Equal(t, 401, query("/rooms/1/events?access_token="+user.APITokenPrefix+"abc").Code)
+	})
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+	"trpg-sync/backend/domain/campaign"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/homebrew"
+	"trpg-sync/backend/domain/monster"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/infrastructure/search"
+	"trpg-sync/backend/infrastructure/storage"
+
+	"gorm.io/gorm"
+)
+
+// roomCopier 生成房间内容快照，并由快照创建新房间；克隆房间和战役模板共用
+type roomCopier struct {
+	db      *gorm.DB
+	storage *storage.CharacterStorage
+	search  *search.Index
+}
+
+func newRoomCopier(db *gorm.DB) *roomCopier {
+	return &roomCopier{
+		db:      db,
+		storage: storage.NewCharacterStorage().WithIndex(storage.NewCharacterIndex(db)),
+		search:  search.NewIndex(db),
+	}
+}
+
+// snapshot 读取房间中 opts 指定的内容
+func (rc *roomCopier) snapshot(source *room.Room, opts campaign.Options) (campaign.Snapshot, error) {
+	opts = opts.Normalize()
+	snap := campaign.Snapshot{
+		Room:       *source,
+		Characters: []character.CharacterCard{},
+		StatBlocks: []monster.StatBlock{},
+		Encounters: []encounter.Encounter{},
+		Homebrew:   []homebrew.Entry{},
+	}
+
+	if opts.Characters {
+		cards, err := rc.storage.GetRoomCharacters(source.ID)
+		if err != nil {
+			return snap, err
+		}
+		snap.Characters = append(snap.Characters, cards...)
+	}
+	if opts.StatBlocks {
+		if err := rc.db.Where("room_id = ?", source.ID).Order("id").Find(&snap.StatBlocks).Error; err != nil {
+			return snap, fmt.Errorf("failed to load stat blocks: %w", err)
+		}
+	}
+	if opts.Encounters {
+		if err := rc.db.Preload("Combatants").Where("room_id = ?", source.ID).Order("id").Find(&snap.Encounters).Error; err != nil {
+			return snap, fmt.Errorf("failed to load encounters: %w", err)
+		}
+	}
+	if opts.Homebrew {
+		if err := rc.db.Where("room_id = ?", source.ID).Order("id").Find(&snap.Homebrew).Error; err != nil {
+			return snap, fmt.Errorf("failed to load homebrew entries: %w", err)
+		}
+	}
+	return snap, nil
+}
+
+// restore 由快照创建新房间：房间设置沿用快照，状态和开团记录重置，邀请码重新生成，不设密码；
+// 数据卡、遭遇和自制内容在同一事务中写入，遭遇实例改为引用新数据卡；dmID 不为 0 时该用户成为新房间 DM
+func (rc *roomCopier) restore(snap campaign.Snapshot, name string, dmID uint) (*room.Room, error) {
+	newRoom := snap.Room
+	newRoom.ID = 0
+	newRoom.Name = name
+	newRoom.Status = room.StatusActive
+	newRoom.PlayerCount = 0
+	newRoom.LastPlayedAt = nil
+	newRoom.ArchivedAt = nil
+	newRoom.CreatedAt = time.Time{}
+	newRoom.UpdatedAt = time.Time{}
+	newRoom.Tags = append([]string{}, snap.Room.Tags...)
+	newRoom.RotateInviteCode()
+	newRoom.PasswordHash = ""
+	newRoom.HasPassword = false
+
+	err := rc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newRoom).Error; err != nil {
+			return fmt.Errorf("failed to create room: %w", err)
+		}
+		if err := addDM(tx, newRoom.ID, dmID); err != nil {
+			return fmt.Errorf("failed to add room DM: %w", err)
+		}
+
+		blockIDs := make(map[uint]uint, len(snap.StatBlocks))
+		for _, block := range snap.StatBlocks {
+			oldID := block.ID
+			block.ID = 0
+			block.RoomID = newRoom.ID
+			if err := tx.Create(&block).Error; err != nil {
+				return fmt.Errorf("failed to copy stat block: %w", err)
+			}
+			blockIDs[oldID] = block.ID
+		}
+
+		for _, enc := range snap.Encounters {
+			combatants := make([]encounter.Combatant, 0, len(enc.Combatants))
+			for _, combatant := range enc.Combatants {
+				blockID, ok := blockIDs[combatant.StatBlockID]
+				if !ok {
+					continue
+				}
+				combatant.ID = 0
+				combatant.EncounterID = 0
+				combatant.StatBlockID = blockID
+				combatants = append(combatants, combatant)
+			}
+			enc.ID = 0
+			enc.RoomID = newRoom.ID
+			enc.Combatants = combatants
+			if err := tx.Create(&enc).Error; err != nil {
+				return fmt.Errorf("failed to copy encounter: %w", err)
+			}
+		}
+
+		for _, entry := range snap.Homebrew {
+			entry.ID = 0
+			entry.RoomID = newRoom.ID
+			if err := tx.Create(&entry).Error; err != nil {
+				return fmt.Errorf("failed to copy homebrew entry: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, card := range snap.Characters {
+		card.RoomID = newRoom.ID
+		if err := rc.storage.SaveCharacter(&card); err != nil {
+			return &newRoom, err
+		}
+	}
+	if err := rc.search.Put(search.RoomDocument(&newRoom)); err != nil {
+		return &newRoom, err
+	}
+	return &newRoom, nil
+}
+
+// clone 复制房间，人物卡通过 CopyCharacter 从源房间复制文件
+func (rc *roomCopier) clone(source *room.Room, name string, opts campaign.Options, dmID uint) (*room.Room, error) {
+	withoutCharacters := opts
+	withoutCharacters.Characters = false
+	snap, err := rc.snapshot(source, withoutCharacters)
+	if err != nil {
+		return nil, err
+	}
+
+	newRoom, err := rc.restore(snap, name, dmID)
+	if err != nil {
+		return newRoom, err
+	}
+
+	if opts.Characters {
+		cards, err := rc.storage.GetRoomCharacters(source.ID)
+		if err != nil {
+			return newRoom, err
+		}
+		for _, card := range cards {
+			if _, err := rc.storage.CopyCharacter(source.ID, card.ID, newRoom.ID); err != nil {
+				return newRoom, err
+			}
+		}
+	}
+	return newRoom, nil
+}
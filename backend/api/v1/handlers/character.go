@@ -1,24 +1,47 @@
 package handlers
 
 import (
-	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
 	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/homebrew"
+	"trpg-sync/backend/domain/policy"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/validation"
+	compendiumstore "trpg-sync/backend/infrastructure/compendium"
 	"trpg-sync/backend/infrastructure/storage"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CharacterHandler struct {
+	db      *gorm.DB
 	storage *storage.CharacterStorage
+	catalog *compendiumstore.Catalog
+	events  *event.Bus
 }
 
-func NewCharacterHandler() *CharacterHandler {
+func NewCharacterHandler(db *gorm.DB) *CharacterHandler {
 	return &CharacterHandler{
-		storage: storage.NewCharacterStorage(),
+		db:      db,
+		storage: storage.NewCharacterStorage().WithIndex(storage.NewCharacterIndex(db)),
+		catalog: compendiumstore.NewCatalog(compendiumstore.Default(), db),
 	}
 }
 
+// WithEvents 设置领域事件总线，人物卡变更后发布事件
+func (h *CharacterHandler) WithEvents(bus *event.Bus) *CharacterHandler {
+	h.events = bus
+	return h
+}
+
 type CreateCharacterRequest struct {
 	Name         string `json:"name" binding:"required"`
 	Race         string `json:"race"`
@@ -41,194 +64,338 @@ type CreateCharacterRequest struct {
 	Saves        string `json:"saves"`
 	Equipment    string `json:"equipment"`
 	Spells       string `json:"spells"`
+	// AbilityBonuses 种族等来源的属性加值，按房间生成方式校验时从属性值中扣除
+	AbilityBonuses map[string]int       `json:"ability_bonuses"`
+	Conditions     []string             `json:"conditions"`
+	Resources      []character.Resource `json:"resources"`
 }
 
+// CreateCharacter 创建人物卡，创建者成为人物卡所有者
 func (h *CharacterHandler) CreateCharacter(c *gin.Context) {
 	roomIDStr := c.Param("roomId")
 	roomID, err := strconv.ParseUint(roomIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid room ID",
-			"data":    nil,
-		})
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
 		return
 	}
 
 	var req CreateCharacterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": err.Error(),
-			"data":    nil,
-		})
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	targetRoom, ok := h.loadWritableRoom(c, uint(roomID))
+	if !ok {
+		return
+	}
+	actor, ok := roomActor(c, h.db, targetRoom.ID)
+	if !ok || !authorized(c, policy.Character(actor, policy.CharacterCreate, nil)) {
 		return
 	}
 
 	// 生成新 ID
 	charID, err := h.storage.GenerateNextID(uint(roomID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to generate character ID",
-			"data":    nil,
-		})
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to generate character ID", err))
 		return
 	}
 
 	// 创建人物卡
 	newCharacter := &character.CharacterCard{
-		ID:           charID,
-		RoomID:       uint(roomID),
-		Name:         req.Name,
-		Race:         req.Race,
-		Class:        req.Class,
-		Level:        req.Level,
-		Background:   req.Background,
-		Alignment:    req.Alignment,
-		Strength:     req.Strength,
-		Dexterity:    req.Dexterity,
-		Constitution: req.Constitution,
-		Intelligence: req.Intelligence,
-		Wisdom:       req.Wisdom,
-		Charisma:     req.Charisma,
-		AC:           req.AC,
-		HP:           req.HP,
-		MaxHP:        req.MaxHP,
-		Speed:        req.Speed,
-		Proficiency:  req.Proficiency,
-		Skills:       req.Skills,
-		Saves:        req.Saves,
-		Equipment:    req.Equipment,
-		Spells:       req.Spells,
+		ID:             charID,
+		RoomID:         uint(roomID),
+		OwnerID:        actor.UserID,
+		Name:           req.Name,
+		Race:           req.Race,
+		Class:          req.Class,
+		Level:          req.Level,
+		Background:     req.Background,
+		Alignment:      req.Alignment,
+		Strength:       req.Strength,
+		Dexterity:      req.Dexterity,
+		Constitution:   req.Constitution,
+		Intelligence:   req.Intelligence,
+		Wisdom:         req.Wisdom,
+		Charisma:       req.Charisma,
+		AC:             req.AC,
+		HP:             req.HP,
+		MaxHP:          req.MaxHP,
+		Speed:          req.Speed,
+		Proficiency:    req.Proficiency,
+		Skills:         req.Skills,
+		Saves:          req.Saves,
+		Equipment:      req.Equipment,
+		Spells:         req.Spells,
+		AbilityBonuses: req.AbilityBonuses,
+		Conditions:     req.Conditions,
+		Resources:      req.Resources,
+	}
+
+	// 未填写等级时默认为 1 级
+	if newCharacter.Level == 0 {
+		newCharacter.Level = character.MinLevel
+	}
+
+	// rolled 方式使用成员在房间中的服务器掷骰并记录在人物卡上，未填写属性值时按顺序填入掷骰结果
+	if targetRoom.AbilityMethod == room.AbilityMethodRolled {
+		newCharacter.AbilityRoll, err = h.memberRoll(targetRoom.ID, actor.UserID)
+		if err != nil {
+			response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to roll ability scores", err))
+			return
+		}
+		if allZero(newCharacter.Scores()) {
+			newCharacter.AssignRolledScores()
+		}
+	}
+
+	race, ok := h.raceBonuses(c, newCharacter)
+	if !ok || !validateCharacter(c, newCharacter, targetRoom.AbilityMethod, race) {
+		return
 	}
 
 	if err := h.storage.SaveCharacter(newCharacter); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to create character",
-			"data":    nil,
-		})
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to create character", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.CharacterCreated{Card: *newCharacter})
+	response.OK(c, "Character created successfully", newCharacter)
+}
+
+type GenerateCharacterRequest struct {
+	Name       string `json:"name"`
+	Method     string `json:"method"`
+	Level      int    `json:"level"`
+	Race       string `json:"race"`
+	Subrace    string `json:"subrace"`
+	Class      string `json:"class"`
+	Background string `json:"background"`
+	Seed       *int64 `json:"seed"`
+}
+
+// GenerateCharacter 随机生成一张完整的人物卡并保存
+// 种族、职业、背景可指定 slug（含房间自制内容），未指定时随机选择；返回使用的种子以便复现
+// 客户端指定的种子只决定种族、职业等随机选择，rolled 房间的属性骰使用成员在房间中的服务器掷骰，避免反复生成刷属性
+func (h *CharacterHandler) GenerateCharacter(c *gin.Context) {
+	roomIDStr := c.Param("roomId")
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
+		return
+	}
+
+	var req GenerateCharacterRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	targetRoom, ok := h.loadWritableRoom(c, uint(roomID))
+	if !ok {
+		return
+	}
+	actor, ok := roomActor(c, h.db, targetRoom.ID)
+	if !ok || !authorized(c, policy.Character(actor, policy.CharacterCreate, nil)) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "Character created successfully",
-		"data":    newCharacter,
+	seed := time.Now().UnixNano()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+
+	// 房间限定了生成方式时按房间方式生成，rolled 方式使用服务器掷骰记录
+	method := req.Method
+	var abilityRoll *character.AbilityRoll
+	if roomMethod, restricted := generatorMethods[targetRoom.AbilityMethod]; restricted {
+		if method != "" && method != roomMethod {
+			response.Error(c, apperror.New(apperror.CodeAbilityMethodNotAllowed, ""))
+			return
+		}
+		method = roomMethod
+		if targetRoom.AbilityMethod == room.AbilityMethodRolled {
+			if abilityRoll, err = h.memberRoll(targetRoom.ID, actor.UserID); err != nil {
+				response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to roll ability scores", err))
+				return
+			}
+		}
+	}
+
+	generator := &character.Generator{}
+	for _, source := range []struct {
+		entryType compendium.EntryType
+		slug      string
+		target    *[]compendium.Entry
+	}{
+		{compendium.TypeRace, req.Race, &generator.Races},
+		{compendium.TypeClass, req.Class, &generator.Classes},
+		{compendium.TypeBackground, req.Background, &generator.Backgrounds},
+		{compendium.TypeEquipment, "", &generator.Equipment},
+	} {
+		entries, err := h.generatorEntries(source.entryType, source.slug, uint(roomID))
+		if err != nil {
+			response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get compendium entries", err))
+			return
+		}
+		*source.target = entries
+	}
+
+	newCharacter, err := generator.Generate(character.GenerateOptions{
+		Name:       req.Name,
+		Method:     method,
+		Level:      req.Level,
+		Race:       req.Race,
+		Subrace:    req.Subrace,
+		Class:      req.Class,
+		Background: req.Background,
+		Seed:       seed,
+		Values:     rollValues(abilityRoll),
 	})
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeBadRequest, err.Error()))
+		return
+	}
+	newCharacter.AbilityRoll = abilityRoll
+
+	charID, err := h.storage.GenerateNextID(uint(roomID))
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to generate character ID", err))
+		return
+	}
+	newCharacter.ID = charID
+	newCharacter.RoomID = uint(roomID)
+	newCharacter.OwnerID = actor.UserID
+
+	race, ok := h.raceBonuses(c, newCharacter)
+	if !ok || !validateCharacter(c, newCharacter, targetRoom.AbilityMethod, race) {
+		return
+	}
+
+	if err := h.storage.SaveCharacter(newCharacter); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to create character", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.CharacterCreated{Card: *newCharacter})
+	response.OK(c, "Character generated successfully", gin.H{
+		"character": newCharacter,
+		"seed":      seed,
+	})
+}
+
+// raceBonuses 在资料库（含房间自制内容）中查找人物卡种族提供的属性加值，找不到种族时不允许任何加值
+func (h *CharacterHandler) raceBonuses(c *gin.Context, card *character.CharacterCard) (character.RaceBonuses, bool) {
+	races, err := h.catalog.List(compendium.TypeRace, card.RoomID)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get compendium entries", err))
+		return character.RaceBonuses{}, false
+	}
+	bonuses, _ := character.FindRaceBonuses(races, card.Race)
+	return bonuses, true
 }
 
+// generatorEntries 返回生成器的候选条目；指定 slug 时按资料库优先级只取该条目
+func (h *CharacterHandler) generatorEntries(t compendium.EntryType, slug string, roomID uint) ([]compendium.Entry, error) {
+	if slug == "" {
+		return h.catalog.List(t, roomID)
+	}
+
+	entry, found, err := h.catalog.Get(t, slug, roomID)
+	if err != nil || !found {
+		return nil, err
+	}
+	return []compendium.Entry{entry}, nil
+}
+
+// GetCharacters 返回房间中当前用户可以查看的人物卡：DM 可以看到全部，玩家只能看到自己的
 func (h *CharacterHandler) GetCharacters(c *gin.Context) {
 	roomIDStr := c.Param("roomId")
 	roomID, err := strconv.ParseUint(roomIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid room ID",
-			"data":    nil,
-		})
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
+		return
+	}
+
+	targetRoom, ok := h.loadRoom(c, uint(roomID))
+	if !ok {
+		return
+	}
+	actor, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomView)
+	if !ok {
 		return
 	}
 
 	characters, err := h.storage.GetRoomCharacters(uint(roomID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to get characters",
-			"data":    nil,
-		})
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get characters", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "Success",
-		"data":    characters,
-	})
+	visible := make([]character.CharacterCard, 0, len(characters))
+	for i := range characters {
+		if policy.CanViewCharacter(actor, &characters[i]) {
+			visible = append(visible, characters[i])
+		}
+	}
+	response.Success(c, visible)
 }
 
 func (h *CharacterHandler) GetCharacter(c *gin.Context) {
 	roomIDStr := c.Param("roomId")
 	roomID, err := strconv.ParseUint(roomIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid room ID",
-			"data":    nil,
-		})
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
 		return
 	}
 
 	characterIDStr := c.Param("charId")
 	characterID, err := strconv.ParseUint(characterIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid character ID",
-			"data":    nil,
-		})
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid character ID"))
 		return
 	}
 
-	char, err := h.storage.LoadCharacter(uint(roomID), uint(characterID))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"code":    404,
-			"message": "Character not found",
-			"data":    nil,
-		})
+	targetRoom, ok := h.loadRoom(c, uint(roomID))
+	if !ok {
+		return
+	}
+	actor, char, ok := h.loadCharacter(c, targetRoom, uint(characterID))
+	if !ok {
+		return
+	}
+	if !authorized(c, policy.Character(actor, policy.CharacterView, char)) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "Success",
-		"data":    char,
-	})
+	response.Success(c, char)
 }
 
 func (h *CharacterHandler) UpdateCharacter(c *gin.Context) {
 	roomIDStr := c.Param("roomId")
 	roomID, err := strconv.ParseUint(roomIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid room ID",
-			"data":    nil,
-		})
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
 		return
 	}
 
 	characterIDStr := c.Param("charId")
 	characterID, err := strconv.ParseUint(characterIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid character ID",
-			"data":    nil,
-		})
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid character ID"))
 		return
 	}
 
-	// 加载现有人物卡
-	targetCharacter, err := h.storage.LoadCharacter(uint(roomID), uint(characterID))
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"code":    404,
-			"message": "Character not found",
-			"data":    nil,
-		})
+	targetRoom, ok := h.loadWritableRoom(c, uint(roomID))
+	if !ok {
+		return
+	}
+	actor, targetCharacter, ok := h.loadCharacter(c, targetRoom, uint(characterID))
+	if !ok || !h.authorizeCharacter(c, actor, policy.CharacterUpdate, targetCharacter) {
 		return
 	}
+	before := targetCharacter.Clone()
 
 	var req CreateCharacterRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": err.Error(),
-			"data":    nil,
-		})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -254,58 +421,491 @@ func (h *CharacterHandler) UpdateCharacter(c *gin.Context) {
 	targetCharacter.Saves = req.Saves
 	targetCharacter.Equipment = req.Equipment
 	targetCharacter.Spells = req.Spells
+	targetCharacter.AbilityBonuses = req.AbilityBonuses
+	targetCharacter.Conditions = req.Conditions
+	targetCharacter.Resources = req.Resources
+	if targetCharacter.Level == 0 {
+		targetCharacter.Level = character.MinLevel
+	}
+
+	race, ok := h.raceBonuses(c, targetCharacter)
+	if !ok || !validateCharacter(c, targetCharacter, targetRoom.AbilityMethod, race) {
+		return
+	}
 
 	if err := h.storage.SaveCharacter(targetCharacter); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to update character",
-			"data":    nil,
-		})
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to update character", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "Character updated successfully",
-		"data":    targetCharacter,
-	})
+	h.events.PublishFrom(eventMeta(c), event.CharacterUpdated{Before: before, After: *targetCharacter})
+	response.OK(c, "Character updated successfully", targetCharacter)
 }
 
 func (h *CharacterHandler) DeleteCharacter(c *gin.Context) {
 	roomIDStr := c.Param("roomId")
 	roomID, err := strconv.ParseUint(roomIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid room ID",
-			"data":    nil,
-		})
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
 		return
 	}
 
 	characterIDStr := c.Param("charId")
 	characterID, err := strconv.ParseUint(characterIDStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": "Invalid character ID",
-			"data":    nil,
-		})
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid character ID"))
 		return
 	}
 
+	targetRoom, ok := h.loadWritableRoom(c, uint(roomID))
+	if !ok {
+		return
+	}
+
+	// 删除前读取内容，用于鉴权和发布事件
+	actor, deleted, ok := h.loadCharacter(c, targetRoom, uint(characterID))
+	if !ok || !h.authorizeCharacter(c, actor, policy.CharacterDelete, deleted) {
+		return
+	}
 	if err := h.storage.DeleteCharacter(uint(roomID), uint(characterID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to delete character",
-			"data":    nil,
+		response.Error(c, storageError("Failed to delete character", err))
+		return
+	}
+	if err := deleteShareLinks(h.db, uint(roomID), uint(characterID)); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to delete share links", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.CharacterDeleted{Room: uint(roomID), CharacterID: uint(characterID), Card: deleted})
+	response.OK(c, "Character deleted successfully", nil)
+}
+
+type AddReferenceRequest struct {
+	Type string `json:"type" binding:"required"`
+	Slug string `json:"slug" binding:"required"`
+}
+
+// AddReference 将资料库条目（含房间自制内容）以引用方式添加到人物卡
+// 法术名称同时追加到 Spells，装备和魔法物品名称追加到 Equipment
+func (h *CharacterHandler) AddReference(c *gin.Context) {
+	roomIDStr := c.Param("roomId")
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
+		return
+	}
+
+	characterIDStr := c.Param("charId")
+	characterID, err := strconv.ParseUint(characterIDStr, 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid character ID"))
+		return
+	}
+
+	targetRoom, ok := h.loadWritableRoom(c, uint(roomID))
+	if !ok {
+		return
+	}
+
+	var req AddReferenceRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	entryType, ok := compendium.ParseType(req.Type)
+	if !ok {
+		response.Error(c, apperror.New(apperror.CodeCompendiumTypeUnknown, ""))
+		return
+	}
+
+	entry, found, err := h.catalog.Get(entryType, req.Slug, uint(roomID))
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get compendium entry", err))
+		return
+	}
+	if !found {
+		response.Error(c, apperror.New(apperror.CodeCompendiumEntryNotFound, ""))
+		return
+	}
+
+	actor, targetCharacter, ok := h.loadCharacter(c, targetRoom, uint(characterID))
+	if !ok || !h.authorizeCharacter(c, actor, policy.CharacterUpdate, targetCharacter) {
+		return
+	}
+	before := targetCharacter.Clone()
+
+	if !targetCharacter.HasReference(string(entry.Type), entry.Slug) {
+		targetCharacter.References = append(targetCharacter.References, character.CompendiumRef{
+			Type:   string(entry.Type),
+			Slug:   entry.Slug,
+			Name:   entry.Name,
+			Source: entry.Source,
 		})
+
+		switch entry.Type {
+		case compendium.TypeSpell:
+			targetCharacter.Spells = appendListItem(targetCharacter.Spells, entry.Name)
+		case compendium.TypeEquipment, compendium.TypeMagicItem:
+			targetCharacter.Equipment = appendListItem(targetCharacter.Equipment, entry.Name)
+		}
+	}
+
+	if err := h.storage.SaveCharacter(targetCharacter); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to update character", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "Character deleted successfully",
-		"data":    nil,
-	})
+	h.events.PublishFrom(eventMeta(c), event.CharacterUpdated{Before: before, After: *targetCharacter})
+	response.OK(c, "Reference added successfully", targetCharacter)
+}
+
+// RemoveReference 移除人物卡上的资料库引用，文本字段保持不变
+func (h *CharacterHandler) RemoveReference(c *gin.Context) {
+	roomIDStr := c.Param("roomId")
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
+		return
+	}
+
+	characterIDStr := c.Param("charId")
+	characterID, err := strconv.ParseUint(characterIDStr, 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid character ID"))
+		return
+	}
+
+	targetRoom, ok := h.loadWritableRoom(c, uint(roomID))
+	if !ok {
+		return
+	}
+
+	actor, targetCharacter, ok := h.loadCharacter(c, targetRoom, uint(characterID))
+	if !ok || !h.authorizeCharacter(c, actor, policy.CharacterUpdate, targetCharacter) {
+		return
+	}
+	before := targetCharacter.Clone()
+
+	if !targetCharacter.RemoveReference(c.Param("type"), c.Param("slug")) {
+		response.Error(c, apperror.New(apperror.CodeReferenceNotFound, ""))
+		return
+	}
+
+	if err := h.storage.SaveCharacter(targetCharacter); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to update character", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.CharacterUpdated{Before: before, After: *targetCharacter})
+	response.OK(c, "Reference removed successfully", targetCharacter)
+}
+
+// RollAbilities 为人物卡填入成员在房间中的服务器掷骰记录（rolled 方式），已有记录时不允许重掷
+// 掷骰结果按顺序填入属性值（保留种族加值），之后可通过更新接口重新分配
+func (h *CharacterHandler) RollAbilities(c *gin.Context) {
+	roomIDStr := c.Param("roomId")
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
+		return
+	}
+
+	characterIDStr := c.Param("charId")
+	characterID, err := strconv.ParseUint(characterIDStr, 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid character ID"))
+		return
+	}
+
+	targetRoom, ok := h.loadWritableRoom(c, uint(roomID))
+	if !ok {
+		return
+	}
+	if targetRoom.AbilityMethod != room.AbilityMethodRolled {
+		response.Error(c, apperror.New(apperror.CodeAbilityMethodNotAllowed, "Room does not use rolled ability scores"))
+		return
+	}
+
+	actor, targetCharacter, ok := h.loadCharacter(c, targetRoom, uint(characterID))
+	if !ok || !h.authorizeCharacter(c, actor, policy.CharacterUpdate, targetCharacter) {
+		return
+	}
+	before := targetCharacter.Clone()
+	if targetCharacter.AbilityRoll != nil {
+		response.Error(c, apperror.New(apperror.CodeCharacterConflict, "Ability scores already rolled").WithData(targetCharacter.AbilityRoll))
+		return
+	}
+
+	targetCharacter.AbilityRoll, err = h.memberRoll(targetRoom.ID, actor.UserID)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to roll ability scores", err))
+		return
+	}
+	targetCharacter.AssignRolledScores()
+
+	if err := h.storage.SaveCharacter(targetCharacter); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to update character", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.CharacterUpdated{Before: before, After: *targetCharacter})
+	response.OK(c, "Ability scores rolled successfully", targetCharacter)
+}
+
+// memberRoll 返回成员在房间中的属性骰，第一次调用时由服务器掷骰并保存，之后始终返回同一组结果
+// 并发请求只有一个能写入，其余读取已保存的记录
+func (h *CharacterHandler) memberRoll(roomID, userID uint) (*character.AbilityRoll, error) {
+	record := character.MemberRoll{RoomID: roomID, UserID: userID, Roll: *character.RollAbilities(time.Now().UnixNano())}
+	if err := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error; err != nil {
+		return nil, err
+	}
+	if err := h.db.Where("room_id = ? AND user_id = ?", roomID, userID).First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record.Roll, nil
+}
+
+// generatorMethods 房间属性生成方式对应的随机生成方式，free 不限定
+var generatorMethods = map[string]string{
+	room.AbilityMethodPointBuy:      character.MethodPointBuy,
+	room.AbilityMethodStandardArray: character.MethodStandardArray,
+	room.AbilityMethodRolled:        character.MethodRoll,
+}
+
+// loadRoom 加载人物卡所属房间，失败时直接返回错误响应
+func (h *CharacterHandler) loadRoom(c *gin.Context, roomID uint) (*room.Room, bool) {
+	var target room.Room
+	if err := h.db.First(&target, roomID).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeRoomNotFound, err))
+		return nil, false
+	}
+	return &target, true
+}
+
+// loadCharacter 确认当前用户可以查看房间后再读取人物卡，非成员不能通过 404 和 403 判断人物卡是否存在
+func (h *CharacterHandler) loadCharacter(c *gin.Context, targetRoom *room.Room, characterID uint) (policy.Actor, *character.CharacterCard, bool) {
+	actor, ok := roomActor(c, h.db, targetRoom.ID)
+	if !ok || !authorized(c, policy.Room(actor, policy.RoomView)) {
+		return actor, nil, false
+	}
+	card, err := h.storage.LoadCharacter(targetRoom.ID, characterID)
+	if err != nil {
+		response.Error(c, storageError("Failed to load character", err))
+		return actor, nil, false
+	}
+	return actor, card, true
+}
+
+// authorizeCharacter 检查当前用户对人物卡的操作权限，无权限时直接返回错误响应
+// 已归档的人物卡不能修改，只能由所有者删除
+func (h *CharacterHandler) authorizeCharacter(c *gin.Context, actor policy.Actor, action policy.Action, card *character.CharacterCard) bool {
+	if !authorized(c, policy.Character(actor, action, card)) {
+		return false
+	}
+	if action == policy.CharacterUpdate && card.IsArchived() {
+		response.Error(c, apperror.New(apperror.CodeCharacterArchived, ""))
+		return false
+	}
+	return true
+}
+
+// loadWritableRoom 加载房间并确认未归档，所有修改人物卡的接口都要先调用
+func (h *CharacterHandler) loadWritableRoom(c *gin.Context, roomID uint) (*room.Room, bool) {
+	target, ok := h.loadRoom(c, roomID)
+	if !ok {
+		return nil, false
+	}
+	if target.IsArchived() {
+		response.Error(c, apperror.New(apperror.CodeRoomArchived, ""))
+		return nil, false
+	}
+	return target, true
+}
+
+func rollValues(roll *character.AbilityRoll) []int {
+	if roll == nil {
+		return nil
+	}
+	return roll.Values
+}
+
+func allZero(scores map[string]int) bool {
+	for _, score := range scores {
+		if score != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// appendListItem 向逗号分隔的文本字段追加一项，已存在时不重复添加
+func appendListItem(list string, item string) string {
+	for _, existing := range strings.Split(list, ",") {
+		if strings.EqualFold(strings.TrimSpace(existing), item) {
+			return list
+		}
+	}
+	if strings.TrimSpace(list) == "" {
+		return item
+	}
+	return list + ", " + item
+}
+
+// CharacterSearchHit 人物卡搜索结果，附带所在房间的信息
+type CharacterSearchHit struct {
+	character.IndexEntry
+	Room CharacterSearchRoom `json:"room"`
+}
+
+// CharacterSearchRoom 搜索结果中的房间信息
+type CharacterSearchRoom struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	RuleSystem string `json:"rule_system"`
+	Status     string `json:"status"`
+}
+
+// characterSearchRow 索引表与房间表联查的结果行
+type characterSearchRow struct {
+	character.IndexEntry
+	RoomName       string
+	RoomRuleSystem string
+	RoomStatus     string
+}
+
+// SearchCharacters 通过人物卡索引跨房间搜索
+// name、race、class 为子串匹配，alignment、rule_system 忽略大小写精确匹配，
+// level_min、level_max 限定等级范围，结果按名称排序并分页；只返回当前用户可以查看的人物卡
+func (h *CharacterHandler) SearchCharacters(c *gin.Context) {
+	page, errs := parsePagination(c)
+
+	levelMin, levelMax := character.MinLevel, character.MaxLevel
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"level_min", &levelMin}, {"level_max", &levelMax}} {
+		raw := c.Query(param.name)
+		if raw == "" {
+			continue
+		}
+		level, err := strconv.Atoi(raw)
+		if err != nil || level < character.MinLevel || level > character.MaxLevel {
+			errs = append(errs, validation.NewError(param.name, validation.CodeOutOfRange, map[string]interface{}{"min": character.MinLevel, "max": character.MaxLevel}))
+			continue
+		}
+		*param.value = level
+	}
+	if levelMin > levelMax {
+		errs = append(errs, validation.NewError("level_min", validation.CodeExceedsMax, map[string]interface{}{"other": "level_max"}))
+	}
+	if len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+
+	filters := func(db *gorm.DB) *gorm.DB {
+		db = db.Table("character_index").
+			Joins("JOIN rooms ON rooms.id = character_index.room_id").
+			Where("character_index.level BETWEEN ? AND ?", levelMin, levelMax).
+			Scopes(visibleCharacters(currentUserID(c)))
+		for _, column := range []string{"name", "race", "class"} {
+			if text := strings.TrimSpace(c.Query(column)); text != "" {
+				db = db.Where("character_index."+column+` LIKE ? ESCAPE '\'`, likePattern(text))
+			}
+		}
+		if alignment := strings.TrimSpace(c.Query("alignment")); alignment != "" {
+			db = db.Where("character_index.alignment = ? COLLATE NOCASE", alignment)
+		}
+		if ruleSystem := strings.TrimSpace(c.Query("rule_system")); ruleSystem != "" {
+			db = db.Where("rooms.rule_system = ? COLLATE NOCASE", ruleSystem)
+		}
+		return db
+	}
+
+	var total int64
+	if err := h.db.Scopes(filters).Count(&total).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to search characters", err))
+		return
+	}
+
+	var rows []characterSearchRow
+	if err := h.db.Scopes(filters).
+		Select("character_index.*, rooms.name AS room_name, rooms.rule_system AS room_rule_system, rooms.status AS room_status").
+		Order("character_index.name COLLATE NOCASE, character_index.room_id, character_index.character_id").
+		Limit(page.PageSize).
+		Offset(page.Offset).
+		Scan(&rows).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to search characters", err))
+		return
+	}
+
+	hits := make([]CharacterSearchHit, len(rows))
+	for i, row := range rows {
+		hits[i] = CharacterSearchHit{
+			IndexEntry: row.IndexEntry,
+			Room: CharacterSearchRoom{
+				ID:         row.RoomID,
+				Name:       row.RoomName,
+				RuleSystem: row.RoomRuleSystem,
+				Status:     row.RoomStatus,
+			},
+		}
+	}
+
+	response.Success(c, newPageResult(hits, total, page))
+}
+
+// GetParty 队伍总览：一次读取房间内全部人物卡，汇总生命、护甲、被动察觉、法术豁免难度、状态和剩余资源
+// 已归档的房间同样可以查看；被踢出玩家的已归档人物卡不计入队伍
+func (h *CharacterHandler) GetParty(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomView); !ok {
+		return
+	}
+
+	all, err := h.storage.GetRoomCharacters(targetRoom.ID)
+	if err != nil {
+		response.Error(c, storageError("Failed to get characters", err))
+		return
+	}
+	cards := character.ActiveCards(all)
+
+	spellcasting, err := h.spellcastingAbilities(cards, targetRoom.ID)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to load classes", err))
+		return
+	}
+
+	response.Success(c, character.NewParty(targetRoom.ID, cards, spellcasting))
+}
+
+// spellcastingAbilities 按职业名称查询施法属性，每个职业只查一次资料库，未知职业视为非施法者
+func (h *CharacterHandler) spellcastingAbilities(cards []character.CharacterCard, roomID uint) (map[string]string, error) {
+	abilities := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, card := range cards {
+		if card.Class == "" || seen[card.Class] {
+			continue
+		}
+		seen[card.Class] = true
+
+		entry, found, err := h.catalog.Get(compendium.TypeClass, homebrew.Slugify(card.Class), roomID)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		var class compendium.ClassData
+		if err := entry.Decode(&class); err != nil {
+			return nil, err
+		}
+		if class.SpellcastingAbility != "" {
+			abilities[card.Class] = class.SpellcastingAbility
+		}
+	}
+	return abilities, nil
 }
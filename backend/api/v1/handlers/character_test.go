@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"net/http/httptest"
 	"os"
-	"path/filepath"
 	"testing"
 
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
 	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/validation"
 	"trpg-sync/backend/infrastructure/storage"
+	"trpg-sync/backend/testutil"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -89,11 +94,11 @@ func TestCharacterStorage_DeleteCharacter(t *testing.T) {
 
 	// 创建测试人物卡
 	char := &character.CharacterCard{
-		ID:      1,
-		RoomID:  100,
-		Name:    "Test Character",
-		Race:    "Human",
-		Class:   "Fighter",
+		ID:     1,
+		RoomID: 100,
+		Name:   "Test Character",
+		Race:   "Human",
+		Class:  "Fighter",
 	}
 
 	err := charStorage.SaveCharacter(char)
@@ -115,6 +120,7 @@ func TestCharacterStorage_DeleteCharacter(t *testing.T) {
 
 func TestCharacterStorage_GenerateNextID(t *testing.T) {
 	charStorage := storage.NewCharacterStorage()
+	require.NoError(t, os.RemoveAll(charStorage.GetRoomCharactersPath(100)))
 
 	// 空目录，应该返回 1
 	id1, err := charStorage.GenerateNextID(100)
@@ -130,3 +136,335 @@ func TestCharacterStorage_GenerateNextID(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, uint(2), id2)
 }
+
+func TestCharacterHandler_AbilityMethods(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	rooms := []room.Room{
+		{ID: 210, Name: "Free", AbilityMethod: room.AbilityMethodFree},
+		{ID: 211, Name: "Point Buy", AbilityMethod: room.AbilityMethodPointBuy},
+		{ID: 212, Name: "Standard Array", AbilityMethod: room.AbilityMethodStandardArray},
+		{ID: 213, Name: "Rolled", AbilityMethod: room.AbilityMethodRolled},
+	}
+	handler := NewCharacterHandler(db)
+	for _, r := range rooms {
+		db.Create(&r)
+		require.NoError(t, os.RemoveAll(handler.storage.GetRoomCharactersPath(r.ID)))
+		defer os.RemoveAll(handler.storage.GetRoomCharactersPath(r.ID))
+	}
+
+	router := testutil.SetupTestRouter()
+	router.POST("/characters/:roomId", handler.CreateCharacter)
+	router.PUT("/characters/:roomId/:charId", handler.UpdateCharacter)
+	router.POST("/characters/:roomId/:charId/ability-roll", handler.RollAbilities)
+	router.DELETE("/characters/:roomId/:charId", handler.DeleteCharacter)
+
+	scores := func(str, dex, con, intel, wis, cha int) map[string]interface{} {
+		return map[string]interface{}{
+			"name": "Hero", "strength": str, "dexterity": dex, "constitution": con,
+			"intelligence": intel, "wisdom": wis, "charisma": cha,
+		}
+	}
+	withFields := func(body map[string]interface{}, fields map[string]interface{}) map[string]interface{} {
+		for key, value := range fields {
+			body[key] = value
+		}
+		return body
+	}
+	withBonuses := scores(17, 14, 14, 12, 10, 8)
+	withBonuses["race"] = "Half-Orc"
+	withBonuses["ability_bonuses"] = map[string]int{"strength": 2, "constitution": 1}
+	inflated := scores(17, 17, 17, 10, 10, 10)
+	inflated["ability_bonuses"] = map[string]int{"strength": 2, "dexterity": 2, "constitution": 2}
+
+	tests := []struct {
+		name           string
+		url            string
+		body           map[string]interface{}
+		expectedStatus int
+		expectedField  string
+		expectedCode   string
+	}{
+		{name: "自由分配", url: "/characters/210", body: scores(18, 18, 18, 18, 18, 18), expectedStatus: 200},
+		{name: "自由分配超出范围", url: "/characters/210", body: scores(0, 10, 10, 10, 10, 99), expectedStatus: 400, expectedField: "strength", expectedCode: validation.CodeOutOfRange},
+		{name: "购点法合法", url: "/characters/211", body: scores(15, 15, 15, 8, 8, 8), expectedStatus: 200},
+		{name: "购点法含种族加值", url: "/characters/211", body: withBonuses, expectedStatus: 200},
+		{name: "加值与种族不符", url: "/characters/211", body: inflated, expectedStatus: 400, expectedField: "ability_bonuses.constitution", expectedCode: validation.CodeOutOfRange},
+		{name: "购点法超出预算", url: "/characters/211", body: scores(15, 15, 15, 15, 8, 8), expectedStatus: 400, expectedField: "abilities", expectedCode: validation.CodePointBuyBudget},
+		{name: "购点法单项超出范围", url: "/characters/211", body: scores(16, 8, 8, 8, 8, 8), expectedStatus: 400, expectedField: "strength", expectedCode: validation.CodePointBuyRange},
+		{name: "标准属性组", url: "/characters/212", body: scores(8, 10, 12, 13, 14, 15), expectedStatus: 200},
+		{name: "标准属性组不匹配", url: "/characters/212", body: scores(15, 15, 13, 12, 10, 8), expectedStatus: 400, expectedField: "abilities", expectedCode: validation.CodeStandardArrayMismatch},
+		{name: "房间不存在", url: "/characters/299", body: scores(10, 10, 10, 10, 10, 10), expectedStatus: 404},
+		{name: "等级超出范围", url: "/characters/210", body: withFields(scores(10, 10, 10, 10, 10, 10), map[string]interface{}{"level": 21}), expectedStatus: 400, expectedField: "level", expectedCode: validation.CodeOutOfRange},
+		{name: "生命值超过最大值", url: "/characters/210", body: withFields(scores(10, 10, 10, 10, 10, 10), map[string]interface{}{"hp": 12, "max_hp": 10}), expectedStatus: 400, expectedField: "hp", expectedCode: validation.CodeExceedsMax},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := testutil.MakeJSONRequest("POST", tt.url, tt.body)
+			require.NoError(t, err)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedField == "" {
+				return
+			}
+
+			var resp struct {
+				Data struct {
+					Errors []validation.FieldError `json:"errors"`
+				} `json:"data"`
+			}
+			require.NoError(t, testutil.ParseResponse(rec, &resp))
+			require.NotEmpty(t, resp.Data.Errors)
+			assert.Equal(t, tt.expectedField, resp.Data.Errors[0].Field)
+			assert.Equal(t, tt.expectedCode, resp.Data.Errors[0].Code)
+		})
+	}
+
+	t.Run("服务器掷骰", func(t *testing.T) {
+		req, err := testutil.MakeJSONRequest("POST", "/characters/213", map[string]interface{}{"name": "Rolled Hero"})
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, 200, rec.Code)
+
+		var resp struct {
+			Data character.CharacterCard `json:"data"`
+		}
+		require.NoError(t, testutil.ParseResponse(rec, &resp))
+		created := resp.Data
+		require.NotNil(t, created.AbilityRoll)
+		assert.Equal(t, created.AbilityRoll.Values[0], created.Strength)
+
+		// 掷骰结果可以重新分配，但不能改成其它数值
+		body := scores(created.Charisma, created.Wisdom, created.Intelligence, created.Constitution, created.Dexterity, created.Strength)
+		req, err = testutil.MakeJSONRequest("PUT", "/characters/213/1", body)
+		require.NoError(t, err)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, 200, rec.Code)
+
+		body = scores(created.Strength+1, created.Dexterity, created.Constitution, created.Intelligence, created.Wisdom, created.Charisma)
+		body["strength"] = 19
+		body["dexterity"] = 19
+		req, err = testutil.MakeJSONRequest("PUT", "/characters/213/1", body)
+		require.NoError(t, err)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, 400, rec.Code)
+		assert.Contains(t, rec.Body.String(), validation.CodeRollMismatch)
+
+		// 已有掷骰记录时不能重掷
+		req, err = testutil.MakeJSONRequest("POST", "/characters/213/1/ability-roll", nil)
+		require.NoError(t, err)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, 409, rec.Code)
+
+		// 删除后重新创建沿用同一组掷骰结果，不能借此重掷
+		req = httptest.NewRequest("DELETE", "/characters/213/1", nil)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, 200, rec.Code)
+
+		req, err = testutil.MakeJSONRequest("POST", "/characters/213", map[string]interface{}{"name": "Rerolled Hero"})
+		require.NoError(t, err)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, 200, rec.Code)
+		require.NoError(t, testutil.ParseResponse(rec, &resp))
+		require.NotNil(t, resp.Data.AbilityRoll)
+		assert.Equal(t, created.AbilityRoll.Seed, resp.Data.AbilityRoll.Seed)
+		assert.Equal(t, created.AbilityRoll.Values, resp.Data.AbilityRoll.Values)
+	})
+}
+
+func TestCharacterHandler_DeleteCharacter_NotFound(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	handler := NewCharacterHandler(db)
+	router := testutil.SetupTestRouter()
+	router.GET("/characters/:roomId/:charId", handler.GetCharacter)
+	router.DELETE("/characters/:roomId/:charId", handler.DeleteCharacter)
+
+	require.NoError(t, db.Create(&room.Room{ID: 220, Name: "Empty Room", AbilityMethod: room.AbilityMethodFree}).Error)
+	require.NoError(t, os.RemoveAll(handler.storage.GetRoomCharactersPath(220)))
+
+	for _, method := range []string{"GET", "DELETE"} {
+		req := httptest.NewRequest(method, "/characters/220/1", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, 404, rec.Code, method)
+
+		var resp response.Body
+		require.NoError(t, testutil.ParseResponse(rec, &resp))
+		assert.Equal(t, apperror.CodeCharacterNotFound, resp.ErrorCode)
+		assert.Equal(t, "Character not found", resp.Message)
+	}
+
+	req := httptest.NewRequest("DELETE", "/characters/220/abc", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp response.Body
+	require.NoError(t, testutil.ParseResponse(rec, &resp))
+	assert.Equal(t, 400, resp.Code)
+	assert.Equal(t, apperror.CodeInvalidID, resp.ErrorCode)
+}
+
+func TestCharacterHandler_SearchCharacters(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	handler := NewCharacterHandler(db)
+	router := testutil.SetupTestRouter()
+	router.GET("/characters/search", handler.SearchCharacters)
+	router.GET("/characters/:roomId", handler.GetCharacters)
+	router.DELETE("/characters/:roomId/:charId", handler.DeleteCharacter)
+
+	for _, r := range []room.Room{
+		{ID: 230, Name: "Last Year's Campaign", RuleSystem: "DND5e", AbilityMethod: room.AbilityMethodFree, Status: room.StatusPaused},
+		{ID: 231, Name: "Pathfinder Oneshot", RuleSystem: "PF2e", AbilityMethod: room.AbilityMethodFree, Status: room.StatusActive},
+	} {
+		require.NoError(t, db.Create(&r).Error)
+		require.NoError(t, os.RemoveAll(handler.storage.GetRoomCharactersPath(r.ID)))
+	}
+
+	cards := []character.CharacterCard{
+		{ID: 1, RoomID: 230, Name: "Thorin Oakenshield", Race: "Mountain Dwarf", Class: "Cleric", Level: 7, Alignment: "Lawful Good"},
+		{ID: 2, RoomID: 230, Name: "Elaria", Race: "High Elf", Class: "Wizard", Level: 7, Alignment: "Chaotic Good"},
+		{ID: 1, RoomID: 231, Name: "Brakka", Race: "Hill Dwarf", Class: "Fighter", Level: 2, Alignment: "Neutral"},
+		{ID: 3, RoomID: 230, Name: "Dain", Race: "Hill Dwarf", Class: "Cleric", Level: 12, Alignment: "lawful good"},
+	}
+	for i := range cards {
+		require.NoError(t, handler.storage.SaveCharacter(&cards[i]))
+	}
+
+	search := func(query string) (int, PageResult[CharacterSearchHit]) {
+		req := httptest.NewRequest("GET", "/characters/search"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var resp struct {
+			Data PageResult[CharacterSearchHit] `json:"data"`
+		}
+		require.NoError(t, testutil.ParseResponse(rec, &resp))
+		return rec.Code, resp.Data
+	}
+	names := func(result PageResult[CharacterSearchHit]) []string {
+		var got []string
+		for _, hit := range result.Items {
+			got = append(got, hit.Name)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"全部人物卡按名称排序", "", []string{"Brakka", "Dain", "Elaria", "Thorin Oakenshield"}},
+		{"种族和职业子串匹配", "?race=dwarf&class=cleric", []string{"Dain", "Thorin Oakenshield"}},
+		{"名称子串匹配", "?name=oaken", []string{"Thorin Oakenshield"}},
+		{"等级范围", "?level_min=5&level_max=10", []string{"Elaria", "Thorin Oakenshield"}},
+		{"阵营忽略大小写", "?alignment=LAWFUL%20GOOD", []string{"Dain", "Thorin Oakenshield"}},
+		{"按房间规则系统过滤", "?rule_system=pf2e", []string{"Brakka"}},
+		{"无匹配", "?race=halfling", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, result := search(tt.query)
+			require.Equal(t, 200, code)
+			assert.Equal(t, tt.expected, names(result))
+			assert.Equal(t, int64(len(tt.expected)), result.Total)
+		})
+	}
+
+	t.Run("结果附带房间信息", func(t *testing.T) {
+		_, result := search("?name=thorin")
+		require.Len(t, result.Items, 1)
+		hit := result.Items[0]
+		assert.Equal(t, uint(230), hit.RoomID)
+		assert.Equal(t, uint(1), hit.CharacterID)
+		assert.Equal(t, "Cleric", hit.Class)
+		assert.Equal(t, CharacterSearchRoom{ID: 230, Name: "Last Year's Campaign", RuleSystem: "DND5e", Status: room.StatusPaused}, hit.Room)
+	})
+
+	t.Run("更新和删除人物卡同步索引", func(t *testing.T) {
+		cards[1].Class = "Cleric"
+		require.NoError(t, handler.storage.SaveCharacter(&cards[1]))
+
+		req := httptest.NewRequest("DELETE", "/characters/230/1", nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, 200, rec.Code)
+
+		_, result := search("?class=cleric")
+		assert.Equal(t, []string{"Dain", "Elaria"}, names(result))
+	})
+
+	t.Run("非法等级范围", func(t *testing.T) {
+		for _, query := range []string{"?level_min=0", "?level_max=abc", "?level_min=10&level_max=5"} {
+			code, _ := search(query)
+			assert.Equal(t, 400, code, query)
+		}
+	})
+}
+
+func TestCharacterHandler_GetParty(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	handler := NewCharacterHandler(db)
+	router := testutil.SetupTestRouter()
+	router.GET("/rooms/:id/party", handler.GetParty)
+
+	roomID := uint(270)
+	require.NoError(t, db.Create(&room.Room{ID: roomID, Name: "Party Room", AbilityMethod: room.AbilityMethodFree}).Error)
+	require.NoError(t, os.RemoveAll(handler.storage.GetRoomCharactersPath(roomID)))
+	defer os.RemoveAll(handler.storage.GetRoomCharactersPath(roomID))
+
+	require.NoError(t, handler.storage.SaveCharacter(&character.CharacterCard{
+		ID: 1, RoomID: roomID, Name: "Elora", Class: "Wizard", Level: 5,
+		HP: 12, MaxHP: 27, AC: 12, Intelligence: 18, Wisdom: 12,
+		Conditions: []string{"frightened"},
+		Resources:  []character.Resource{{Name: "Arcane Recovery", Current: 0, Max: 1, Recharge: character.RechargeLongRest}},
+	}))
+	require.NoError(t, handler.storage.SaveCharacter(&character.CharacterCard{
+		ID: 2, RoomID: roomID, Name: "Thorn", Class: "Barbarian", Level: 5,
+		HP: 55, MaxHP: 55, AC: 15, Wisdom: 14, Skills: "Perception, Athletics",
+	}))
+
+	req := httptest.NewRequest("GET", "/rooms/270/party", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, 200, rec.Code)
+
+	var resp struct {
+		Data character.Party `json:"data"`
+	}
+	require.NoError(t, testutil.ParseResponse(rec, &resp))
+	require.Len(t, resp.Data.Members, 2)
+	assert.Equal(t, 67, resp.Data.TotalHP)
+	assert.Equal(t, 82, resp.Data.TotalMaxHP)
+
+	wizard := resp.Data.Members[0]
+	assert.Equal(t, "intelligence", wizard.SpellcastingAbility)
+	require.NotNil(t, wizard.SpellSaveDC)
+	assert.Equal(t, 15, *wizard.SpellSaveDC)
+	assert.Equal(t, 11, wizard.PassivePerception)
+	assert.Equal(t, []string{"frightened"}, wizard.Conditions)
+	require.Len(t, wizard.Resources, 1)
+	assert.Equal(t, "Arcane Recovery", wizard.Resources[0].Name)
+
+	barbarian := resp.Data.Members[1]
+	assert.Nil(t, barbarian.SpellSaveDC)
+	assert.Equal(t, 15, barbarian.PassivePerception)
+
+	req = httptest.NewRequest("GET", "/rooms/9999/party", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, 404, rec.Code)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"strconv"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/policy"
+	compendiumstore "trpg-sync/backend/infrastructure/compendium"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CompendiumHandler struct {
+	db      *gorm.DB
+	store   *compendiumstore.Store
+	catalog *compendiumstore.Catalog
+}
+
+func NewCompendiumHandler(db *gorm.DB) *CompendiumHandler {
+	store := compendiumstore.Default()
+	return &CompendiumHandler{
+		db:      db,
+		store:   store,
+		catalog: compendiumstore.NewCatalog(store, db),
+	}
+}
+
+// GetTypes 返回资料库支持的条目类型及内置 SRD 条目数量
+func (h *CompendiumHandler) GetTypes(c *gin.Context) {
+	counts := h.store.Counts()
+
+	types := make([]gin.H, 0, len(counts))
+	for _, t := range compendium.Types() {
+		types = append(types, gin.H{
+			"type":  t,
+			"count": counts[t],
+		})
+	}
+
+	response.Success(c, types)
+}
+
+// SearchEntries 按类型搜索资料库（包含自制内容）
+// 支持参数：q（全文搜索）、level（法术环阶）、school（法术学派）、class（可用职业）、room_id（包含该房间的自制内容）
+func (h *CompendiumHandler) SearchEntries(c *gin.Context) {
+	entryType, ok := compendium.ParseType(c.Param("type"))
+	if !ok {
+		response.Error(c, apperror.New(apperror.CodeCompendiumTypeNotFound, ""))
+		return
+	}
+
+	roomID, ok := parseRoomIDQuery(c)
+	if !ok || !authorizeRoomScope(c, h.db, roomID, policy.RoomView) {
+		return
+	}
+
+	query := compendium.Query{
+		Text:   c.Query("q"),
+		School: c.Query("school"),
+		Class:  c.Query("class"),
+	}
+
+	if levelStr := c.Query("level"); levelStr != "" {
+		level, err := strconv.Atoi(levelStr)
+		if err != nil {
+			response.Error(c, apperror.New(apperror.CodeBadRequest, "Invalid spell level"))
+			return
+		}
+		query.Level = &level
+	}
+
+	entries, err := h.catalog.List(entryType, roomID)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to search compendium", err))
+		return
+	}
+
+	response.Success(c, compendium.Search(entries, query))
+}
+
+// GetEntry 获取单个资料库条目，room_id 参数用于查找房间自制内容
+func (h *CompendiumHandler) GetEntry(c *gin.Context) {
+	entryType, ok := compendium.ParseType(c.Param("type"))
+	if !ok {
+		response.Error(c, apperror.New(apperror.CodeCompendiumTypeNotFound, ""))
+		return
+	}
+
+	roomID, ok := parseRoomIDQuery(c)
+	if !ok || !authorizeRoomScope(c, h.db, roomID, policy.RoomView) {
+		return
+	}
+
+	entry, found, err := h.catalog.Get(entryType, c.Param("slug"), roomID)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get compendium entry", err))
+		return
+	}
+	if !found {
+		response.Error(c, apperror.New(apperror.CodeCompendiumEntryNotFound, ""))
+		return
+	}
+
+	response.Success(c, entry)
+}
+
+// CharacterOption 创建人物卡时可选的种族、职业、子职业或背景
+type CharacterOption struct {
+	Slug    string   `json:"slug"`
+	Name    string   `json:"name"`
+	Source  string   `json:"source"`
+	Classes []string `json:"classes,omitempty"`
+}
+
+// GetCharacterOptions 返回房间创建人物卡时可选的种族、职业、子职业和背景（SRD + 自制内容）
+func (h *CompendiumHandler) GetCharacterOptions(c *gin.Context) {
+	roomIDStr := c.Param("id")
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
+		return
+	}
+	if !authorizeRoomScope(c, h.db, uint(roomID), policy.RoomView) {
+		return
+	}
+
+	options := gin.H{}
+	for _, t := range []compendium.EntryType{
+		compendium.TypeRace,
+		compendium.TypeClass,
+		compendium.TypeSubclass,
+		compendium.TypeBackground,
+	} {
+		entries, err := h.catalog.List(t, uint(roomID))
+		if err != nil {
+			response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get character options", err))
+			return
+		}
+
+		items := make([]CharacterOption, 0, len(entries))
+		for _, entry := range compendium.Search(entries, compendium.Query{}) {
+			items = append(items, CharacterOption{
+				Slug:    entry.Slug,
+				Name:    entry.Name,
+				Source:  entry.Source,
+				Classes: entry.Classes,
+			})
+		}
+		options[string(t)] = items
+	}
+
+	response.Success(c, options)
+}
+
+// parseRoomIDQuery 解析可选的 room_id 查询参数，解析失败时直接返回 400
+func parseRoomIDQuery(c *gin.Context) (uint, bool) {
+	roomIDStr := c.Query("room_id")
+	if roomIDStr == "" {
+		return 0, true
+	}
+
+	roomID, err := strconv.ParseUint(roomIDStr, 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
+		return 0, false
+	}
+	return uint(roomID), true
+}
@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompendiumHandler_SearchEntries(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	handler := NewCompendiumHandler(db)
+	router := testutil.SetupTestRouter()
+	router.GET("/compendium/:type", handler.SearchEntries)
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+		expectedSlugs  []string
+	}{
+		{
+			name:           "全文搜索",
+			url:            "/compendium/spells?q=fireball",
+			expectedStatus: 200,
+			expectedSlugs:  []string{"fireball", "delayed-blast-fireball"},
+		},
+		{
+			name:           "按环阶和学派过滤",
+			url:            "/compendium/spells?level=1&school=abjuration",
+			expectedStatus: 200,
+			expectedSlugs:  []string{"alarm", "mage-armor", "protection-from-evil-and-good", "sanctuary", "shield", "shield-of-faith"},
+		},
+		{
+			name:           "按职业过滤",
+			url:            "/compendium/spells?class=warlock&level=0",
+			expectedStatus: 200,
+			expectedSlugs:  []string{"chill-touch", "eldritch-blast", "mage-hand", "minor-illusion", "poison-spray", "prestidigitation", "true-strike"},
+		},
+		{
+			name:           "无效环阶",
+			url:            "/compendium/spells?level=abc",
+			expectedStatus: 400,
+		},
+		{
+			name:           "未知类型",
+			url:            "/compendium/vehicles",
+			expectedStatus: 404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			if tt.expectedStatus == 200 {
+				var resp struct {
+					Data []compendium.Entry `json:"data"`
+				}
+				require.NoError(t, testutil.ParseResponse(rec, &resp))
+
+				slugs := make([]string, 0, len(resp.Data))
+				for _, entry := range resp.Data {
+					slugs = append(slugs, entry.Slug)
+				}
+				assert.Equal(t, tt.expectedSlugs, slugs)
+			}
+		})
+	}
+}
+
+func TestCompendiumHandler_GetTypes(t *testing.T) {
+	router := testutil.SetupTestRouter()
+	router.GET("/compendium", NewCompendiumHandler(testutil.SetupTestDB(t)).GetTypes)
+
+	req := httptest.NewRequest("GET", "/compendium", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, 200, rec.Code)
+	assert.NotContains(t, rec.Body.String(), `"sample"`)
+
+	var resp struct {
+		Data []struct {
+			Type  compendium.EntryType `json:"type"`
+			Count int                  `json:"count"`
+		} `json:"data"`
+	}
+	require.NoError(t, testutil.ParseResponse(rec, &resp))
+
+	counts := make(map[compendium.EntryType]int, len(resp.Data))
+	for _, item := range resp.Data {
+		counts[item.Type] = item.Count
+	}
+	// 内置数据是完整的 SRD 5.1
+	assert.Equal(t, 319, counts[compendium.TypeSpell])
+	assert.Equal(t, 318, counts[compendium.TypeMonster])
+	assert.Equal(t, 216, counts[compendium.TypeEquipment])
+	assert.Equal(t, 260, counts[compendium.TypeMagicItem])
+	assert.Equal(t, 9, counts[compendium.TypeRace])
+	assert.Equal(t, 12, counts[compendium.TypeClass])
+	assert.Equal(t, 15, counts[compendium.TypeCondition])
+}
+
+func TestCompendiumHandler_GetEntry(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	handler := NewCompendiumHandler(db)
+	router := testutil.SetupTestRouter()
+	router.GET("/compendium/:type/:slug", handler.GetEntry)
+
+	req := httptest.NewRequest("GET", "/compendium/monsters/goblin", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"challenge_rating":"1/4"`)
+
+	req = httptest.NewRequest("GET", "/compendium/monsters/beholder", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, 404, rec.Code)
+}
+
+func TestCharacterHandler_AddReference(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	handler := NewCharacterHandler(db)
+	router := testutil.SetupTestRouter()
+	router.POST("/characters/:roomId/:charId/references", handler.AddReference)
+	router.DELETE("/characters/:roomId/:charId/references/:type/:slug", handler.RemoveReference)
+
+	roomID := uint(200)
+	require.NoError(t, db.Create(&room.Room{ID: roomID, Name: "Wizard School", AbilityMethod: room.AbilityMethodFree}).Error)
+	require.NoError(t, os.RemoveAll(handler.storage.GetRoomCharactersPath(roomID)))
+	require.NoError(t, handler.storage.SaveCharacter(&character.CharacterCard{
+		ID:     1,
+		RoomID: roomID,
+		Name:   "Wizard",
+		Spells: "Light",
+	}))
+
+	for i := 0; i < 2; i++ {
+		req, err := testutil.MakeJSONRequest("POST", "/characters/200/1/references", map[string]string{
+			"type": "spells",
+			"slug": "magic-missile",
+		})
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, 200, rec.Code)
+	}
+
+	char, err := handler.storage.LoadCharacter(roomID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Light, Magic Missile", char.Spells)
+	require.Len(t, char.References, 1)
+	assert.Equal(t, "magic-missile", char.References[0].Slug)
+
+	req, err := testutil.MakeJSONRequest("POST", "/characters/200/1/references", map[string]string{
+		"type": "spells",
+		"slug": "unknown-spell",
+	})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, 404, rec.Code)
+
+	req = httptest.NewRequest("DELETE", "/characters/200/1/references/spells/magic-missile", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	char, err = handler.storage.LoadCharacter(roomID, 1)
+	require.NoError(t, err)
+	assert.Empty(t, char.References)
+}
+
+func TestCharacterHandler_GenerateCharacter(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+	db.Create(&room.Room{ID: 201, Name: "Generator Room"})
+
+	handler := NewCharacterHandler(db)
+	router := testutil.SetupTestRouter()
+	router.POST("/characters/:roomId/generate", handler.GenerateCharacter)
+
+	roomID := uint(201)
+	require.NoError(t, os.RemoveAll(handler.storage.GetRoomCharactersPath(roomID)))
+	defer os.RemoveAll(handler.storage.GetRoomCharactersPath(roomID))
+
+	generate := func(body map[string]interface{}) (int, character.CharacterCard) {
+		req, err := testutil.MakeJSONRequest("POST", "/characters/201/generate", body)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var resp struct {
+			Data struct {
+				Character character.CharacterCard `json:"character"`
+				Seed      int64                   `json:"seed"`
+			} `json:"data"`
+		}
+		if rec.Code == 200 {
+			require.NoError(t, testutil.ParseResponse(rec, &resp))
+		}
+		return rec.Code, resp.Data.Character
+	}
+
+	code, first := generate(map[string]interface{}{"seed": 7, "method": "roll", "class": "wizard"})
+	require.Equal(t, 200, code)
+	assert.Equal(t, uint(1), first.ID)
+	assert.Equal(t, "Wizard", first.Class)
+
+	code, second := generate(map[string]interface{}{"seed": 7, "method": "roll", "class": "wizard"})
+	require.Equal(t, 200, code)
+	assert.Equal(t, uint(2), second.ID)
+	assert.Equal(t, first.Race, second.Race)
+	assert.Equal(t, first.Intelligence, second.Intelligence)
+	assert.Equal(t, first.MaxHP, second.MaxHP)
+
+	saved, err := handler.storage.LoadCharacter(roomID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, first.Name, saved.Name)
+
+	code, _ = generate(map[string]interface{}{"race": "warforged"})
+	assert.Equal(t, 400, code)
+
+	code, _ = generate(map[string]interface{}{"level": 21})
+	assert.Equal(t, 400, code)
+}
+
+func TestCharacterHandler_GenerateCharacterRolled(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+	roomID := uint(202)
+	require.NoError(t, db.Create(&room.Room{ID: roomID, Name: "Rolled Room", AbilityMethod: room.AbilityMethodRolled}).Error)
+
+	handler := NewCharacterHandler(db)
+	router := testutil.SetupTestRouter()
+	router.POST("/characters/:roomId/generate", handler.GenerateCharacter)
+	require.NoError(t, os.RemoveAll(handler.storage.GetRoomCharactersPath(roomID)))
+	defer os.RemoveAll(handler.storage.GetRoomCharactersPath(roomID))
+
+	// 客户端种子不影响服务器属性骰，生成的人物卡按房间方式通过校验
+	for i := 0; i < 3; i++ {
+		req, err := testutil.MakeJSONRequest("POST", "/characters/202/generate", map[string]interface{}{"seed": 7})
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, 200, rec.Code, rec.Body.String())
+
+		var resp struct {
+			Data struct {
+				Character character.CharacterCard `json:"character"`
+			} `json:"data"`
+		}
+		require.NoError(t, testutil.ParseResponse(rec, &resp))
+		card := resp.Data.Character
+		require.NotNil(t, card.AbilityRoll)
+		assert.NotEqual(t, int64(7), card.AbilityRoll.Seed)
+		assert.Empty(t, character.ValidateAbilities(&card, room.AbilityMethodRolled, raceBonusesFor(t, handler, card)))
+	}
+}
+
+// raceBonusesFor 返回人物卡种族在资料库中的属性加值
+func raceBonusesFor(t *testing.T, handler *CharacterHandler, card character.CharacterCard) character.RaceBonuses {
+	races, err := handler.catalog.List(compendium.TypeRace, card.RoomID)
+	require.NoError(t, err)
+	bonuses, ok := character.FindRaceBonuses(races, card.Race)
+	require.True(t, ok, card.Race)
+	return bonuses
+}
@@ -0,0 +1,504 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/dice"
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/monster"
+	"trpg-sync/backend/domain/policy"
+	"trpg-sync/backend/infrastructure/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type EncounterHandler struct {
+	db      *gorm.DB
+	storage *storage.CharacterStorage
+	events  *event.Bus
+}
+
+func NewEncounterHandler(db *gorm.DB) *EncounterHandler {
+	return &EncounterHandler{
+		db:      db,
+		storage: storage.NewCharacterStorage().WithIndex(storage.NewCharacterIndex(db)),
+	}
+}
+
+// WithEvents 设置领域事件总线，遭遇和实例变更后发布事件
+func (h *EncounterHandler) WithEvents(bus *event.Bus) *EncounterHandler {
+	h.events = bus
+	return h
+}
+
+type EncounterRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+}
+
+func (h *EncounterHandler) GetEncounters(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomView); !ok {
+		return
+	}
+
+	var encounters []encounter.Encounter
+	if err := h.db.Preload("Combatants").Where("room_id = ?", targetRoom.ID).Order("id").Find(&encounters).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get encounters", err))
+		return
+	}
+
+	response.Success(c, encounters)
+}
+
+func (h *EncounterHandler) GetEncounter(c *gin.Context) {
+	target, ok := h.loadEncounter(c, policy.RoomView)
+	if !ok {
+		return
+	}
+
+	response.Success(c, target)
+}
+
+func (h *EncounterHandler) CreateEncounter(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomManageEncounters); !ok {
+		return
+	}
+
+	var req EncounterRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	newEncounter := encounter.Encounter{
+		RoomID:      targetRoom.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		Status:      encounter.StatusPlanned,
+		Combatants:  []encounter.Combatant{},
+	}
+	if req.Status != "" {
+		if !encounter.IsValidStatus(req.Status) {
+			response.Error(c, apperror.New(apperror.CodeBadRequest, "Invalid encounter status"))
+			return
+		}
+		newEncounter.Status = req.Status
+	}
+
+	if err := h.db.Create(&newEncounter).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to create encounter", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.EncounterCreated{Encounter: newEncounter})
+	response.OK(c, "Encounter created successfully", newEncounter)
+}
+
+func (h *EncounterHandler) UpdateEncounter(c *gin.Context) {
+	target, ok := h.loadEncounter(c, policy.RoomManageEncounters)
+	if !ok {
+		return
+	}
+
+	var req EncounterRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Status != "" && !encounter.IsValidStatus(req.Status) {
+		response.Error(c, apperror.New(apperror.CodeBadRequest, "Invalid encounter status"))
+		return
+	}
+
+	before := *target
+	target.Name = req.Name
+	target.Description = req.Description
+	if req.Status != "" {
+		target.Status = req.Status
+	}
+
+	if err := h.db.Omit("Combatants").Save(target).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to update encounter", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.EncounterUpdated{Before: before, After: *target})
+	response.OK(c, "Encounter updated successfully", target)
+}
+
+func (h *EncounterHandler) DeleteEncounter(c *gin.Context) {
+	target, ok := h.loadEncounter(c, policy.RoomManageEncounters)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("encounter_id = ?", target.ID).Delete(&encounter.Combatant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&encounter.Encounter{}, target.ID).Error
+	})
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to delete encounter", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.EncounterDeleted{Encounter: *target})
+	response.OK(c, "Encounter deleted successfully", nil)
+}
+
+type SpawnRequest struct {
+	StatBlockID    uint   `json:"stat_block_id" binding:"required"`
+	Count          int    `json:"count"`
+	RollHP         bool   `json:"roll_hp"`
+	RollInitiative bool   `json:"roll_initiative"`
+	Seed           *int64 `json:"seed"`
+}
+
+// SpawnCombatants 将数据卡生成为编号实例加入遭遇（Goblin 1、Goblin 2 ...）
+// 编号接着遭遇中同一数据卡已有的最大编号；roll_hp 为 true 时按生命骰掷骰，否则使用平均生命值
+func (h *EncounterHandler) SpawnCombatants(c *gin.Context) {
+	target, ok := h.loadEncounter(c, policy.RoomManageEncounters)
+	if !ok {
+		return
+	}
+
+	var req SpawnRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.Count < 1 || req.Count > 50 {
+		response.Error(c, apperror.New(apperror.CodeBadRequest, "Count must be between 1 and 50"))
+		return
+	}
+
+	var block monster.StatBlock
+	if err := h.db.Where("room_id = ?", target.RoomID).First(&block, req.StatBlockID).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeStatBlockNotFound, err))
+		return
+	}
+
+	seed := time.Now().UnixNano()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	roller := dice.NewRoller(seed)
+
+	var formula *dice.Formula
+	if req.RollHP && block.HitDice != "" {
+		if f, err := dice.ParseFormula(block.HitDice); err == nil {
+			formula = &f
+		}
+	}
+
+	nextNumber := 1
+	for _, existing := range target.Combatants {
+		if existing.StatBlockID == block.ID && existing.Number >= nextNumber {
+			nextNumber = existing.Number + 1
+		}
+	}
+
+	spawned := make([]encounter.Combatant, 0, req.Count)
+	for i := 0; i < req.Count; i++ {
+		hp := block.HitPoints
+		if formula != nil {
+			hp, _ = roller.Roll(*formula)
+			if hp < 1 {
+				hp = 1
+			}
+		}
+
+		initiative := 0
+		if req.RollInitiative {
+			initiative = roller.Die(20) + character.AbilityModifier(block.Dexterity)
+		}
+
+		number := nextNumber + i
+		spawned = append(spawned, encounter.Combatant{
+			EncounterID:     target.ID,
+			StatBlockID:     block.ID,
+			Name:            fmt.Sprintf("%s %d", block.Name, number),
+			ChallengeRating: block.ChallengeRating,
+			Number:          number,
+			HP:              hp,
+			MaxHP:           hp,
+			AC:              block.ArmorClass,
+			Initiative:      initiative,
+			Conditions:      []string{},
+		})
+	}
+
+	if err := h.db.Create(&spawned).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to spawn combatants", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.CombatantsSpawned{Room: target.RoomID, Combatants: spawned})
+	response.OK(c, "Combatants spawned successfully", spawned)
+}
+
+type UpdateCombatantRequest struct {
+	Name       *string  `json:"name"`
+	HP         *int     `json:"hp"`
+	TempHP     *int     `json:"temp_hp"`
+	Initiative *int     `json:"initiative"`
+	Conditions []string `json:"conditions"`
+}
+
+// UpdateCombatant 修改实例的生命值、先攻或状态，生命值限制在 0 到最大生命值之间
+func (h *EncounterHandler) UpdateCombatant(c *gin.Context) {
+	target, combatant, ok := h.loadCombatant(c, policy.RoomManageEncounters)
+	if !ok {
+		return
+	}
+	before := *combatant
+
+	var req UpdateCombatantRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if req.Name != nil && *req.Name != "" {
+		combatant.Name = *req.Name
+	}
+	if req.HP != nil {
+		combatant.HP = clamp(*req.HP, 0, combatant.MaxHP)
+	}
+	if req.TempHP != nil {
+		combatant.TempHP = *req.TempHP
+		if combatant.TempHP < 0 {
+			combatant.TempHP = 0
+		}
+	}
+	if req.Initiative != nil {
+		combatant.Initiative = *req.Initiative
+	}
+	if req.Conditions != nil {
+		combatant.Conditions = req.Conditions
+	}
+
+	if err := h.db.Save(combatant).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to update combatant", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.CombatantUpdated{Room: target.RoomID, Before: before, After: *combatant})
+	response.OK(c, "Combatant updated successfully", combatant)
+}
+
+func (h *EncounterHandler) DeleteCombatant(c *gin.Context) {
+	target, combatant, ok := h.loadCombatant(c, policy.RoomManageEncounters)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(combatant).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to delete combatant", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.CombatantDeleted{Room: target.RoomID, Combatant: *combatant})
+	response.OK(c, "Combatant deleted successfully", nil)
+}
+
+// NextTurn 按先攻顺序推进到下一个实例，未开始的遭遇从第 1 轮开始并进入进行中状态
+func (h *EncounterHandler) NextTurn(c *gin.Context) {
+	target, ok := h.loadEncounter(c, policy.RoomManageEncounters)
+	if !ok {
+		return
+	}
+
+	current, ok := target.NextTurn()
+	if !ok {
+		response.Error(c, apperror.New(apperror.CodeBadRequest, "Encounter has no combatants able to act"))
+		return
+	}
+
+	if err := h.db.Omit("Combatants").Save(target).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to update encounter", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.TurnAdvanced{Encounter: *target, Combatant: *current})
+	response.OK(c, "Turn advanced successfully", target)
+}
+
+type DifficultyRequest struct {
+	Mode        string                   `json:"mode"`
+	Monsters    []encounter.MonsterGroup `json:"monsters"`
+	EncounterID uint                     `json:"encounter_id"`
+}
+
+// CalculateDifficulty 根据房间人物卡的等级和怪物挑战等级计算遭遇难度
+// 怪物来自 monsters 列表，或 encounter_id 指定遭遇中的全部实例；mode 为 dmg（默认）或 budget
+func (h *EncounterHandler) CalculateDifficulty(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomView); !ok {
+		return
+	}
+
+	var req DifficultyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	groups := req.Monsters
+	if req.EncounterID != 0 {
+		var target encounter.Encounter
+		if err := h.db.Preload("Combatants").Where("room_id = ?", targetRoom.ID).First(&target, req.EncounterID).Error; err != nil {
+			response.Error(c, recordError(apperror.CodeEncounterNotFound, err))
+			return
+		}
+
+		encounterGroups, err := h.combatantGroups(target.Combatants)
+		if err != nil {
+			response.Error(c, storageError("Failed to load encounter stat blocks", err))
+			return
+		}
+		groups = append(groups, encounterGroups...)
+	}
+
+	characters, err := h.storage.GetRoomCharacters(targetRoom.ID)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get characters", err))
+		return
+	}
+
+	// 与队伍总览相同，只统计未归档的人物卡
+	party := character.ActiveCards(characters)
+	levels := make([]int, 0, len(party))
+	for _, char := range party {
+		levels = append(levels, clamp(char.Level, 1, 20))
+	}
+
+	result, err := encounter.Evaluate(levels, groups, req.Mode)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeBadRequest, err.Error()))
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// combatantGroups 将遭遇实例按数据卡汇总为怪物组
+// 数据卡已删除的实例按生成时记录的挑战等级计算，没有记录时返回错误，不能悄悄少算
+func (h *EncounterHandler) combatantGroups(combatants []encounter.Combatant) ([]encounter.MonsterGroup, error) {
+	byBlock := make(map[uint][]encounter.Combatant)
+	var ids []uint
+	for _, combatant := range combatants {
+		if len(byBlock[combatant.StatBlockID]) == 0 {
+			ids = append(ids, combatant.StatBlockID)
+		}
+		byBlock[combatant.StatBlockID] = append(byBlock[combatant.StatBlockID], combatant)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var blocks []monster.StatBlock
+	if err := h.db.Where("id IN ?", ids).Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[uint]monster.StatBlock, len(blocks))
+	for _, block := range blocks {
+		found[block.ID] = block
+	}
+
+	slices.Sort(ids)
+	groups := make([]encounter.MonsterGroup, 0, len(ids))
+	for _, id := range ids {
+		members := byBlock[id]
+		if block, ok := found[id]; ok {
+			groups = append(groups, encounter.MonsterGroup{Name: block.Name, CR: block.ChallengeRating, Count: len(members)})
+			continue
+		}
+		first := members[0]
+		if first.ChallengeRating == "" {
+			return nil, apperror.New(apperror.CodeStatBlockNotFound, fmt.Sprintf("Stat block for %s was deleted", first.Name))
+		}
+		groups = append(groups, encounter.MonsterGroup{
+			Name:  strings.TrimSuffix(first.Name, fmt.Sprintf(" %d", first.Number)),
+			CR:    first.ChallengeRating,
+			Count: len(members),
+		})
+	}
+	return groups, nil
+}
+
+// loadEncounter 加载路径中的遭遇并检查当前用户对房间的操作权限，失败时直接返回错误响应
+func (h *EncounterHandler) loadEncounter(c *gin.Context, action policy.Action) (*encounter.Encounter, bool) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, action); !ok {
+		return nil, false
+	}
+
+	encounterID, err := strconv.ParseUint(c.Param("encounterId"), 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid encounter ID"))
+		return nil, false
+	}
+
+	var target encounter.Encounter
+	if err := h.db.Preload("Combatants").Where("room_id = ?", targetRoom.ID).First(&target, encounterID).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeEncounterNotFound, err))
+		return nil, false
+	}
+	return &target, true
+}
+
+// loadCombatant 加载遭遇及其中的实例
+func (h *EncounterHandler) loadCombatant(c *gin.Context, action policy.Action) (*encounter.Encounter, *encounter.Combatant, bool) {
+	target, ok := h.loadEncounter(c, action)
+	if !ok {
+		return nil, nil, false
+	}
+
+	combatantID, err := strconv.ParseUint(c.Param("combatantId"), 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid combatant ID"))
+		return nil, nil, false
+	}
+
+	for i := range target.Combatants {
+		if target.Combatants[i].ID == uint(combatantID) {
+			return target, &target.Combatants[i], true
+		}
+	}
+
+	response.Error(c, apperror.New(apperror.CodeCombatantNotFound, ""))
+	return nil, nil, false
+}
+
+func clamp(value, min, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/monster"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/validation"
+	"trpg-sync/backend/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func setupEncounterDB(t *testing.T) *gorm.DB {
+	db := testutil.SetupMigratedDB(t)
+
+	testRoom := room.Room{Name: "Test Room", RuleSystem: "DND5e"}
+	db.Create(&testRoom)
+	return db
+}
+
+func TestStatBlockHandler_CreateAndClone(t *testing.T) {
+	db := setupEncounterDB(t)
+
+	handler := NewStatBlockHandler(db)
+	router := testutil.SetupTestRouter()
+	router.POST("/rooms/:id/statblocks", handler.CreateStatBlock)
+	router.POST("/rooms/:id/statblocks/clone", handler.CloneStatBlock)
+	router.PUT("/rooms/:id/statblocks/:blockId", handler.UpdateStatBlock)
+
+	tests := []struct {
+		name           string
+		url            string
+		body           map[string]interface{}
+		expectedStatus int
+		expectedField  string
+		expectedCode   string
+	}{
+		{
+			name:           "创建 NPC",
+			url:            "/rooms/1/statblocks",
+			body:           map[string]interface{}{"name": "Captain Vex", "is_npc": true, "hit_dice": "6d8 + 6", "challenge_rating": "2"},
+			expectedStatus: 200,
+		},
+		{
+			name:           "非法挑战等级",
+			url:            "/rooms/1/statblocks",
+			body:           map[string]interface{}{"name": "Blob", "challenge_rating": "1/3"},
+			expectedStatus: 400,
+			expectedField:  "challenge_rating",
+			expectedCode:   validation.CodeOutOfRange,
+		},
+		{
+			name:           "非法生命骰",
+			url:            "/rooms/1/statblocks",
+			body:           map[string]interface{}{"name": "Blob", "hit_dice": "lots"},
+			expectedStatus: 400,
+			expectedField:  "hit_dice",
+			expectedCode:   validation.CodeInvalidType,
+		},
+		{
+			name:           "从资料库克隆",
+			url:            "/rooms/1/statblocks/clone",
+			body:           map[string]interface{}{"slug": "adult-red-dragon"},
+			expectedStatus: 200,
+		},
+		{
+			name:           "资料库中不存在",
+			url:            "/rooms/1/statblocks/clone",
+			body:           map[string]interface{}{"slug": "beholder"},
+			expectedStatus: 404,
+		},
+		{
+			name:           "房间不存在",
+			url:            "/rooms/999/statblocks",
+			body:           map[string]interface{}{"name": "Ghost"},
+			expectedStatus: 404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := testutil.MakeJSONRequest("POST", tt.url, tt.body)
+			require.NoError(t, err)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedField == "" {
+				return
+			}
+
+			var resp struct {
+				Data struct {
+					Errors []validation.FieldError `json:"errors"`
+				} `json:"data"`
+			}
+			require.NoError(t, testutil.ParseResponse(rec, &resp))
+			require.NotEmpty(t, resp.Data.Errors)
+			assert.Equal(t, tt.expectedField, resp.Data.Errors[0].Field)
+			assert.Equal(t, tt.expectedCode, resp.Data.Errors[0].Code)
+		})
+	}
+
+	var npc monster.StatBlock
+	require.NoError(t, db.Where("name = ?", "Captain Vex").First(&npc).Error)
+	assert.Equal(t, "6d8+6", npc.HitDice)
+	assert.Equal(t, 33, npc.HitPoints)
+	assert.Equal(t, 450, npc.XP)
+
+	var dragon monster.StatBlock
+	require.NoError(t, db.Where("compendium_slug = ?", "adult-red-dragon").First(&dragon).Error)
+	assert.Equal(t, "Adult Red Dragon", dragon.Name)
+	assert.Equal(t, 256, dragon.HitPoints)
+	assert.Equal(t, 80, dragon.Speed.Fly)
+	assert.Equal(t, 13, dragon.SavingThrows["constitution"])
+	assert.Len(t, dragon.LegendaryActions, 3)
+
+	req, err := testutil.MakeJSONRequest("PUT", "/rooms/1/statblocks/1", map[string]interface{}{"name": "Captain Vex the Bold", "hit_points": 40})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	require.NoError(t, db.First(&npc, npc.ID).Error)
+	assert.Equal(t, "Captain Vex the Bold", npc.Name)
+	assert.Equal(t, 40, npc.HitPoints)
+}
+
+func TestEncounterHandler_SpawnCombatants(t *testing.T) {
+	db := setupEncounterDB(t)
+
+	goblin := monster.StatBlock{RoomID: 1, Name: "Goblin", ArmorClass: 15, HitPoints: 7, HitDice: "2d6", Dexterity: 14, ChallengeRating: "1/4", XP: 50}
+	db.Create(&goblin)
+
+	handler := NewEncounterHandler(db)
+	router := testutil.SetupTestRouter()
+	router.POST("/rooms/:id/encounters", handler.CreateEncounter)
+	router.POST("/rooms/:id/encounters/:encounterId/spawn", handler.SpawnCombatants)
+	router.PATCH("/rooms/:id/encounters/:encounterId/combatants/:combatantId", handler.UpdateCombatant)
+
+	req, err := testutil.MakeJSONRequest("POST", "/rooms/1/encounters", map[string]interface{}{"name": "Ambush"})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, 200, rec.Code)
+
+	spawn := func(body map[string]interface{}) []encounter.Combatant {
+		req, err := testutil.MakeJSONRequest("POST", "/rooms/1/encounters/1/spawn", body)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, 200, rec.Code)
+
+		var resp struct {
+			Data []encounter.Combatant `json:"data"`
+		}
+		require.NoError(t, testutil.ParseResponse(rec, &resp))
+		return resp.Data
+	}
+
+	first := spawn(map[string]interface{}{"stat_block_id": goblin.ID, "count": 2})
+	require.Len(t, first, 2)
+	assert.Equal(t, "Goblin 1", first[0].Name)
+	assert.Equal(t, "Goblin 2", first[1].Name)
+	assert.Equal(t, 7, first[0].HP)
+	assert.Equal(t, "1/4", first[0].ChallengeRating)
+
+	rolled := spawn(map[string]interface{}{"stat_block_id": goblin.ID, "count": 1, "roll_hp": true, "seed": 42})
+	require.Len(t, rolled, 1)
+	assert.Equal(t, "Goblin 3", rolled[0].Name)
+	assert.GreaterOrEqual(t, rolled[0].HP, 2)
+	assert.LessOrEqual(t, rolled[0].HP, 12)
+
+	again := spawn(map[string]interface{}{"stat_block_id": goblin.ID, "count": 1, "roll_hp": true, "seed": 42})
+	assert.Equal(t, rolled[0].HP, again[0].HP)
+
+	// 每个实例拥有独立生命值，且不会超过最大值
+	req, err = testutil.MakeJSONRequest("PATCH", "/rooms/1/encounters/1/combatants/1", map[string]interface{}{"hp": 3})
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	req, err = testutil.MakeJSONRequest("PATCH", "/rooms/1/encounters/1/combatants/2", map[string]interface{}{"hp": 99})
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+
+	var combatants []encounter.Combatant
+	db.Order("id").Find(&combatants)
+	assert.Equal(t, 3, combatants[0].HP)
+	assert.Equal(t, 7, combatants[1].HP)
+
+	req, err = testutil.MakeJSONRequest("POST", "/rooms/1/encounters/1/spawn", map[string]interface{}{"stat_block_id": 999})
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, 404, rec.Code)
+}
+
+func TestEncounterHandler_CalculateDifficulty(t *testing.T) {
+	db := setupEncounterDB(t)
+
+	partyRoom := room.Room{ID: 300, Name: "Party Room", RuleSystem: "DND5e"}
+	db.Create(&partyRoom)
+
+	handler := NewEncounterHandler(db)
+	router := testutil.SetupTestRouter()
+	router.POST("/rooms/:id/encounter-difficulty", handler.CalculateDifficulty)
+
+	require.NoError(t, os.RemoveAll(handler.storage.GetRoomCharactersPath(partyRoom.ID)))
+	defer os.RemoveAll(handler.storage.GetRoomCharactersPath(partyRoom.ID))
+	for i := uint(1); i <= 4; i++ {
+		require.NoError(t, handler.storage.SaveCharacter(&character.CharacterCard{
+			ID:     i,
+			RoomID: partyRoom.ID,
+			Name:   "Hero",
+			Level:  3,
+		}))
+	}
+	// 已归档的人物卡不在队伍中，不计入难度
+	archivedAt := time.Now()
+	require.NoError(t, handler.storage.SaveCharacter(&character.CharacterCard{
+		ID: 5, RoomID: partyRoom.ID, Name: "Kicked", Level: 20, ArchivedAt: &archivedAt,
+	}))
+
+	ogre := monster.StatBlock{RoomID: partyRoom.ID, Name: "Ogre", ChallengeRating: "2", XP: 450}
+	db.Create(&ogre)
+	ambush := encounter.Encounter{RoomID: partyRoom.ID, Name: "Ambush", Combatants: []encounter.Combatant{
+		{StatBlockID: ogre.ID, Name: "Ogre 1", Number: 1},
+		{StatBlockID: ogre.ID, Name: "Ogre 2", Number: 2},
+	}}
+	db.Create(&ambush)
+	// 数据卡已删除的实例按生成时记录的挑战等级计算；没有记录时不能计算
+	orphans := encounter.Encounter{RoomID: partyRoom.ID, Name: "Orphans", Combatants: []encounter.Combatant{
+		{StatBlockID: 900, Name: "Ogre 1", Number: 1, ChallengeRating: "2"},
+		{StatBlockID: 900, Name: "Ogre 2", Number: 2, ChallengeRating: "2"},
+	}}
+	db.Create(&orphans)
+	unknown := encounter.Encounter{RoomID: partyRoom.ID, Name: "Unknown", Combatants: []encounter.Combatant{
+		{StatBlockID: 901, Name: "Ghost 1", Number: 1},
+	}}
+	db.Create(&unknown)
+
+	tests := []struct {
+		name           string
+		url            string
+		body           map[string]interface{}
+		expectedStatus int
+		expectedRating string
+		expectedXP     int
+	}{
+		{
+			name:           "手动输入怪物",
+			url:            "/rooms/300/encounter-difficulty",
+			body:           map[string]interface{}{"monsters": []map[string]interface{}{{"cr": "1/4", "count": 4}}},
+			expectedStatus: 200,
+			expectedRating: encounter.RatingEasy,
+			expectedXP:     400,
+		},
+		{
+			name:           "使用遭遇中的实例",
+			url:            "/rooms/300/encounter-difficulty",
+			body:           map[string]interface{}{"encounter_id": ambush.ID},
+			expectedStatus: 200,
+			expectedRating: encounter.RatingHard,
+			expectedXP:     1350,
+		},
+		{
+			name:           "每角色预算模式",
+			url:            "/rooms/300/encounter-difficulty",
+			body:           map[string]interface{}{"encounter_id": ambush.ID, "mode": "budget"},
+			expectedStatus: 200,
+			expectedRating: encounter.RatingHard,
+			expectedXP:     900,
+		},
+		{
+			name:           "数据卡已删除",
+			url:            "/rooms/300/encounter-difficulty",
+			body:           map[string]interface{}{"encounter_id": orphans.ID},
+			expectedStatus: 200,
+			expectedRating: encounter.RatingHard,
+			expectedXP:     1350,
+		},
+		{
+			name:           "数据卡已删除且没有挑战等级",
+			url:            "/rooms/300/encounter-difficulty",
+			body:           map[string]interface{}{"encounter_id": unknown.ID},
+			expectedStatus: 404,
+		},
+		{
+			name:           "非法挑战等级",
+			url:            "/rooms/300/encounter-difficulty",
+			body:           map[string]interface{}{"monsters": []map[string]interface{}{{"cr": "1/3", "count": 1}}},
+			expectedStatus: 400,
+		},
+		{
+			name:           "遭遇不存在",
+			url:            "/rooms/300/encounter-difficulty",
+			body:           map[string]interface{}{"encounter_id": 999},
+			expectedStatus: 404,
+		},
+		{
+			name:           "房间内没有人物卡",
+			url:            "/rooms/1/encounter-difficulty",
+			body:           map[string]interface{}{"monsters": []map[string]interface{}{{"cr": "1", "count": 1}}},
+			expectedStatus: 400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := testutil.MakeJSONRequest("POST", tt.url, tt.body)
+			require.NoError(t, err)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != 200 {
+				return
+			}
+
+			var resp struct {
+				Data encounter.Difficulty `json:"data"`
+			}
+			require.NoError(t, testutil.ParseResponse(rec, &resp))
+			assert.Equal(t, 4, resp.Data.PartySize)
+			assert.Equal(t, tt.expectedXP, resp.Data.AdjustedXP)
+			assert.Equal(t, tt.expectedRating, resp.Data.Rating)
+		})
+	}
+}
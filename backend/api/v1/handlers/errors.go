@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"errors"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ErrorHandler struct{}
+
+func NewErrorHandler() *ErrorHandler {
+	return &ErrorHandler{}
+}
+
+// GetErrorCodes 返回错误码目录：错误码、HTTP 状态码、默认消息和说明
+func (h *ErrorHandler) GetErrorCodes(c *gin.Context) {
+	response.Success(c, apperror.Catalogue())
+}
+
+// recordError 将记录不存在转换为指定的应用错误，其它数据库错误视为内部错误
+func recordError(code apperror.Code, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperror.New(code, "")
+	}
+	return apperror.Wrap(apperror.CodeInternal, "", err)
+}
+
+// storageError 保留存储层返回的应用错误（如人物卡不存在），其它错误视为内部错误
+func storageError(message string, err error) error {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+	return apperror.Wrap(apperror.CodeInternal, message, err)
+}
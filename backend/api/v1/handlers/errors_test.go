@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandler_GetErrorCodes(t *testing.T) {
+	handler := NewErrorHandler()
+	router := testutil.SetupTestRouter()
+	router.GET("/errors", handler.GetErrorCodes)
+
+	req := httptest.NewRequest("GET", "/errors", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, 200, rec.Code)
+
+	var resp struct {
+		Data []apperror.Definition `json:"data"`
+	}
+	require.NoError(t, testutil.ParseResponse(rec, &resp))
+
+	codes := make(map[apperror.Code]apperror.Definition)
+	for _, def := range resp.Data {
+		assert.NotEmpty(t, def.Message, def.Code)
+		assert.NotEmpty(t, def.Description, def.Code)
+		codes[def.Code] = def
+	}
+	assert.Equal(t, 404, codes[apperror.CodeRoomNotFound].Status)
+	assert.Equal(t, 409, codes[apperror.CodeCharacterConflict].Status)
+	assert.Equal(t, 500, codes[apperror.CodeInternal].Status)
+}
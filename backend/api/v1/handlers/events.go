@@ -0,0 +1,12 @@
+package handlers
+
+import (
+	"trpg-sync/backend/domain/event"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventMeta 当前请求的操作上下文，随领域事件一起发布，供审计日志记录操作者和客户端 IP
+func eventMeta(c *gin.Context) event.Meta {
+	return event.Meta{ActorID: currentUserID(c), IP: c.ClientIP()}
+}
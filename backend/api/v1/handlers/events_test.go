@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_PublishDomainEvents(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	bus := event.NewBus()
+	var published []event.Event
+	bus.SubscribeAll(func(e event.Event) {
+		published = append(published, e)
+	})
+
+	roomHandler := NewRoomHandler(db).WithEvents(bus)
+	characterHandler := NewCharacterHandler(db).WithEvents(bus)
+	rollHandler := NewRollHandler(db).WithEvents(bus)
+
+	router := testutil.SetupTestRouter()
+	router.POST("/rooms", roomHandler.CreateRoom)
+	router.PATCH("/rooms/:id", roomHandler.PatchRoom)
+	router.POST("/rooms/:id/rolls", rollHandler.Roll)
+	router.POST("/characters/:roomId", characterHandler.CreateCharacter)
+	router.PUT("/characters/:roomId/:charId", characterHandler.UpdateCharacter)
+	router.DELETE("/characters/:roomId/:charId", characterHandler.DeleteCharacter)
+
+	do := func(method, url string, body interface{}) int {
+		req, err := testutil.MakeJSONRequest(method, url, body)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	roomID := uint(290)
+	require.NoError(t, os.RemoveAll(characterHandler.storage.GetRoomCharactersPath(roomID)))
+	defer os.RemoveAll(characterHandler.storage.GetRoomCharactersPath(roomID))
+	// 新建的房间 ID 接着已有的最大 ID，避免人物卡目录与其它测试冲突
+	require.NoError(t, db.Create(&room.Room{ID: roomID - 1, Name: "Seed Room"}).Error)
+
+	card := map[string]interface{}{
+		"name": "Rook", "level": 2, "hp": 14, "max_hp": 14,
+		"strength": 15, "dexterity": 12, "constitution": 14, "intelligence": 8, "wisdom": 10, "charisma": 10,
+	}
+
+	require.Equal(t, 200, do("POST", "/rooms", map[string]interface{}{"name": "Event Room", "ability_method": room.AbilityMethodFree}))
+	require.Equal(t, 200, do("PATCH", "/rooms/290", map[string]interface{}{"name": "Renamed Room"}))
+	require.Equal(t, 200, do("POST", "/characters/290", card))
+	card["name"] = "Rook the Bold"
+	require.Equal(t, 200, do("PUT", "/characters/290/1", card))
+	require.Equal(t, 200, do("POST", "/rooms/290/rolls", map[string]interface{}{"formula": "1d20+2", "character_id": 1}))
+	require.Equal(t, 200, do("DELETE", "/characters/290/1", nil))
+
+	// 失败的请求不发布事件
+	require.Equal(t, 404, do("DELETE", "/characters/290/1", nil))
+
+	names := make([]string, 0, len(published))
+	for _, e := range published {
+		names = append(names, e.Name())
+		assert.Equal(t, roomID, e.RoomID(), e.Name())
+	}
+	require.Equal(t, []string{
+		"room.created", "room.updated", "character.created", "character.updated", "roll.made", "character.deleted",
+	}, names)
+
+	roomUpdated := published[1].(event.RoomUpdated)
+	assert.Equal(t, "Event Room", roomUpdated.Before.Name)
+	assert.Equal(t, "Renamed Room", roomUpdated.After.Name)
+
+	characterUpdated := published[3].(event.CharacterUpdated)
+	assert.Equal(t, "Rook", characterUpdated.Before.Name)
+	assert.Equal(t, "Rook the Bold", characterUpdated.After.Name)
+
+	assert.Equal(t, "Rook the Bold", published[4].(event.RollMade).Result.CharacterName)
+
+	deleted := published[5].(event.CharacterDeleted)
+	assert.Equal(t, uint(1), deleted.CharacterID)
+	require.NotNil(t, deleted.Card)
+	assert.Equal(t, "Rook the Bold", deleted.Card.Name)
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/homebrew"
+	"trpg-sync/backend/domain/policy"
+	"trpg-sync/backend/domain/validation"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type HomebrewHandler struct {
+	db     *gorm.DB
+	events *event.Bus
+}
+
+func NewHomebrewHandler(db *gorm.DB) *HomebrewHandler {
+	return &HomebrewHandler{db: db}
+}
+
+// WithEvents 设置领域事件总线，自制内容变更后发布事件
+func (h *HomebrewHandler) WithEvents(bus *event.Bus) *HomebrewHandler {
+	h.events = bus
+	return h
+}
+
+type HomebrewRequest struct {
+	RoomID      uint            `json:"room_id"`
+	Type        string          `json:"type" binding:"required"`
+	Slug        string          `json:"slug"`
+	Name        string          `json:"name" binding:"required"`
+	Source      string          `json:"source"`
+	Description string          `json:"description"`
+	Level       *int            `json:"level"`
+	School      string          `json:"school"`
+	Classes     []string        `json:"classes"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// GetHomebrew 获取自制内容列表
+// 支持参数：room_id（不传时只返回全局内容）、type
+func (h *HomebrewHandler) GetHomebrew(c *gin.Context) {
+	roomID, ok := parseRoomIDQuery(c)
+	if !ok || !authorizeRoomScope(c, h.db, roomID, policy.RoomView) {
+		return
+	}
+
+	query := h.db.Where("room_id = ?", roomID)
+	if entryType := c.Query("type"); entryType != "" {
+		query = query.Where("type = ?", entryType)
+	}
+
+	var entries []homebrew.Entry
+	if err := query.Order("type, name").Find(&entries).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get homebrew entries", err))
+		return
+	}
+
+	response.Success(c, entries)
+}
+
+func (h *HomebrewHandler) GetHomebrewEntry(c *gin.Context) {
+	entry, ok := h.loadEntry(c, policy.RoomView)
+	if !ok {
+		return
+	}
+
+	response.Success(c, entry)
+}
+
+func (h *HomebrewHandler) CreateHomebrew(c *gin.Context) {
+	var req HomebrewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	entry := homebrew.Entry{RoomID: req.RoomID, OwnerID: currentUserID(c)}
+	applyHomebrewRequest(&entry, &req)
+
+	if !h.validateEntry(c, &entry) || !authorizeRoomScope(c, h.db, entry.RoomID, policy.RoomManageHomebrew) {
+		return
+	}
+
+	if err := h.db.Create(&entry).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeHomebrewConflict, "", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.HomebrewCreated{Entry: entry})
+	response.OK(c, "Homebrew entry created successfully", entry)
+}
+
+// UpdateHomebrew 更新自制内容，不允许修改所属房间和创建者
+func (h *HomebrewHandler) UpdateHomebrew(c *gin.Context) {
+	entry, ok := h.loadEntry(c, policy.RoomManageHomebrew)
+	if !ok {
+		return
+	}
+
+	var req HomebrewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	before := *entry
+	applyHomebrewRequest(entry, &req)
+
+	if !h.validateEntry(c, entry) {
+		return
+	}
+
+	if err := h.db.Save(entry).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeHomebrewConflict, "", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.HomebrewUpdated{Before: before, After: *entry})
+	response.OK(c, "Homebrew entry updated successfully", entry)
+}
+
+func (h *HomebrewHandler) DeleteHomebrew(c *gin.Context) {
+	entry, ok := h.loadEntry(c, policy.RoomManageHomebrew)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(entry).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to delete homebrew entry", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.HomebrewDeleted{Entry: *entry})
+	response.OK(c, "Homebrew entry deleted successfully", nil)
+}
+
+// ExportHomebrew 将自制内容导出为内容包 JSON 文件
+// 支持参数：room_id（不传时导出全局内容）、name（内容包名称）
+func (h *HomebrewHandler) ExportHomebrew(c *gin.Context) {
+	roomID, ok := parseRoomIDQuery(c)
+	if !ok || !authorizeRoomScope(c, h.db, roomID, policy.RoomView) {
+		return
+	}
+
+	var entries []homebrew.Entry
+	if err := h.db.Where("room_id = ?", roomID).Order("type, name").Find(&entries).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to export homebrew entries", err))
+		return
+	}
+
+	pack := homebrew.Pack{
+		Name:       c.DefaultQuery("name", "homebrew"),
+		ExportedAt: time.Now(),
+		Entries:    make([]compendium.Entry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		pack.Entries = append(pack.Entries, entry.ToCompendium())
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, homebrew.Slugify(pack.Name)))
+	c.JSON(http.StatusOK, pack)
+}
+
+// ImportHomebrew 导入内容包
+// 支持参数：room_id（导入目标房间，不传时导入为全局内容）、on_conflict（skip 或 replace，默认 skip）
+// 覆盖全局内容时要求是这些条目的创建者，有任何一条无权覆盖时整个内容包都不会导入
+func (h *HomebrewHandler) ImportHomebrew(c *gin.Context) {
+	roomID, ok := parseRoomIDQuery(c)
+	if !ok {
+		return
+	}
+
+	replace := c.DefaultQuery("on_conflict", "skip") == "replace"
+
+	var pack homebrew.Pack
+	if !bindJSON(c, &pack) {
+		return
+	}
+
+	if !authorizeRoomScope(c, h.db, roomID, policy.RoomManageHomebrew) {
+		return
+	}
+
+	// 先校验全部条目，避免导入一半
+	ownerID := currentUserID(c)
+	entries := make([]homebrew.Entry, 0, len(pack.Entries))
+	for i, item := range pack.Entries {
+		entry := homebrew.FromCompendium(roomID, item)
+		entry.OwnerID = ownerID
+		if entry.Source == homebrew.DefaultSource && pack.Name != "" {
+			entry.Source = pack.Name
+		}
+		if _, ok := compendium.ParseType(entry.Type); !ok || entry.Name == "" || entry.Slug == "" {
+			response.Error(c, apperror.New(apperror.CodeBadRequest, fmt.Sprintf("Invalid homebrew entry at index %d", i)))
+			return
+		}
+		entries = append(entries, entry)
+	}
+
+	imported, replaced, skipped := 0, 0, 0
+	var written []homebrew.Entry
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			var existing homebrew.Entry
+			err := tx.Where("room_id = ? AND type = ? AND slug = ?", entry.RoomID, entry.Type, entry.Slug).
+				First(&existing).Error
+			switch {
+			case err == nil && !replace:
+				skipped++
+				continue
+			case err == nil:
+				if entry.RoomID == homebrew.GlobalRoomID {
+					if err := policy.GlobalContent(ownerID, existing.OwnerID); err != nil {
+						return err
+					}
+				}
+				entry.ID = existing.ID
+				entry.OwnerID = existing.OwnerID
+				entry.CreatedAt = existing.CreatedAt
+				if err := tx.Save(&entry).Error; err != nil {
+					return err
+				}
+				replaced++
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Create(&entry).Error; err != nil {
+					return err
+				}
+				imported++
+			default:
+				return err
+			}
+			written = append(written, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		response.Error(c, storageError("Failed to import homebrew pack", err))
+		return
+	}
+
+	if len(written) > 0 {
+		h.events.PublishFrom(eventMeta(c), event.HomebrewImported{Room: roomID, Entries: written})
+	}
+
+	response.OK(c, "Homebrew pack imported successfully", gin.H{
+		"imported": imported,
+		"replaced": replaced,
+		"skipped":  skipped,
+	})
+}
+
+// loadEntry 加载路径中的自制内容，房间自制内容检查当前用户对所属房间的操作权限，
+// 修改和删除全局内容时要求是创建者
+func (h *HomebrewHandler) loadEntry(c *gin.Context, action policy.Action) (*homebrew.Entry, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid homebrew ID"))
+		return nil, false
+	}
+
+	var entry homebrew.Entry
+	if err := h.db.First(&entry, id).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeHomebrewNotFound, err))
+		return nil, false
+	}
+	if !authorizeRoomScope(c, h.db, entry.RoomID, action) {
+		return nil, false
+	}
+	if entry.RoomID == homebrew.GlobalRoomID && action != policy.RoomView &&
+		!authorized(c, policy.GlobalContent(currentUserID(c), entry.OwnerID)) {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// validateEntry 校验条目类型和 slug
+func (h *HomebrewHandler) validateEntry(c *gin.Context, entry *homebrew.Entry) bool {
+	var errs []validation.FieldError
+	if _, ok := compendium.ParseType(entry.Type); !ok {
+		choices := make([]string, 0, len(compendium.Types()))
+		for _, t := range compendium.Types() {
+			choices = append(choices, string(t))
+		}
+		errs = append(errs, validation.NewError("type", validation.CodeInvalidChoice, map[string]interface{}{
+			"choices": strings.Join(choices, ", "),
+		}))
+	}
+	if entry.Slug == "" {
+		errs = append(errs, validation.NewError("slug", validation.CodeRequired, nil))
+	}
+	if len(errs) > 0 {
+		respondValidation(c, errs)
+		return false
+	}
+	return true
+}
+
+func applyHomebrewRequest(entry *homebrew.Entry, req *HomebrewRequest) {
+	entry.Type = req.Type
+	entry.Slug = req.Slug
+	entry.Name = req.Name
+	entry.Source = req.Source
+	entry.Description = req.Description
+	entry.Level = req.Level
+	entry.School = req.School
+	entry.Classes = req.Classes
+	entry.Data = req.Data
+	entry.Normalize()
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/homebrew"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/validation"
+	"trpg-sync/backend/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHomebrewHandler_CreateAndSearch(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	testRoom := room.Room{Name: "Test Room", RuleSystem: "DND5e"}
+	db.Create(&testRoom)
+
+	handler := NewHomebrewHandler(db)
+	compendiumHandler := NewCompendiumHandler(db)
+	router := testutil.SetupTestRouter()
+	router.POST("/homebrew", handler.CreateHomebrew)
+	router.GET("/compendium/:type", compendiumHandler.SearchEntries)
+	router.GET("/rooms/:id/character-options", compendiumHandler.GetCharacterOptions)
+
+	tests := []struct {
+		name           string
+		body           map[string]interface{}
+		expectedStatus int
+		expectedField  string
+		expectedCode   string
+	}{
+		{
+			name:           "创建房间自制种族",
+			body:           map[string]interface{}{"room_id": testRoom.ID, "type": "races", "name": "Shadow Elf"},
+			expectedStatus: 200,
+		},
+		{
+			name:           "创建全局自制法术",
+			body:           map[string]interface{}{"type": "spells", "name": "Frost Nova", "level": 2, "school": "evocation", "classes": []string{"wizard"}},
+			expectedStatus: 200,
+		},
+		{
+			name:           "重复 slug",
+			body:           map[string]interface{}{"room_id": testRoom.ID, "type": "races", "name": "Shadow Elf"},
+			expectedStatus: 409,
+		},
+		{
+			name:           "未知类型",
+			body:           map[string]interface{}{"type": "vehicles", "name": "Airship"},
+			expectedStatus: 400,
+			expectedField:  "type",
+			expectedCode:   validation.CodeInvalidChoice,
+		},
+		{
+			name:           "房间不存在",
+			body:           map[string]interface{}{"room_id": 999, "type": "races", "name": "Lizardfolk"},
+			expectedStatus: 404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := testutil.MakeJSONRequest("POST", "/homebrew", tt.body)
+			require.NoError(t, err)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedField == "" {
+				return
+			}
+
+			var resp struct {
+				Data struct {
+					Errors []validation.FieldError `json:"errors"`
+				} `json:"data"`
+			}
+			require.NoError(t, testutil.ParseResponse(rec, &resp))
+			require.NotEmpty(t, resp.Data.Errors)
+			assert.Equal(t, tt.expectedField, resp.Data.Errors[0].Field)
+			assert.Equal(t, tt.expectedCode, resp.Data.Errors[0].Code)
+		})
+	}
+
+	// 全局法术出现在资料库搜索中
+	req := httptest.NewRequest("GET", "/compendium/spells?class=wizard&level=2", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Body.String(), "frost-nova")
+
+	// 房间种族只在该房间可见
+	req = httptest.NewRequest("GET", "/compendium/races?q=shadow", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.NotContains(t, rec.Body.String(), "shadow-elf")
+
+	req = httptest.NewRequest("GET", "/rooms/1/character-options", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Body.String(), "shadow-elf")
+	assert.Contains(t, rec.Body.String(), "champion")
+}
+
+func TestHomebrewHandler_ImportExport(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	handler := NewHomebrewHandler(db)
+	router := testutil.SetupTestRouter()
+	router.GET("/homebrew/export", handler.ExportHomebrew)
+	router.POST("/homebrew/import", handler.ImportHomebrew)
+
+	pack := homebrew.Pack{
+		Name: "Frozen North",
+		Entries: []compendium.Entry{
+			{Type: compendium.TypeRace, Name: "Snow Goblin"},
+			{Type: compendium.TypeMagicItem, Slug: "ice-brand", Name: "Ice Brand", Description: "A frozen blade."},
+		},
+	}
+
+	req, err := testutil.MakeJSONRequest("POST", "/homebrew/import", pack)
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"imported":2`)
+
+	// 再次导入时默认跳过已存在的条目
+	req, err = testutil.MakeJSONRequest("POST", "/homebrew/import", pack)
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Contains(t, rec.Body.String(), `"skipped":2`)
+
+	pack.Entries[1].Description = "A blade of eternal ice."
+	req, err = testutil.MakeJSONRequest("POST", "/homebrew/import?on_conflict=replace", pack)
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Contains(t, rec.Body.String(), `"replaced":2`)
+
+	req = httptest.NewRequest("GET", "/homebrew/export?name=Frozen%20North", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "frozen-north.json")
+
+	var exported homebrew.Pack
+	require.NoError(t, testutil.ParseResponse(rec, &exported))
+	require.Len(t, exported.Entries, 2)
+	assert.Equal(t, "Ice Brand", exported.Entries[0].Name)
+	assert.Equal(t, "A blade of eternal ice.", exported.Entries[0].Description)
+	assert.Equal(t, "Frozen North", exported.Entries[1].Source)
+	assert.Equal(t, "snow-goblin", exported.Entries[1].Slug)
+
+	// 非法条目整体拒绝
+	req, err = testutil.MakeJSONRequest("POST", "/homebrew/import", homebrew.Pack{
+		Entries: []compendium.Entry{{Type: "vehicles", Name: "Airship"}},
+	})
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, 400, rec.Code)
+}
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/policy"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/validation"
+	"trpg-sync/backend/infrastructure/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MemberHandler struct {
+	db      *gorm.DB
+	storage *storage.CharacterStorage
+	events  *event.Bus
+}
+
+func NewMemberHandler(db *gorm.DB) *MemberHandler {
+	return &MemberHandler{
+		db:      db,
+		storage: storage.NewCharacterStorage().WithIndex(storage.NewCharacterIndex(db)),
+	}
+}
+
+// WithEvents 设置领域事件总线，成员加入、退出、被踢出和 DM 转让后发布事件
+func (h *MemberHandler) WithEvents(bus *event.Bus) *MemberHandler {
+	h.events = bus
+	return h
+}
+
+// MemberView 成员列表中的一项，附带用户昵称和头像
+type MemberView struct {
+	room.Member
+	Nickname string `json:"nickname"`
+	Avatar   string `json:"avatar"`
+}
+
+type JoinRoomRequest struct {
+	InviteCode string `json:"invite_code" binding:"required"`
+	Password   string `json:"password"`
+}
+
+type UpdateRoomPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// KickResponse 踢出玩家的结果，Characters 为按 Cards 方式处理的人物卡 ID
+type KickResponse struct {
+	UserID     uint   `json:"user_id"`
+	Cards      string `json:"cards"`
+	Characters []uint `json:"characters"`
+}
+
+// InviteResponse 房间邀请码
+type InviteResponse struct {
+	RoomID     uint   `json:"room_id"`
+	InviteCode string `json:"invite_code"`
+}
+
+// GetMembers 返回房间成员，DM 在前，其余按加入时间排列
+func (h *MemberHandler) GetMembers(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomView); !ok {
+		return
+	}
+
+	members := []MemberView{}
+	err := h.db.Table("room_members").
+		Select("room_members.*, users.nickname, users.avatar").
+		Joins("LEFT JOIN users ON users.id = room_members.user_id").
+		Where("room_members.room_id = ?", targetRoom.ID).
+		Order("CASE room_members.role WHEN 'dm' THEN 0 ELSE 1 END, room_members.joined_at, room_members.id").
+		Scan(&members).Error
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to load members", err))
+		return
+	}
+	response.Success(c, members)
+}
+
+// JoinRoom 通过邀请码加入房间，房间设有密码时需要同时提供密码
+// 已归档的房间不能加入；玩家数（不含 DM）达到 max_players 时返回房间已满；已是成员时直接返回现有成员记录
+// 被踢出时归档的人物卡在重新加入后恢复
+func (h *MemberHandler) JoinRoom(c *gin.Context) {
+	current, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+
+	var req JoinRoomRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if existing, err := findMember(h.db, targetRoom.ID, current.ID); err == nil {
+		response.OK(c, "Already a member", existing)
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to join room", err))
+		return
+	}
+
+	if targetRoom.IsArchived() {
+		response.Error(c, apperror.New(apperror.CodeRoomArchived, ""))
+		return
+	}
+	if !targetRoom.MatchInviteCode(req.InviteCode) {
+		response.Error(c, apperror.New(apperror.CodeInviteCodeInvalid, ""))
+		return
+	}
+	if !targetRoom.CheckPassword(req.Password) {
+		response.Error(c, apperror.New(apperror.CodeRoomPasswordIncorrect, ""))
+		return
+	}
+
+	// 人数检查和插入在同一条语句中完成，并发加入时不会超过房间当前的人数上限
+	now := time.Now()
+	result := h.db.Exec(`INSERT INTO room_members (room_id, user_id, role, joined_at, created_at, updated_at)
+		SELECT ?, ?, ?, ?, ?, ?
+		WHERE (SELECT COUNT(*) FROM room_members WHERE room_id = ? AND role = ?) < (SELECT max_players FROM rooms WHERE id = ?)`,
+		targetRoom.ID, current.ID, room.RolePlayer, now, now, now, targetRoom.ID, room.RolePlayer, targetRoom.ID)
+	if result.Error != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to join room", result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		response.Error(c, apperror.New(apperror.CodeRoomFull, ""))
+		return
+	}
+	member, err := findMember(h.db, targetRoom.ID, current.ID)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to join room", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.MemberJoined{Member: *member})
+	changes, err := h.updateOwnedCards(targetRoom.ID, current.ID, func(card *character.CharacterCard) bool {
+		if !card.IsArchived() {
+			return false
+		}
+		card.ArchivedAt = nil
+		return true
+	})
+	if err != nil {
+		response.Error(c, storageError("Failed to restore characters", err))
+		return
+	}
+	h.publishCardChanges(eventMeta(c), changes)
+	response.OK(c, "Joined room successfully", member)
+}
+
+// LeaveRoom 当前用户退出房间，DM 需要先转让 DM 身份
+func (h *MemberHandler) LeaveRoom(c *gin.Context) {
+	current, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+
+	member, err := findMember(h.db, targetRoom.ID, current.ID)
+	if err != nil {
+		response.Error(c, recordError(apperror.CodeMemberNotFound, err))
+		return
+	}
+	if member.IsDM() {
+		response.Error(c, apperror.New(apperror.CodeMemberConflict, "The DM cannot leave the room"))
+		return
+	}
+
+	if err := h.removeMember(member); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to leave room", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.MemberLeft{Member: *member})
+	response.OK(c, "Left room successfully", nil)
+}
+
+// KickMember DM 将玩家踢出房间，查询参数 cards 决定其人物卡的处理方式：
+// archive（默认）归档为只读，玩家重新加入后恢复；transfer 转给 DM
+// 被踢出的玩家仍可用邀请码重新加入，需要时请重新生成邀请码
+func (h *MemberHandler) KickMember(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	actor, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomManageMembers)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid user ID"))
+		return
+	}
+	cards := c.DefaultQuery("cards", room.KickCardsArchive)
+	if !room.IsValidKickCards(cards) {
+		respondValidation(c, []validation.FieldError{validation.NewError("cards", validation.CodeInvalidChoice, map[string]interface{}{
+			"choices": room.KickCardsArchive + ", " + room.KickCardsTransfer,
+		})})
+		return
+	}
+
+	member, err := findMember(h.db, targetRoom.ID, uint(userID))
+	if err != nil {
+		response.Error(c, recordError(apperror.CodeMemberNotFound, err))
+		return
+	}
+	if member.IsDM() {
+		response.Error(c, apperror.New(apperror.CodeMemberConflict, "The DM cannot be kicked"))
+		return
+	}
+
+	// 先处理人物卡再删除成员记录，删除失败时恢复人物卡，不会出现成员已移除但人物卡仍可编辑的情况
+	// 没有 DM 的房间中转给 DM 的人物卡没有所有者，与成员功能之前创建的人物卡一样归 DM 管理
+	var newOwner uint
+	if actor.IsDM() {
+		newOwner = actor.UserID
+	}
+	now := time.Now()
+	changes, err := h.updateOwnedCards(targetRoom.ID, member.UserID, func(card *character.CharacterCard) bool {
+		if cards == room.KickCardsTransfer {
+			card.OwnerID = newOwner
+			card.ArchivedAt = nil
+		} else if !card.IsArchived() {
+			card.ArchivedAt = &now
+		}
+		return true
+	})
+	if err != nil {
+		response.Error(c, storageError("Failed to update kicked member's characters", err))
+		return
+	}
+	if err := h.removeMember(member); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to kick member", errors.Join(err, h.restoreCards(changes))))
+		return
+	}
+
+	characters := h.publishCardChanges(eventMeta(c), changes)
+	h.events.PublishFrom(eventMeta(c), event.MemberKicked{Member: *member, KickedBy: actor.UserID, Cards: cards, Characters: characters})
+	response.OK(c, "Member kicked successfully", KickResponse{UserID: member.UserID, Cards: cards, Characters: characters})
+}
+
+// GetInvite 返回房间邀请码，仅 DM 可见；没有邀请码的旧房间在此时生成
+func (h *MemberHandler) GetInvite(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomManageMembers); !ok {
+		return
+	}
+
+	if targetRoom.InviteCode == "" {
+		targetRoom.RotateInviteCode()
+		if err := h.db.Model(targetRoom).Update("invite_code", targetRoom.InviteCode).Error; err != nil {
+			response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to generate invite code", err))
+			return
+		}
+	}
+	response.Success(c, InviteResponse{RoomID: targetRoom.ID, InviteCode: targetRoom.InviteCode})
+}
+
+// RotateInvite 重新生成邀请码，旧邀请码立即失效，已加入的成员不受影响
+func (h *MemberHandler) RotateInvite(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomManageMembers); !ok {
+		return
+	}
+
+	targetRoom.RotateInviteCode()
+	if err := h.db.Model(targetRoom).Update("invite_code", targetRoom.InviteCode).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to rotate invite code", err))
+		return
+	}
+	response.OK(c, "Invite code rotated successfully", InviteResponse{RoomID: targetRoom.ID, InviteCode: targetRoom.InviteCode})
+}
+
+// UpdatePassword 设置或取消（password 为空）房间密码，只影响之后的加入
+func (h *MemberHandler) UpdatePassword(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomManageMembers); !ok {
+		return
+	}
+	before := *targetRoom
+
+	var req UpdateRoomPasswordRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+	if errs := room.ValidatePassword(req.Password); len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+	if err := targetRoom.SetPassword(req.Password); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to update room password", err))
+		return
+	}
+
+	err := h.db.Model(targetRoom).Updates(map[string]interface{}{
+		"password_hash": targetRoom.PasswordHash,
+		"has_password":  targetRoom.HasPassword,
+	}).Error
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to update room password", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.RoomUpdated{Before: before, After: *targetRoom})
+	response.OK(c, "Room password updated successfully", targetRoom)
+}
+
+// removeMember 删除成员记录，以该成员为转让对象的待确认 DM 转让一并作废
+func (h *MemberHandler) removeMember(member *room.Member) error {
+	return h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("room_id = ? AND to_user_id = ?", member.RoomID, member.UserID).Delete(&room.Transfer{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(member).Error
+	})
+}
+
+// cardChange 已保存的人物卡修改，用于发布事件或回滚
+type cardChange struct {
+	before character.CharacterCard
+	after  *character.CharacterCard
+}
+
+// updateOwnedCards 对房间中属于 userID 的人物卡执行 update，返回 true 的人物卡保存
+// 任一人物卡保存失败时恢复已保存的人物卡；不发布事件，调用方确认操作完成后再调用 publishCardChanges
+func (h *MemberHandler) updateOwnedCards(roomID, userID uint, update func(*character.CharacterCard) bool) ([]cardChange, error) {
+	cards, err := h.storage.GetRoomCharacters(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []cardChange
+	for i := range cards {
+		card := &cards[i]
+		if card.OwnerID != userID {
+			continue
+		}
+		before := card.Clone()
+		if !update(card) {
+			continue
+		}
+		if err := h.storage.SaveCharacter(card); err != nil {
+			return nil, errors.Join(err, h.restoreCards(changes))
+		}
+		changes = append(changes, cardChange{before: before, after: card})
+	}
+	return changes, nil
+}
+
+// restoreCards 将人物卡恢复为修改前的内容
+func (h *MemberHandler) restoreCards(changes []cardChange) error {
+	var errs []error
+	for i := range changes {
+		if err := h.storage.SaveCharacter(&changes[i].before); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// publishCardChanges 为已保存的人物卡发布更新事件，返回人物卡 ID
+func (h *MemberHandler) publishCardChanges(meta event.Meta, changes []cardChange) []uint {
+	ids := make([]uint, 0, len(changes))
+	for _, change := range changes {
+		ids = append(ids, change.after.ID)
+		h.events.PublishFrom(meta, event.CharacterUpdated{Before: change.before, After: *change.after})
+	}
+	return ids
+}
+
+// findMember 加载房间中指定用户的成员记录
+func findMember(db *gorm.DB, roomID, userID uint) (*room.Member, error) {
+	var member room.Member
+	if err := db.Where("room_id = ? AND user_id = ?", roomID, userID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
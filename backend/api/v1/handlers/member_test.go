@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"trpg-sync/backend/api/middleware"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/user"
+	auditlog "trpg-sync/backend/infrastructure/audit"
+	"trpg-sync/backend/infrastructure/auth"
+	"trpg-sync/backend/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memberTestServer 带认证中间件（不强制登录）的房间和成员路由，tokens 为各测试用户的访问令牌
+type memberTestServer struct {
+	db     *gorm.DB
+	router *gin.Engine
+	tokens map[string]string
+	users  map[string]uint
+	events []string
+}
+
+func setupMemberServer(t *testing.T, names ...string) *memberTestServer {
+	db := testutil.SetupMigratedDB(t)
+
+	issuer := auth.NewIssuer([]byte("test-secret"), time.Hour, 0)
+	s := &memberTestServer{db: db, tokens: map[string]string{}, users: map[string]uint{}}
+	for _, name := range names {
+		u := user.User{Email: name + "@example.com", Nickname: name, Password: "-"}
+		require.NoError(t, db.Create(&u).Error)
+		pair, err := issuer.Issue(u.ID)
+		require.NoError(t, err)
+		s.tokens[name] = pair.AccessToken
+		s.users[name] = u.ID
+	}
+
+	bus := event.NewBus()
+	bus.SubscribeAll(func(e event.Event) {
+		s.events = append(s.events, e.Name())
+	})
+	auditlog.Record(bus, db)
+
+	roomHandler := NewRoomHandler(db).WithEvents(bus)
+	memberHandler := NewMemberHandler(db).WithEvents(bus)
+	s.router = testutil.SetupTestRouter()
+	api := s.router.Group("", middleware.Auth(db, issuer, false))
+	api.POST("/rooms", roomHandler.CreateRoom)
+	api.DELETE("/rooms/:id", roomHandler.DeleteRoom)
+	api.POST("/rooms/:id/archive", roomHandler.ArchiveRoom)
+	api.GET("/rooms/:id/members", memberHandler.GetMembers)
+	api.POST("/rooms/:id/join", memberHandler.JoinRoom)
+	api.POST("/rooms/:id/leave", memberHandler.LeaveRoom)
+	api.POST("/rooms/:id/kick/:userId", memberHandler.KickMember)
+	api.GET("/rooms/:id/invite", memberHandler.GetInvite)
+	api.POST("/rooms/:id/invite/rotate", memberHandler.RotateInvite)
+	api.PUT("/rooms/:id/password", memberHandler.UpdatePassword)
+	api.GET("/rooms/:id/transfer", memberHandler.GetTransfer)
+	api.POST("/rooms/:id/transfer", memberHandler.RequestTransfer)
+	api.POST("/rooms/:id/transfer/accept", memberHandler.AcceptTransfer)
+	api.DELETE("/rooms/:id/transfer", memberHandler.CancelTransfer)
+
+	characterHandler := NewCharacterHandler(db).WithEvents(bus)
+	api.POST("/characters/:roomId", characterHandler.CreateCharacter)
+	api.PUT("/characters/:roomId/:charId", characterHandler.UpdateCharacter)
+	api.GET("/audit", NewAuditHandler(db).GetAuditLog)
+	return s
+}
+
+// do 以 as 的身份发起请求，as 为空时匿名访问
+func (s *memberTestServer) do(t *testing.T, as, method, url string, body interface{}) (*httptest.ResponseRecorder, response.Body) {
+	req, err := testutil.MakeJSONRequest(method, url, body)
+	require.NoError(t, err)
+	if as != "" {
+		req.Header.Set("Authorization", "Bearer "+s.tokens[as])
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	var resp response.Body
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), rec.Body.String())
+	return rec, resp
+}
+
+func (s *memberTestServer) createRoom(t *testing.T, as string, body map[string]interface{}) room.Room {
+	rec, _ := s.do(t, as, "POST", "/rooms", body)
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	var resp struct {
+		Data room.Room `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.Data
+}
+
+func (s *memberTestServer) inviteCode(t *testing.T, as string, roomID uint) string {
+	rec, _ := s.do(t, as, "GET", roomURL(roomID, "/invite"), nil)
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	var resp struct {
+		Data InviteResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data.InviteCode, room.InviteCodeLength)
+	return resp.Data.InviteCode
+}
+
+func roomURL(roomID uint, suffix string) string {
+	return fmt.Sprintf("/rooms/%d%s", roomID, suffix)
+}
+
+func TestMemberHandler_CreateRoomWithDM(t *testing.T) {
+	s := setupMemberServer(t, "dm")
+
+	rec, _ := s.do(t, "dm", "POST", "/rooms", map[string]interface{}{"name": "Rime of the Frostmaiden", "password": "brr"})
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	body := rec.Body.String()
+	assert.Contains(t, body, `"has_password":true`)
+	assert.Contains(t, body, `"max_players":10`)
+	assert.NotContains(t, body, "invite_code")
+	assert.NotContains(t, body, "brr")
+
+	var created struct {
+		Data room.Room `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+
+	rec, _ = s.do(t, "dm", "GET", roomURL(created.Data.ID, "/members"), nil)
+	require.Equal(t, 200, rec.Code)
+	var members struct {
+		Data []MemberView `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &members))
+	require.Len(t, members.Data, 1)
+	assert.Equal(t, s.users["dm"], members.Data[0].UserID)
+	assert.Equal(t, room.RoleDM, members.Data[0].Role)
+	assert.Equal(t, "dm", members.Data[0].Nickname)
+
+	t.Run("validation", func(t *testing.T) {
+		rec, resp := s.do(t, "dm", "POST", "/rooms", map[string]interface{}{"name": "Too Big", "max_players": room.MaxPlayerCount + 1})
+		assert.Equal(t, 400, rec.Code)
+		assert.Equal(t, apperror.CodeValidationFailed, resp.ErrorCode)
+		assert.Contains(t, rec.Body.String(), `"field":"max_players"`)
+
+		rec, _ = s.do(t, "dm", "POST", "/rooms", map[string]interface{}{"name": "Long Password", "password": strings.Repeat("x", room.MaxPasswordLength+1)})
+		assert.Equal(t, 400, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"field":"password"`)
+	})
+
+	t.Run("delete removes members", func(t *testing.T) {
+		rec, _ := s.do(t, "dm", "DELETE", roomURL(created.Data.ID, ""), nil)
+		require.Equal(t, 200, rec.Code)
+		var count int64
+		s.db.Model(&room.Member{}).Where("room_id = ?", created.Data.ID).Count(&count)
+		assert.Zero(t, count)
+	})
+}
+
+func TestMemberHandler_JoinRoom(t *testing.T) {
+	s := setupMemberServer(t, "dm", "alice", "bob", "carol")
+	target := s.createRoom(t, "dm", map[string]interface{}{"name": "Curse of Strahd", "password": "ravenloft", "max_players": 2})
+	code := s.inviteCode(t, "dm", target.ID)
+	joinURL := roomURL(target.ID, "/join")
+
+	tests := []struct {
+		name   string
+		as     string
+		body   map[string]interface{}
+		status int
+		code   apperror.Code
+	}{
+		{"anonymous", "", map[string]interface{}{"invite_code": code, "password": "ravenloft"}, 401, apperror.CodeUnauthorized},
+		{"missing code", "alice", map[string]interface{}{"password": "ravenloft"}, 400, apperror.CodeValidationFailed},
+		{"wrong code", "alice", map[string]interface{}{"invite_code": "WRONGONE", "password": "ravenloft"}, 403, apperror.CodeInviteCodeInvalid},
+		{"missing password", "alice", map[string]interface{}{"invite_code": code}, 403, apperror.CodeRoomPasswordIncorrect},
+		{"wrong password", "alice", map[string]interface{}{"invite_code": code, "password": "barovia"}, 403, apperror.CodeRoomPasswordIncorrect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, resp := s.do(t, tt.as, "POST", joinURL, tt.body)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.code, resp.ErrorCode)
+		})
+	}
+
+	// 邀请码不区分大小写，可以带分隔符
+	loose := strings.ToLower(code[:4]) + "-" + code[4:]
+	rec, _ := s.do(t, "alice", "POST", joinURL, map[string]interface{}{"invite_code": loose, "password": "ravenloft"})
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"role":"player"`)
+
+	rec, resp := s.do(t, "alice", "POST", joinURL, map[string]interface{}{"invite_code": code, "password": "ravenloft"})
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "Already a member", resp.Message)
+
+	rec, _ = s.do(t, "bob", "POST", joinURL, map[string]interface{}{"invite_code": code, "password": "ravenloft"})
+	require.Equal(t, 200, rec.Code)
+
+	// DM 不计入玩家上限
+	rec, resp = s.do(t, "carol", "POST", joinURL, map[string]interface{}{"invite_code": code, "password": "ravenloft"})
+	assert.Equal(t, 409, rec.Code)
+	assert.Equal(t, apperror.CodeRoomFull, resp.ErrorCode)
+
+	assert.Equal(t, []string{"room.created", "member.joined", "member.joined"}, s.events)
+
+	t.Run("archived", func(t *testing.T) {
+		other := s.createRoom(t, "dm", map[string]interface{}{"name": "Finished"})
+		otherCode := s.inviteCode(t, "dm", other.ID)
+		rec, _ := s.do(t, "dm", "POST", roomURL(other.ID, "/archive"), nil)
+		require.Equal(t, 200, rec.Code)
+
+		rec, resp := s.do(t, "carol", "POST", roomURL(other.ID, "/join"), map[string]interface{}{"invite_code": otherCode})
+		assert.Equal(t, 409, rec.Code)
+		assert.Equal(t, apperror.CodeRoomArchived, resp.ErrorCode)
+	})
+}
+
+func TestMemberHandler_LeaveAndKick(t *testing.T) {
+	s := setupMemberServer(t, "dm", "alice", "bob", "mallory")
+	target := s.createRoom(t, "dm", map[string]interface{}{"name": "Tomb of Annihilation"})
+	code := s.inviteCode(t, "dm", target.ID)
+	for _, name := range []string{"alice", "bob", "mallory"} {
+		rec, _ := s.do(t, name, "POST", roomURL(target.ID, "/join"), map[string]interface{}{"invite_code": code})
+		require.Equal(t, 200, rec.Code)
+	}
+	kickURL := func(name string) string {
+		return roomURL(target.ID, fmt.Sprintf("/kick/%d", s.users[name]))
+	}
+
+	t.Run("kick", func(t *testing.T) {
+		rec, resp := s.do(t, "mallory", "POST", kickURL("bob"), nil)
+		assert.Equal(t, 403, rec.Code)
+		assert.Equal(t, apperror.CodeForbidden, resp.ErrorCode)
+
+		rec, _ = s.do(t, "", "POST", kickURL("bob"), nil)
+		assert.Equal(t, 401, rec.Code)
+
+		rec, resp = s.do(t, "dm", "POST", kickURL("dm"), nil)
+		assert.Equal(t, 409, rec.Code)
+		assert.Equal(t, apperror.CodeMemberConflict, resp.ErrorCode)
+
+		rec, _ = s.do(t, "dm", "POST", kickURL("mallory"), nil)
+		require.Equal(t, 200, rec.Code)
+
+		rec, resp = s.do(t, "dm", "POST", kickURL("mallory"), nil)
+		assert.Equal(t, 404, rec.Code)
+		assert.Equal(t, apperror.CodeMemberNotFound, resp.ErrorCode)
+
+		rec, resp = s.do(t, "dm", "POST", roomURL(target.ID, "/kick/abc"), nil)
+		assert.Equal(t, 400, rec.Code)
+		assert.Equal(t, apperror.CodeInvalidID, resp.ErrorCode)
+	})
+
+	t.Run("rotate invite", func(t *testing.T) {
+		rec, _ := s.do(t, "alice", "POST", roomURL(target.ID, "/invite/rotate"), nil)
+		assert.Equal(t, 403, rec.Code)
+		rec, _ = s.do(t, "alice", "GET", roomURL(target.ID, "/invite"), nil)
+		assert.Equal(t, 403, rec.Code)
+
+		rec, _ = s.do(t, "dm", "POST", roomURL(target.ID, "/invite/rotate"), nil)
+		require.Equal(t, 200, rec.Code)
+		rotated := s.inviteCode(t, "dm", target.ID)
+		assert.NotEqual(t, code, rotated)
+
+		// 被踢出的玩家不能再用旧邀请码加入
+		rec, resp := s.do(t, "mallory", "POST", roomURL(target.ID, "/join"), map[string]interface{}{"invite_code": code})
+		assert.Equal(t, 403, rec.Code)
+		assert.Equal(t, apperror.CodeInviteCodeInvalid, resp.ErrorCode)
+	})
+
+	t.Run("leave", func(t *testing.T) {
+		rec, resp := s.do(t, "dm", "POST", roomURL(target.ID, "/leave"), nil)
+		assert.Equal(t, 409, rec.Code)
+		assert.Equal(t, apperror.CodeMemberConflict, resp.ErrorCode)
+
+		rec, _ = s.do(t, "alice", "POST", roomURL(target.ID, "/leave"), nil)
+		require.Equal(t, 200, rec.Code)
+
+		rec, resp = s.do(t, "alice", "POST", roomURL(target.ID, "/leave"), nil)
+		assert.Equal(t, 404, rec.Code)
+		assert.Equal(t, apperror.CodeMemberNotFound, resp.ErrorCode)
+
+		rec, _ = s.do(t, "", "POST", roomURL(target.ID, "/leave"), nil)
+		assert.Equal(t, 401, rec.Code)
+	})
+
+	var roles []string
+	s.db.Model(&room.Member{}).Where("room_id = ?", target.ID).Order("id").Pluck("role", &roles)
+	assert.Equal(t, []string{room.RoleDM, room.RolePlayer}, roles)
+	assert.Contains(t, s.events, "member.kicked")
+	assert.Contains(t, s.events, "member.left")
+}
+
+func TestMemberHandler_UpdatePassword(t *testing.T) {
+	s := setupMemberServer(t, "dm", "alice")
+	target := s.createRoom(t, "dm", map[string]interface{}{"name": "Waterdeep"})
+	code := s.inviteCode(t, "dm", target.ID)
+
+	rec, _ := s.do(t, "alice", "PUT", roomURL(target.ID, "/password"), map[string]interface{}{"password": "dragon"})
+	assert.Equal(t, 403, rec.Code)
+
+	rec, _ = s.do(t, "dm", "PUT", roomURL(target.ID, "/password"), map[string]interface{}{"password": "dragon"})
+	require.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"has_password":true`)
+
+	rec, _ = s.do(t, "alice", "POST", roomURL(target.ID, "/join"), map[string]interface{}{"invite_code": code})
+	assert.Equal(t, 403, rec.Code)
+
+	rec, _ = s.do(t, "dm", "PUT", roomURL(target.ID, "/password"), map[string]interface{}{"password": ""})
+	require.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"has_password":false`)
+
+	rec, _ = s.do(t, "alice", "POST", roomURL(target.ID, "/join"), map[string]interface{}{"invite_code": code})
+	assert.Equal(t, 200, rec.Code)
+}
+
+func TestMemberHandler_LocalMode(t *testing.T) {
+	s := setupMemberServer(t, "alice")
+
+	// 未登录创建的房间没有 DM，任何人都可以管理邀请码
+	target := s.createRoom(t, "", map[string]interface{}{"name": "Solo Campaign"})
+	code := s.inviteCode(t, "", target.ID)
+
+	rec, _ := s.do(t, "alice", "POST", roomURL(target.ID, "/join"), map[string]interface{}{"invite_code": code})
+	require.Equal(t, 200, rec.Code)
+
+	var count int64
+	s.db.Model(&room.Member{}).Where("room_id = ?", target.ID).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
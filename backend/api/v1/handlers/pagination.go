@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"trpg-sync/backend/domain/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 分页参数默认值和上限
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+const cursorPrefix = "o:"
+
+// Pagination 列表分页参数，提供 cursor 时以 cursor 为准，忽略 page
+type Pagination struct {
+	Page     int
+	PageSize int
+	Offset   int
+}
+
+// PageResult 分页列表响应，next_cursor 为空表示没有下一页
+type PageResult[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// parsePagination 解析 page、page_size 和 cursor 查询参数
+func parsePagination(c *gin.Context) (Pagination, []validation.FieldError) {
+	var errs []validation.FieldError
+	p := Pagination{Page: 1, PageSize: DefaultPageSize}
+
+	if value := c.Query("page_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > MaxPageSize {
+			errs = append(errs, validation.NewError("page_size", validation.CodeOutOfRange, map[string]interface{}{"min": 1, "max": MaxPageSize}))
+		} else {
+			p.PageSize = size
+		}
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		offset, ok := decodeCursor(cursor)
+		if !ok {
+			errs = append(errs, validation.NewError("cursor", validation.CodeInvalidCursor, nil))
+			return p, errs
+		}
+		p.Offset = offset
+		p.Page = offset/p.PageSize + 1
+		return p, errs
+	}
+
+	if value := c.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			errs = append(errs, validation.NewError("page", validation.CodeTooSmall, map[string]interface{}{"min": 1}))
+		} else {
+			p.Page = page
+		}
+	}
+	p.Offset = (p.Page - 1) * p.PageSize
+	return p, errs
+}
+
+// newPageResult 组装分页响应，还有剩余条目时生成下一页的 cursor
+func newPageResult[T any](items []T, total int64, p Pagination) PageResult[T] {
+	if items == nil {
+		items = []T{}
+	}
+	result := PageResult[T]{Items: items, Total: total, Page: p.Page, PageSize: p.PageSize}
+	if next := p.Offset + len(items); int64(next) < total && len(items) > 0 {
+		result.NextCursor = encodeCursor(next)
+	}
+	return result
+}
+
+// encodeCursor 将偏移量编码为不透明的 cursor，客户端只需原样传回
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, false
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, false
+	}
+	return offset, true
+}
+
+// likePattern 生成 LIKE 子串匹配模式，转义通配符
+func likePattern(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(text) + "%"
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"time"
+	"trpg-sync/backend/api/middleware"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/policy"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/user"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// roomActor 读取当前用户在房间中的身份，失败时直接返回错误响应
+func roomActor(c *gin.Context, db *gorm.DB, roomID uint) (policy.Actor, bool) {
+	actor := policy.Actor{UserID: currentUserID(c), Open: true}
+
+	var members []room.Member
+	if err := db.Where("room_id = ? AND (role = ? OR user_id = ?)", roomID, room.RoleDM, actor.UserID).
+		Find(&members).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to load room members", err))
+		return actor, false
+	}
+	for _, member := range members {
+		if member.IsDM() {
+			actor.Open = false
+		}
+		if actor.UserID != 0 && member.UserID == actor.UserID {
+			actor.Role = member.Role
+		}
+	}
+	return actor, true
+}
+
+// authorizeRoom 检查当前用户对房间的操作权限，无权限时直接返回 401 或 403
+func authorizeRoom(c *gin.Context, db *gorm.DB, targetRoom *room.Room, action policy.Action) (policy.Actor, bool) {
+	actor, ok := roomActor(c, db, targetRoom.ID)
+	if !ok {
+		return actor, false
+	}
+	return actor, authorized(c, policy.Room(actor, action))
+}
+
+// authorizeRoomScope 检查房间范围内容（房间自制内容等）的操作权限，roomID 为 0 表示全局内容，不做限制
+// 房间不存在时返回 404
+func authorizeRoomScope(c *gin.Context, db *gorm.DB, roomID uint, action policy.Action) bool {
+	if roomID == 0 {
+		return true
+	}
+	var targetRoom room.Room
+	if err := db.First(&targetRoom, roomID).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeRoomNotFound, err))
+		return false
+	}
+	_, ok := authorizeRoom(c, db, &targetRoom, action)
+	return ok
+}
+
+// authorized 鉴权失败时返回错误响应
+func authorized(c *gin.Context, err error) bool {
+	if err != nil {
+		response.Error(c, err)
+		return false
+	}
+	return true
+}
+
+// visibleRooms 限定当前用户可见的房间：没有 DM 的房间和自己所在的房间
+func visibleRooms(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(rooms.id NOT IN (SELECT room_id FROM room_members WHERE role = ?) OR rooms.id IN (SELECT room_id FROM room_members WHERE user_id = ?))",
+			room.RoleDM, userID)
+	}
+}
+
+// visibleCharacters 限定当前用户可见的人物卡索引：没有 DM 的房间中的全部人物卡、
+// 自己担任 DM 的房间中的全部人物卡，以及自己所在房间中自己的人物卡
+func visibleCharacters(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(character_index.room_id NOT IN (SELECT room_id FROM room_members WHERE role = ?)"+
+			" OR character_index.room_id IN (SELECT room_id FROM room_members WHERE role = ? AND user_id = ?)"+
+			" OR (character_index.owner_id = ? AND character_index.room_id IN (SELECT room_id FROM room_members WHERE user_id = ?)))",
+			room.RoleDM, room.RoleDM, userID, userID, userID)
+	}
+}
+
+// visibleAudit 限定当前用户可以查看的审计日志：自己的操作、自己担任 DM 的房间，以及自己所在且没有 DM 的房间
+// 已删除的房间只有执行删除等操作的用户本人能看到相关记录；匿名用户不是任何房间的成员，也不匹配 actor_id = 0 的记录
+func visibleAudit(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if userID == 0 {
+			return db.Where("1 = 0")
+		}
+		return db.Where("(audit_log.actor_id = ?"+
+			" OR audit_log.room_id IN (SELECT room_id FROM room_members WHERE role = ? AND user_id = ?)"+
+			" OR (audit_log.room_id IN (SELECT room_id FROM room_members WHERE user_id = ?)"+
+			" AND audit_log.room_id NOT IN (SELECT room_id FROM room_members WHERE role = ?)))",
+			userID, room.RoleDM, userID, userID, room.RoleDM)
+	}
+}
+
+// visibleTemplates 限定当前用户可见的战役模板：自己保存的模板和未登录时保存的模板
+func visibleTemplates(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(room_templates.owner_id = 0 OR room_templates.owner_id = ?)", userID)
+	}
+}
+
+// addDM 将用户设为房间 DM，userID 为 0（未登录的本地模式）时不做任何事
+func addDM(tx *gorm.DB, roomID, userID uint) error {
+	if userID == 0 {
+		return nil
+	}
+	return tx.Create(&room.Member{RoomID: roomID, UserID: userID, Role: room.RoleDM, JoinedAt: time.Now()}).Error
+}
+
+// currentUserID 返回当前登录用户的 ID，匿名访问时为 0
+func currentUserID(c *gin.Context) uint {
+	if current, ok := middleware.CurrentUser(c); ok {
+		return current.ID
+	}
+	return 0
+}
+
+// requireUser 要求已登录，未登录时返回 401
+func requireUser(c *gin.Context) (*user.User, bool) {
+	current, ok := middleware.CurrentUser(c)
+	if !ok {
+		response.Error(c, apperror.New(apperror.CodeUnauthorized, ""))
+		return nil, false
+	}
+	return current, true
+}
@@ -0,0 +1,457 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"trpg-sync/backend/api/middleware"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/homebrew"
+	"trpg-sync/backend/domain/monster"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/user"
+	"trpg-sync/backend/infrastructure/auth"
+	"trpg-sync/backend/infrastructure/realtime"
+	"trpg-sync/backend/infrastructure/search"
+	"trpg-sync/backend/infrastructure/storage"
+	"trpg-sync/backend/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+)
+
+// permissionRoomID 权限测试使用的房间，DM 为 dm，玩家为 owner 和 player，outsider 不是成员
+// 人物卡 1 属于 owner，人物卡 2 属于 dm；房间内有数据卡 1、遭遇 1（含实例 1）和房间自制内容 1，
+// 另有 owner 创建的全局自制内容 2
+const permissionRoomID = 310
+
+type permissionServer struct {
+	db     *gorm.DB
+	router *gin.Engine
+	issuer *auth.Issuer
+	tokens map[string]string
+	ids    map[string]uint
+}
+
+func setupPermissionServer(t *testing.T) *permissionServer {
+	db := testutil.SetupMigratedDB(t)
+
+	issuer := auth.NewIssuer([]byte("test-secret"), time.Hour, 0)
+	ids := map[string]uint{}
+	s := &permissionServer{db: db, issuer: issuer, tokens: map[string]string{}, ids: ids}
+	for _, name := range []string{"dm", "owner", "player", "outsider"} {
+		u := user.User{Email: name + "@example.com", Nickname: name, Password: "-"}
+		require.NoError(t, db.Create(&u).Error)
+		pair, err := issuer.Issue(u.ID)
+		require.NoError(t, err)
+		s.tokens[name] = pair.AccessToken
+		ids[name] = u.ID
+	}
+
+	require.NoError(t, db.Create(&room.Room{ID: permissionRoomID, Name: "Permission Room", AbilityMethod: room.AbilityMethodFree}).Error)
+	require.NoError(t, db.Create(&[]room.Member{
+		{RoomID: permissionRoomID, UserID: ids["dm"], Role: room.RoleDM},
+		{RoomID: permissionRoomID, UserID: ids["owner"], Role: room.RolePlayer},
+		{RoomID: permissionRoomID, UserID: ids["player"], Role: room.RolePlayer},
+	}).Error)
+
+	roomHandler := NewRoomHandler(db)
+	characterHandler := NewCharacterHandler(db)
+	path := characterHandler.storage.GetRoomCharactersPath(permissionRoomID)
+	require.NoError(t, os.RemoveAll(path))
+	t.Cleanup(func() { os.RemoveAll(path) })
+	for _, card := range []character.CharacterCard{
+		{ID: 1, RoomID: permissionRoomID, OwnerID: ids["owner"], Name: "Owner Card", Level: 1},
+		{ID: 2, RoomID: permissionRoomID, OwnerID: ids["dm"], Name: "DM Card", Level: 1},
+	} {
+		require.NoError(t, characterHandler.storage.SaveCharacter(&card))
+	}
+	require.NoError(t, db.Create(&monster.StatBlock{ID: 1, RoomID: permissionRoomID, Name: "Goblin", ChallengeRating: "1/4", HitPoints: 7}).Error)
+	require.NoError(t, db.Create(&encounter.Encounter{ID: 1, RoomID: permissionRoomID, Name: "Ambush", Status: encounter.StatusPlanned}).Error)
+	require.NoError(t, db.Create(&encounter.Combatant{ID: 1, EncounterID: 1, StatBlockID: 1, Name: "Goblin 1", HP: 7, MaxHP: 7}).Error)
+	require.NoError(t, db.Create(&homebrew.Entry{ID: 1, RoomID: permissionRoomID, Type: string(compendium.TypeSpell), Slug: "secret-bolt", Name: "Secret Bolt"}).Error)
+	require.NoError(t, db.Create(&homebrew.Entry{ID: 2, OwnerID: ids["owner"], Type: string(compendium.TypeSpell), Slug: "owner-bolt", Name: "Owner Bolt"}).Error)
+
+	s.router = testutil.SetupTestRouter()
+	api := s.router.Group("", middleware.Auth(db, issuer, false))
+	api.GET("/rooms", roomHandler.GetRooms)
+	api.GET("/rooms/:id", roomHandler.GetRoom)
+	api.PATCH("/rooms/:id", roomHandler.PatchRoom)
+	api.DELETE("/rooms/:id", roomHandler.DeleteRoom)
+	api.POST("/rooms/:id/archive", roomHandler.ArchiveRoom)
+	api.GET("/rooms/:id/export", roomHandler.ExportRoom)
+	api.POST("/rooms/:id/clone", roomHandler.CloneRoom)
+	api.GET("/rooms/:id/party", characterHandler.GetParty)
+	api.GET("/characters/search", characterHandler.SearchCharacters)
+	api.POST("/characters/:roomId", characterHandler.CreateCharacter)
+	api.GET("/characters/:roomId", characterHandler.GetCharacters)
+	api.GET("/characters/:roomId/:charId", characterHandler.GetCharacter)
+	api.PUT("/characters/:roomId/:charId", characterHandler.UpdateCharacter)
+	api.DELETE("/characters/:roomId/:charId", characterHandler.DeleteCharacter)
+
+	shareHandler := NewShareHandler(db)
+	api.POST("/characters/:roomId/:charId/share", shareHandler.CreateShare)
+	api.GET("/characters/:roomId/:charId/shares", shareHandler.GetShares)
+	api.DELETE("/characters/:roomId/:charId/shares/:shareId", shareHandler.RevokeShare)
+	s.router.GET("/share/:token", shareHandler.GetSharedCharacter)
+
+	statBlockHandler := NewStatBlockHandler(db)
+	api.GET("/rooms/:id/statblocks", statBlockHandler.GetStatBlocks)
+	api.POST("/rooms/:id/statblocks", statBlockHandler.CreateStatBlock)
+	api.POST("/rooms/:id/statblocks/clone", statBlockHandler.CloneStatBlock)
+	api.GET("/rooms/:id/statblocks/:blockId", statBlockHandler.GetStatBlock)
+	api.PUT("/rooms/:id/statblocks/:blockId", statBlockHandler.UpdateStatBlock)
+	api.DELETE("/rooms/:id/statblocks/:blockId", statBlockHandler.DeleteStatBlock)
+
+	encounterHandler := NewEncounterHandler(db)
+	api.GET("/rooms/:id/encounters", encounterHandler.GetEncounters)
+	api.POST("/rooms/:id/encounters", encounterHandler.CreateEncounter)
+	api.GET("/rooms/:id/encounters/:encounterId", encounterHandler.GetEncounter)
+	api.PUT("/rooms/:id/encounters/:encounterId", encounterHandler.UpdateEncounter)
+	api.DELETE("/rooms/:id/encounters/:encounterId", encounterHandler.DeleteEncounter)
+	api.POST("/rooms/:id/encounters/:encounterId/spawn", encounterHandler.SpawnCombatants)
+	api.PATCH("/rooms/:id/encounters/:encounterId/combatants/:combatantId", encounterHandler.UpdateCombatant)
+	api.DELETE("/rooms/:id/encounters/:encounterId/combatants/:combatantId", encounterHandler.DeleteCombatant)
+	api.POST("/rooms/:id/encounters/:encounterId/next-turn", encounterHandler.NextTurn)
+	api.POST("/rooms/:id/encounter-difficulty", encounterHandler.CalculateDifficulty)
+
+	rollHandler := NewRollHandler(db)
+	api.POST("/rooms/:id/rolls", rollHandler.Roll)
+
+	homebrewHandler := NewHomebrewHandler(db)
+	api.GET("/homebrew", homebrewHandler.GetHomebrew)
+	api.POST("/homebrew", homebrewHandler.CreateHomebrew)
+	api.GET("/homebrew/export", homebrewHandler.ExportHomebrew)
+	api.POST("/homebrew/import", homebrewHandler.ImportHomebrew)
+	api.GET("/homebrew/:id", homebrewHandler.GetHomebrewEntry)
+	api.PUT("/homebrew/:id", homebrewHandler.UpdateHomebrew)
+	api.DELETE("/homebrew/:id", homebrewHandler.DeleteHomebrew)
+
+	compendiumHandler := NewCompendiumHandler(db)
+	api.GET("/compendium/:type", compendiumHandler.SearchEntries)
+	api.GET("/compendium/:type/:slug", compendiumHandler.GetEntry)
+	api.GET("/rooms/:id/character-options", compendiumHandler.GetCharacterOptions)
+
+	templateHandler := NewTemplateHandler(db)
+	api.POST("/rooms/:id/templates", templateHandler.CreateTemplate)
+	api.GET("/templates", templateHandler.GetTemplates)
+	api.GET("/templates/:id", templateHandler.GetTemplate)
+	api.DELETE("/templates/:id", templateHandler.DeleteTemplate)
+	api.POST("/templates/:id/rooms", templateHandler.CreateRoomFromTemplate)
+
+	searchHandler := NewSearchHandler(db)
+	api.GET("/search", searchHandler.Search)
+
+	apiTokenHandler := NewAPITokenHandler(db)
+	api.POST("/user/tokens", apiTokenHandler.CreateAPIToken)
+	api.GET("/user/tokens", apiTokenHandler.GetAPITokens)
+	api.DELETE("/user/tokens/:id", apiTokenHandler.RevokeAPIToken)
+	return s
+}
+
+func (s *permissionServer) do(t *testing.T, as, method, url string, body interface{}) (*httptest.ResponseRecorder, response.Body) {
+	req, err := testutil.MakeJSONRequest(method, url, body)
+	require.NoError(t, err)
+	if as != "anonymous" {
+		req.Header.Set("Authorization", "Bearer "+s.tokens[as])
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	var resp response.Body
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), rec.Body.String())
+	return rec, resp
+}
+
+func TestPermissions_Matrix(t *testing.T) {
+	card := map[string]interface{}{
+		"name": "Edited", "level": 2,
+		"strength": 15, "dexterity": 12, "constitution": 14, "intelligence": 8, "wisdom": 10, "charisma": 10,
+	}
+	rooms := fmt.Sprintf("/rooms/%d", permissionRoomID)
+	characters := fmt.Sprintf("/characters/%d", permissionRoomID)
+
+	// 每个操作依次对应 dm、owner、player、outsider、anonymous 的期望状态码
+	roles := []string{"dm", "owner", "player", "outsider", "anonymous"}
+	matrix := []struct {
+		name   string
+		method string
+		url    string
+		body   interface{}
+		want   [5]int
+	}{
+		{"view room", "GET", rooms, nil, [5]int{200, 200, 200, 403, 401}},
+		{"update room", "PATCH", rooms, gin.H{"name": "Renamed"}, [5]int{200, 403, 403, 403, 401}},
+		{"archive room", "POST", rooms + "/archive", nil, [5]int{200, 403, 403, 403, 401}},
+		{"dissolve room", "DELETE", rooms, nil, [5]int{200, 403, 403, 403, 401}},
+		{"export room", "GET", rooms + "/export", nil, [5]int{200, 403, 403, 403, 401}},
+		{"clone room", "POST", rooms + "/clone", nil, [5]int{200, 403, 403, 403, 401}},
+		{"save template", "POST", rooms + "/templates", gin.H{"include": gin.H{"characters": true}}, [5]int{200, 403, 403, 403, 401}},
+		{"party overview", "GET", rooms + "/party", nil, [5]int{200, 200, 200, 403, 401}},
+		{"list characters", "GET", characters, nil, [5]int{200, 200, 200, 403, 401}},
+		{"create character", "POST", characters, card, [5]int{200, 200, 200, 403, 401}},
+		{"view player card", "GET", characters + "/1", nil, [5]int{200, 200, 403, 403, 401}},
+		{"edit player card", "PUT", characters + "/1", card, [5]int{403, 200, 403, 403, 401}},
+		{"delete player card", "DELETE", characters + "/1", nil, [5]int{403, 200, 403, 403, 401}},
+		{"view DM card", "GET", characters + "/2", nil, [5]int{200, 403, 403, 403, 401}},
+		{"edit DM card", "PUT", characters + "/2", card, [5]int{200, 403, 403, 403, 401}},
+		{"delete DM card", "DELETE", characters + "/2", nil, [5]int{200, 403, 403, 403, 401}},
+		{"view missing card", "GET", characters + "/99", nil, [5]int{404, 404, 404, 403, 401}},
+		{"edit missing card", "PUT", characters + "/99", card, [5]int{404, 404, 404, 403, 401}},
+		{"delete missing card", "DELETE", characters + "/99", nil, [5]int{404, 404, 404, 403, 401}},
+		{"list stat blocks", "GET", rooms + "/statblocks", nil, [5]int{200, 200, 200, 403, 401}},
+		{"view stat block", "GET", rooms + "/statblocks/1", nil, [5]int{200, 200, 200, 403, 401}},
+		{"create stat block", "POST", rooms + "/statblocks", gin.H{"name": "Orc", "challenge_rating": "1/2"}, [5]int{200, 403, 403, 403, 401}},
+		{"clone stat block", "POST", rooms + "/statblocks/clone", gin.H{"slug": "goblin"}, [5]int{200, 403, 403, 403, 401}},
+		{"edit stat block", "PUT", rooms + "/statblocks/1", gin.H{"name": "Goblin Boss", "challenge_rating": "1"}, [5]int{200, 403, 403, 403, 401}},
+		{"delete stat block", "DELETE", rooms + "/statblocks/1", nil, [5]int{200, 403, 403, 403, 401}},
+		{"list encounters", "GET", rooms + "/encounters", nil, [5]int{200, 200, 200, 403, 401}},
+		{"view encounter", "GET", rooms + "/encounters/1", nil, [5]int{200, 200, 200, 403, 401}},
+		{"create encounter", "POST", rooms + "/encounters", gin.H{"name": "Second Wave"}, [5]int{200, 403, 403, 403, 401}},
+		{"edit encounter", "PUT", rooms + "/encounters/1", gin.H{"name": "Renamed"}, [5]int{200, 403, 403, 403, 401}},
+		{"delete encounter", "DELETE", rooms + "/encounters/1", nil, [5]int{200, 403, 403, 403, 401}},
+		{"spawn combatants", "POST", rooms + "/encounters/1/spawn", gin.H{"stat_block_id": 1, "count": 2}, [5]int{200, 403, 403, 403, 401}},
+		{"edit combatant", "PATCH", rooms + "/encounters/1/combatants/1", gin.H{"hp": 3}, [5]int{200, 403, 403, 403, 401}},
+		{"delete combatant", "DELETE", rooms + "/encounters/1/combatants/1", nil, [5]int{200, 403, 403, 403, 401}},
+		{"next turn", "POST", rooms + "/encounters/1/next-turn", nil, [5]int{200, 403, 403, 403, 401}},
+		{"encounter difficulty", "POST", rooms + "/encounter-difficulty", gin.H{"encounter_id": 1}, [5]int{200, 200, 200, 403, 401}},
+		{"roll", "POST", rooms + "/rolls", gin.H{"formula": "1d20"}, [5]int{200, 200, 200, 403, 401}},
+		{"roll for player card", "POST", rooms + "/rolls", gin.H{"formula": "1d20", "character_id": 1}, [5]int{200, 200, 403, 403, 401}},
+		{"list room homebrew", "GET", fmt.Sprintf("/homebrew?room_id=%d", permissionRoomID), nil, [5]int{200, 200, 200, 403, 401}},
+		{"view room homebrew", "GET", "/homebrew/1", nil, [5]int{200, 200, 200, 403, 401}},
+		{"export room homebrew", "GET", fmt.Sprintf("/homebrew/export?room_id=%d", permissionRoomID), nil, [5]int{200, 200, 200, 403, 401}},
+		{"create room homebrew", "POST", "/homebrew", gin.H{"room_id": permissionRoomID, "type": "spells", "name": "Arc Bolt"}, [5]int{200, 403, 403, 403, 401}},
+		{"edit room homebrew", "PUT", "/homebrew/1", gin.H{"type": "spells", "slug": "secret-bolt", "name": "Secret Bolt II"}, [5]int{200, 403, 403, 403, 401}},
+		{"delete room homebrew", "DELETE", "/homebrew/1", nil, [5]int{200, 403, 403, 403, 401}},
+		{"import room homebrew", "POST", fmt.Sprintf("/homebrew/import?room_id=%d", permissionRoomID), gin.H{"name": "pack", "entries": []gin.H{}}, [5]int{200, 403, 403, 403, 401}},
+		{"room compendium", "GET", fmt.Sprintf("/compendium/spells?room_id=%d", permissionRoomID), nil, [5]int{200, 200, 200, 403, 401}},
+		{"room compendium entry", "GET", fmt.Sprintf("/compendium/spells/secret-bolt?room_id=%d", permissionRoomID), nil, [5]int{200, 200, 200, 403, 401}},
+		{"character options", "GET", rooms + "/character-options", nil, [5]int{200, 200, 200, 403, 401}},
+		{"global homebrew", "POST", "/homebrew", gin.H{"type": "spells", "name": "Open Bolt"}, [5]int{200, 200, 200, 200, 200}},
+		{"view global homebrew", "GET", "/homebrew/2", nil, [5]int{200, 200, 200, 200, 200}},
+		{"edit global homebrew", "PUT", "/homebrew/2", gin.H{"type": "spells", "slug": "owner-bolt", "name": "Owner Bolt II"}, [5]int{403, 200, 403, 403, 401}},
+		{"delete global homebrew", "DELETE", "/homebrew/2", nil, [5]int{403, 200, 403, 403, 401}},
+		{"replace global homebrew", "POST", "/homebrew/import?on_conflict=replace", gin.H{"name": "pack", "entries": []gin.H{{"type": "spells", "slug": "owner-bolt", "name": "Stolen Bolt"}}}, [5]int{403, 200, 403, 403, 401}},
+		{"skip global homebrew", "POST", "/homebrew/import", gin.H{"name": "pack", "entries": []gin.H{{"type": "spells", "slug": "owner-bolt", "name": "Stolen Bolt"}}}, [5]int{200, 200, 200, 200, 200}},
+	}
+	for _, tt := range matrix {
+		for i, role := range roles {
+			t.Run(tt.name+"/"+role, func(t *testing.T) {
+				s := setupPermissionServer(t)
+				rec, resp := s.do(t, role, tt.method, tt.url, tt.body)
+				require.Equal(t, tt.want[i], rec.Code, rec.Body.String())
+				switch rec.Code {
+				case 401:
+					require.Equal(t, apperror.CodeUnauthorized, resp.ErrorCode)
+				case 403:
+					require.Equal(t, apperror.CodeForbidden, resp.ErrorCode)
+					require.NotEmpty(t, resp.Message)
+				}
+			})
+		}
+	}
+}
+
+func TestPermissions_Listings(t *testing.T) {
+	s := setupPermissionServer(t)
+
+	// names 返回列表接口中的名称，data 为数组或分页结果
+	names := func(as, url string) []string {
+		rec, _ := s.do(t, as, "GET", url, nil)
+		require.Equal(t, 200, rec.Code, rec.Body.String())
+		var resp struct {
+			Data json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+		type named struct {
+			Name string `json:"name"`
+		}
+		var items []named
+		if json.Unmarshal(resp.Data, &items) != nil {
+			var page struct {
+				Items []named `json:"items"`
+			}
+			require.NoError(t, json.Unmarshal(resp.Data, &page))
+			items = page.Items
+		}
+		result := []string{}
+		for _, item := range items {
+			result = append(result, item.Name)
+		}
+		return result
+	}
+
+	list := fmt.Sprintf("/characters/%d", permissionRoomID)
+	require.ElementsMatch(t, []string{"Owner Card", "DM Card"}, names("dm", list))
+	require.Equal(t, []string{"Owner Card"}, names("owner", list))
+	require.Empty(t, names("player", list))
+
+	require.Equal(t, []string{"DM Card", "Owner Card"}, names("dm", "/characters/search"))
+	require.Equal(t, []string{"Owner Card"}, names("owner", "/characters/search"))
+	require.Empty(t, names("player", "/characters/search"))
+	require.Empty(t, names("outsider", "/characters/search"))
+	require.Empty(t, names("anonymous", "/characters/search"))
+
+	require.Equal(t, []string{"Permission Room"}, names("player", "/rooms"))
+	require.Empty(t, names("outsider", "/rooms"))
+	require.Empty(t, names("anonymous", "/rooms"))
+}
+
+func TestPermissions_Search(t *testing.T) {
+	s := setupPermissionServer(t)
+	index := search.NewIndex(s.db)
+	var target room.Room
+	require.NoError(t, s.db.First(&target, permissionRoomID).Error)
+	require.NoError(t, index.Put(search.RoomDocument(&target)))
+
+	// titles 返回当前用户搜索 q 时各实体类型命中的标题，命中数与结果条数一致
+	titles := func(as, q string) map[string][]string {
+		rec, _ := s.do(t, as, "GET", "/search?q="+q, nil)
+		require.Equal(t, 200, rec.Code, rec.Body.String())
+		var resp struct {
+			Data SearchResult `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		result := map[string][]string{}
+		for _, group := range resp.Data.Groups {
+			require.Equal(t, int64(len(group.Hits)), group.Total, group.Type)
+			for _, hit := range group.Hits {
+				result[group.Type] = append(result[group.Type], hit.Title)
+			}
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"<mark>Permission</mark> Room"}, titles("player", "permission")[search.TypeRoom])
+	assert.Empty(t, titles("outsider", "permission")[search.TypeRoom])
+	assert.Empty(t, titles("anonymous", "permission")[search.TypeRoom])
+
+	// 玩家只能搜到自己的人物卡，DM 可以搜到房间内全部人物卡
+	assert.ElementsMatch(t, []string{"Owner <mark>Card</mark>", "DM <mark>Card</mark>"}, titles("dm", "card")[search.TypeCharacter])
+	assert.Equal(t, []string{"Owner <mark>Card</mark>"}, titles("owner", "card")[search.TypeCharacter])
+	assert.Empty(t, titles("player", "card")[search.TypeCharacter])
+	assert.Empty(t, titles("outsider", "card")[search.TypeCharacter])
+}
+
+func TestPermissions_Templates(t *testing.T) {
+	s := setupPermissionServer(t)
+	rec, resp := s.do(t, "dm", "POST", fmt.Sprintf("/rooms/%d/templates", permissionRoomID), gin.H{"include": gin.H{"characters": true}})
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	id := uint(resp.Data.(map[string]interface{})["id"].(float64))
+	url := fmt.Sprintf("/templates/%d", id)
+
+	// 模板只对保存它的用户可见，其他用户按不存在处理
+	for _, as := range []string{"owner", "outsider", "anonymous"} {
+		rec, resp := s.do(t, as, "GET", "/templates", nil)
+		require.Equal(t, 200, rec.Code, as)
+		assert.Equal(t, float64(0), resp.Data.(map[string]interface{})["total"], as)
+		for _, method := range []string{"GET", "DELETE"} {
+			rec, resp = s.do(t, as, method, url, nil)
+			assert.Equal(t, 404, rec.Code, as+" "+method)
+			assert.Equal(t, apperror.CodeTemplateNotFound, resp.ErrorCode, as+" "+method)
+		}
+		rec, _ = s.do(t, as, "POST", url+"/rooms", nil)
+		assert.Equal(t, 404, rec.Code, as)
+	}
+
+	rec, resp = s.do(t, "dm", "GET", "/templates", nil)
+	require.Equal(t, 200, rec.Code)
+	assert.Equal(t, float64(1), resp.Data.(map[string]interface{})["total"])
+	rec, resp = s.do(t, "dm", "POST", url+"/rooms", nil)
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	created := uint(resp.Data.(map[string]interface{})["id"].(float64))
+	t.Cleanup(func() { os.RemoveAll(storage.NewCharacterStorage().GetRoomPath(created)) })
+	rec, _ = s.do(t, "dm", "DELETE", url, nil)
+	assert.Equal(t, 200, rec.Code)
+}
+
+func TestPermissions_Realtime(t *testing.T) {
+	s := setupPermissionServer(t)
+	sqlDB, err := s.db.DB()
+	require.NoError(t, err)
+	// 内存数据库每个连接相互独立，WebSocket 与普通请求并发时必须共用同一连接
+	sqlDB.SetMaxOpenConns(1)
+
+	bus := event.NewBus()
+	hub := realtime.NewHub(realtime.DefaultHistory)
+	realtime.Forward(bus, hub)
+	realtimeHandler := NewRealtimeHandler(s.db, hub).WithAllowedOrigins([]string{"http://localhost:5173/, http://localhost:3000"})
+	realtimeHandler.pingInterval = time.Hour
+	memberHandler := NewMemberHandler(s.db).WithEvents(bus)
+	characterHandler := NewCharacterHandler(s.db).WithEvents(bus)
+	api := s.router.Group("/live", middleware.Auth(s.db, s.issuer, false))
+	api.GET("/rooms/:id/ws", realtimeHandler.Connect)
+	api.GET("/rooms/:id/events", realtimeHandler.Events)
+	api.POST("/rooms/:id/kick/:userId", memberHandler.KickMember)
+	api.PUT("/characters/:roomId/:charId", characterHandler.UpdateCharacter)
+
+	// 非成员和匿名用户不能订阅房间事件
+	for _, path := range []string{"ws", "events"} {
+		url := fmt.Sprintf("/live/rooms/%d/%s", permissionRoomID, path)
+		rec, _ := s.do(t, "outsider", "GET", url, nil)
+		assert.Equal(t, 403, rec.Code, path)
+		rec, _ = s.do(t, "anonymous", "GET", url, nil)
+		assert.Equal(t, 401, rec.Code, path)
+	}
+
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+	dial := func(as, origin string) (*websocket.Conn, error) {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + fmt.Sprintf("/live/rooms/%d/ws?access_token=%s", permissionRoomID, s.tokens[as])
+		return websocket.Dial(url, "", origin)
+	}
+	receive := func(ws *websocket.Conn) wsMessage {
+		ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var msg wsMessage
+		require.NoError(t, websocket.JSON.Receive(ws, &msg))
+		return msg
+	}
+
+	// 其它网站的页面不能建立连接，同源页面和配置的来源可以
+	_, err = dial("dm", "http://evil.example")
+	assert.Error(t, err)
+	for _, origin := range []string{server.URL, "http://localhost:5173", "http://localhost:3000"} {
+		ws, err := dial("dm", origin)
+		require.NoError(t, err, origin)
+		assert.Equal(t, MessageHello, receive(ws).Type)
+		ws.Close()
+	}
+
+	player, err := dial("player", server.URL)
+	require.NoError(t, err)
+	defer player.Close()
+	assert.Equal(t, MessageHello, receive(player).Type)
+
+	// 人物卡事件只推送 ID，玩家无权查看的人物卡内容不会经由实时连接泄露
+	rec, _ := s.do(t, "dm", "PUT", fmt.Sprintf("/live/characters/%d/2", permissionRoomID), map[string]interface{}{
+		"name": "Hidden DM Card", "level": 2,
+		"strength": 10, "dexterity": 10, "constitution": 10, "intelligence": 10, "wisdom": 10, "charisma": 10,
+	})
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	msg := receive(player)
+	assert.Equal(t, realtime.EventCharacterUpdated, msg.Type)
+	assert.JSONEq(t, `{"id":2}`, string(msg.Data))
+
+	// 被踢出的玩家先收到踢出事件，随后连接被断开
+	rec, _ = s.do(t, "dm", "POST", fmt.Sprintf("/live/rooms/%d/kick/%d", permissionRoomID, s.ids["player"]), nil)
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	assert.Equal(t, realtime.EventMemberKicked, receive(player).Type)
+	player.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var closed wsMessage
+	assert.Equal(t, io.EOF, websocket.JSON.Receive(player, &closed))
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"trpg-sync/backend/domain/policy"
+	"trpg-sync/backend/domain/validation"
+	"trpg-sync/backend/infrastructure/realtime"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
+)
+
+// 心跳参数：服务端每隔 PingInterval 发送 ping（SSE 为保活注释），WebSocket 超过 PongTimeout 未收到客户端任何消息即断开
+// sseRetry 为建议浏览器 SSE 断线后的重连间隔
+const (
+	PingInterval = 25 * time.Second
+	PongTimeout  = 60 * time.Second
+	writeTimeout = 10 * time.Second
+	sseRetry     = 3 * time.Second
+)
+
+// 连接控制消息类型，与房间事件共用同一种 JSON 结构
+const (
+	MessageHello = "hello"
+	MessagePing  = "ping"
+	MessagePong  = "pong"
+)
+
+type RealtimeHandler struct {
+	db             *gorm.DB
+	hub            *realtime.Hub
+	allowedOrigins map[string]bool
+	pingInterval   time.Duration
+	pongTimeout    time.Duration
+}
+
+func NewRealtimeHandler(db *gorm.DB, hub *realtime.Hub) *RealtimeHandler {
+	return &RealtimeHandler{
+		db:           db,
+		hub:          hub,
+		pingInterval: PingInterval,
+		pongTimeout:  PongTimeout,
+	}
+}
+
+// WithAllowedOrigins 设置允许发起 WebSocket 连接的跨域来源，如 http://localhost:5173；逗号分隔的配置项会被拆开
+func (h *RealtimeHandler) WithAllowedOrigins(origins []string) *RealtimeHandler {
+	h.allowedOrigins = make(map[string]bool)
+	for _, item := range origins {
+		for _, origin := range strings.Split(item, ",") {
+			if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+				h.allowedOrigins[origin] = true
+			}
+		}
+	}
+	return h
+}
+
+// checkOrigin 校验 WebSocket 握手的 Origin，防止其它网站借用户的登录状态连接房间
+// 没有 Origin 的非浏览器客户端、同源页面和配置中允许的来源可以连接，其余返回错误，握手以 403 拒绝
+func (h *RealtimeHandler) checkOrigin(_ *websocket.Config, req *http.Request) error {
+	raw := req.Header.Get("Origin")
+	if raw == "" {
+		return nil
+	}
+	origin, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if origin.Host == req.Host || h.allowedOrigins[strings.TrimRight(raw, "/")] {
+		return nil
+	}
+	return fmt.Errorf("origin %q is not allowed", raw)
+}
+
+// ClientMessage 客户端发来的消息，目前只处理 ping 和 pong
+type ClientMessage struct {
+	Type string `json:"type"`
+}
+
+// Connect 建立房间的 WebSocket 连接
+// 连接建立后先发送 hello（seq 为房间当前序号，data 说明重放情况），再补发 since 之后的事件，之后推送实时事件
+// 客户端断线重连时带上收到的最大 seq，hello 中 complete 为 false 时需要重新拉取房间完整状态
+// 只有能查看房间的用户可以连接，被踢出或退出房间后连接会被断开
+func (h *RealtimeHandler) Connect(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	actor, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomView)
+	if !ok {
+		return
+	}
+
+	since, ok := parseSince(c, "since", c.Query("since"))
+	if !ok {
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: h.checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			h.serve(ws, targetRoom.ID, actor.UserID, since)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// serve 在当前 goroutine 负责全部写入，读取放在单独的 goroutine 中
+func (h *RealtimeHandler) serve(ws *websocket.Conn, roomID, userID uint, since uint64) {
+	defer ws.Close()
+
+	sub, replay := h.hub.Subscribe(roomID, userID, since)
+	defer sub.Close()
+
+	send := func(event realtime.Event) bool {
+		ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+		return websocket.JSON.Send(ws, event) == nil
+	}
+
+	if !send(helloEvent(roomID, since, replay)) {
+		return
+	}
+	for _, event := range replay.Events {
+		if !send(event) {
+			return
+		}
+	}
+
+	pings := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go h.read(ws, pings, done)
+
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, open := <-sub.Events():
+			if !open {
+				return
+			}
+			if !send(event) {
+				return
+			}
+		case <-pings:
+			if !send(realtime.Event{RoomID: roomID, Type: MessagePong, At: time.Now()}) {
+				return
+			}
+		case <-ticker.C:
+			if !send(realtime.Event{RoomID: roomID, Type: MessagePing, At: time.Now()}) {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// read 读取客户端消息，任何消息都会刷新超时；客户端的 ping 交给写入方回复 pong
+func (h *RealtimeHandler) read(ws *websocket.Conn, pings chan<- struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		ws.SetReadDeadline(time.Now().Add(h.pongTimeout))
+
+		var msg ClientMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			return
+		}
+		if msg.Type == MessagePing {
+			select {
+			case pings <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// Events 房间事件的 SSE 订阅，供无法使用 WebSocket 的网络环境使用，与 WebSocket 共用同一个事件中心
+// 每个事件的 id 为序号、event 为事件类型；浏览器自动重连时带上 Last-Event-ID 即可补发，首次连接也可以用 ?since=
+// 连接开始时发送不带 id 的 hello 事件，complete 为 false 时客户端需要重新拉取完整状态；空闲时定期发送注释行保活
+// 权限与 WebSocket 相同
+func (h *RealtimeHandler) Events(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	actor, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomView)
+	if !ok {
+		return
+	}
+
+	field, raw := "since", c.Query("since")
+	if lastID := c.GetHeader("Last-Event-ID"); lastID != "" {
+		field, raw = "Last-Event-ID", lastID
+	}
+	since, ok := parseSince(c, field, raw)
+	if !ok {
+		return
+	}
+
+	sub, replay := h.hub.Subscribe(targetRoom.ID, actor.UserID, since)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// 关闭反向代理（如 nginx）的响应缓冲
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	if !writeSSE(w, helloEvent(targetRoom.ID, since, replay), false) {
+		return
+	}
+	for _, event := range replay.Events {
+		if !writeSSE(w, event, true) {
+			return
+		}
+	}
+	w.Flush()
+
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, open := <-sub.Events():
+			if !open {
+				return
+			}
+			if !writeSSE(w, event, true) {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-c.Request.Context().Done():
+			return
+		}
+		w.Flush()
+	}
+}
+
+// writeSSE 写出一个 SSE 事件，withID 为 false 时不写 id，避免覆盖客户端记录的 Last-Event-ID
+func writeSSE(w io.Writer, event realtime.Event, withID bool) bool {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return false
+	}
+	if withID {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.Seq); err != nil {
+			return false
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, payload)
+	return err == nil
+}
+
+// helloEvent 连接建立时的第一条消息，seq 为房间当前序号
+func helloEvent(roomID uint, since uint64, replay realtime.Replay) realtime.Event {
+	return realtime.Event{
+		Seq:    replay.Seq,
+		RoomID: roomID,
+		Type:   MessageHello,
+		Data: gin.H{
+			"since":    since,
+			"replayed": len(replay.Events),
+			"complete": replay.Complete,
+		},
+		At: time.Now(),
+	}
+}
+
+// parseSince 解析客户端已收到的最大序号，为空时视为 0，field 用于校验错误中的字段名
+func parseSince(c *gin.Context, field, raw string) (uint64, bool) {
+	if raw == "" {
+		return 0, true
+	}
+	value, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		respondValidation(c, []validation.FieldError{validation.NewError(field, validation.CodeInvalidType, map[string]interface{}{"type": "uint64"})})
+		return 0, false
+	}
+	return value, true
+}
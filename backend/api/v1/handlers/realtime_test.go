@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/infrastructure/realtime"
+	"trpg-sync/backend/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// wsMessage 测试中解析服务端推送的消息，data 保留原始 JSON
+type wsMessage struct {
+	Seq    uint64          `json:"seq"`
+	RoomID uint            `json:"room_id"`
+	Type   string          `json:"type"`
+	Data   json.RawMessage `json:"data"`
+}
+
+func TestRealtimeHandler_Connect(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	// 内存数据库每个连接相互独立，WebSocket 与普通请求并发时必须共用同一连接
+	sqlDB.SetMaxOpenConns(1)
+
+	roomID := uint(280)
+	require.NoError(t, db.Create(&room.Room{ID: roomID, Name: "Live Room", AbilityMethod: room.AbilityMethodFree}).Error)
+
+	bus := event.NewBus()
+	hub := realtime.NewHub(realtime.DefaultHistory)
+	realtime.Forward(bus, hub)
+	realtimeHandler := NewRealtimeHandler(db, hub)
+	realtimeHandler.pingInterval = time.Hour
+	heartbeatHandler := NewRealtimeHandler(db, hub)
+	heartbeatHandler.pingInterval = 20 * time.Millisecond
+	characterHandler := NewCharacterHandler(db).WithEvents(bus)
+	encounterHandler := NewEncounterHandler(db).WithEvents(bus)
+	rollHandler := NewRollHandler(db).WithEvents(bus)
+	rollHandler.seed = func() int64 { return 1 }
+	roomHandler := NewRoomHandler(db).WithEvents(bus)
+
+	require.NoError(t, os.RemoveAll(characterHandler.storage.GetRoomCharactersPath(roomID)))
+	defer os.RemoveAll(characterHandler.storage.GetRoomCharactersPath(roomID))
+
+	router := testutil.SetupTestRouter()
+	router.GET("/rooms/:id/ws", realtimeHandler.Connect)
+	router.GET("/rooms/:id/heartbeat", heartbeatHandler.Connect)
+	router.POST("/rooms/:id/rolls", rollHandler.Roll)
+	router.PATCH("/rooms/:id", roomHandler.PatchRoom)
+	router.POST("/rooms/:id/encounters/:encounterId/next-turn", encounterHandler.NextTurn)
+	router.POST("/characters/:roomId", characterHandler.CreateCharacter)
+	router.DELETE("/characters/:roomId/:charId", characterHandler.DeleteCharacter)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	do := func(method, url string, body interface{}) int {
+		req, err := testutil.MakeJSONRequest(method, url, body)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	dial := func(path string) *websocket.Conn {
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + fmt.Sprintf("/rooms/%d/%s", roomID, path)
+		ws, err := websocket.Dial(url, "", server.URL)
+		require.NoError(t, err)
+		return ws
+	}
+	receive := func(ws *websocket.Conn) wsMessage {
+		ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var msg wsMessage
+		require.NoError(t, websocket.JSON.Receive(ws, &msg))
+		return msg
+	}
+	hello := func(ws *websocket.Conn) (wsMessage, map[string]interface{}) {
+		msg := receive(ws)
+		require.Equal(t, MessageHello, msg.Type)
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal(msg.Data, &data))
+		return msg, data
+	}
+
+	// 连接前产生的事件
+	require.Equal(t, 200, do("POST", "/rooms/280/rolls", map[string]interface{}{"formula": "1d20+5"}))
+
+	ws := dial("ws")
+	msg, data := hello(ws)
+	assert.Equal(t, uint64(1), msg.Seq)
+	assert.Equal(t, true, data["complete"])
+	assert.Equal(t, float64(0), data["replayed"])
+
+	require.Equal(t, 200, do("POST", "/characters/280", map[string]interface{}{
+		"name": "Vex", "level": 3, "hp": 20, "max_hp": 20,
+		"strength": 10, "dexterity": 14, "constitution": 12, "intelligence": 10, "wisdom": 13, "charisma": 8,
+	}))
+	msg = receive(ws)
+	assert.Equal(t, realtime.EventCharacterCreated, msg.Type)
+	assert.Equal(t, uint64(2), msg.Seq)
+	assert.Contains(t, string(msg.Data), `"id":`)
+	assert.NotContains(t, string(msg.Data), "Vex")
+
+	require.Equal(t, 200, do("PATCH", "/rooms/280", map[string]interface{}{"schedule": "Fridays"}))
+	msg = receive(ws)
+	assert.Equal(t, realtime.EventRoomUpdated, msg.Type)
+
+	goblins := encounter.Encounter{RoomID: roomID, Name: "Ambush", Status: encounter.StatusPlanned, Combatants: []encounter.Combatant{
+		{Name: "Goblin 1", HP: 7, MaxHP: 7, Initiative: 10},
+		{Name: "Goblin 2", HP: 7, MaxHP: 7, Initiative: 17},
+	}}
+	require.NoError(t, db.Create(&goblins).Error)
+	require.Equal(t, 200, do("POST", fmt.Sprintf("/rooms/280/encounters/%d/next-turn", goblins.ID), nil))
+	msg = receive(ws)
+	assert.Equal(t, realtime.EventEncounterTurn, msg.Type)
+	var turn realtime.TurnPayload
+	require.NoError(t, json.Unmarshal(msg.Data, &turn))
+	assert.Equal(t, 1, turn.Round)
+	assert.Equal(t, "Goblin 2", turn.Combatant.Name)
+
+	// 客户端 ping 由服务端回复 pong
+	require.NoError(t, websocket.JSON.Send(ws, ClientMessage{Type: MessagePing}))
+	assert.Equal(t, MessagePong, receive(ws).Type)
+	ws.Close()
+
+	// 断线期间的事件在重连时按序号补发；客户端传入的种子被忽略
+	require.Equal(t, 200, do("POST", "/rooms/280/rolls", map[string]interface{}{"formula": "2d6", "label": "Damage", "seed": 99}))
+	require.Equal(t, 200, do("DELETE", "/characters/280/1", nil))
+
+	ws = dial("ws?since=4")
+	msg, data = hello(ws)
+	assert.Equal(t, uint64(6), msg.Seq)
+	assert.Equal(t, float64(2), data["replayed"])
+	msg = receive(ws)
+	assert.Equal(t, realtime.EventRoll, msg.Type)
+	assert.Equal(t, uint64(5), msg.Seq)
+	assert.Contains(t, string(msg.Data), `"label":"Damage"`)
+	assert.Contains(t, string(msg.Data), `"seed":1,`)
+	msg = receive(ws)
+	assert.Equal(t, realtime.EventCharacterDeleted, msg.Type)
+	assert.Equal(t, uint64(6), msg.Seq)
+	ws.Close()
+
+	// 序号超出服务端记录时要求客户端重新拉取
+	ws = dial("ws?since=99")
+	_, data = hello(ws)
+	assert.Equal(t, false, data["complete"])
+	ws.Close()
+
+	// 服务端心跳
+	ws = dial("heartbeat")
+	hello(ws)
+	assert.Equal(t, MessagePing, receive(ws).Type)
+	ws.Close()
+
+	assert.Equal(t, 400, do("GET", "/rooms/280/ws?since=abc", nil))
+	assert.Equal(t, 404, do("GET", "/rooms/9999/ws", nil))
+	assert.Equal(t, 400, do("POST", "/rooms/280/rolls", map[string]interface{}{"formula": "d"}))
+}
+
+// sseFrame 一个 SSE 帧，Comment 为注释行内容
+type sseFrame struct {
+	ID      string
+	Event   string
+	Data    string
+	Comment string
+	Retry   string
+}
+
+func readFrame(t *testing.T, r *bufio.Reader) sseFrame {
+	var frame sseFrame
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return frame
+		}
+		if strings.HasPrefix(line, ":") {
+			frame.Comment = strings.TrimSpace(line[1:])
+			continue
+		}
+		key, value, _ := strings.Cut(line, ": ")
+		switch key {
+		case "id":
+			frame.ID = value
+		case "event":
+			frame.Event = value
+		case "data":
+			frame.Data = value
+		case "retry":
+			frame.Retry = value
+		}
+	}
+}
+
+func TestRealtimeHandler_Events(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+
+	roomID := uint(281)
+	require.NoError(t, db.Create(&room.Room{ID: roomID, Name: "Feed Room", AbilityMethod: room.AbilityMethodFree}).Error)
+
+	bus := event.NewBus()
+	hub := realtime.NewHub(realtime.DefaultHistory)
+	realtime.Forward(bus, hub)
+	realtimeHandler := NewRealtimeHandler(db, hub)
+	realtimeHandler.pingInterval = 20 * time.Millisecond
+	characterHandler := NewCharacterHandler(db).WithEvents(bus)
+	rollHandler := NewRollHandler(db).WithEvents(bus)
+
+	require.NoError(t, os.RemoveAll(characterHandler.storage.GetRoomCharactersPath(roomID)))
+	defer os.RemoveAll(characterHandler.storage.GetRoomCharactersPath(roomID))
+
+	router := testutil.SetupTestRouter()
+	router.GET("/rooms/:id/events", realtimeHandler.Events)
+	router.POST("/rooms/:id/rolls", rollHandler.Roll)
+	router.POST("/characters/:roomId", characterHandler.CreateCharacter)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	do := func(method, url string, body interface{}) int {
+		req, err := testutil.MakeJSONRequest(method, url, body)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, 200, do("POST", "/rooms/281/rolls", map[string]interface{}{"formula": "1d20"}))
+	require.Equal(t, 200, do("POST", "/rooms/281/rolls", map[string]interface{}{"formula": "1d8", "label": "Missed"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/rooms/281/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	r := bufio.NewReader(resp.Body)
+	assert.Equal(t, "3000", readFrame(t, r).Retry)
+
+	hello := readFrame(t, r)
+	assert.Equal(t, MessageHello, hello.Event)
+	assert.Empty(t, hello.ID)
+	assert.Contains(t, hello.Data, `"replayed":1`)
+
+	missed := readFrame(t, r)
+	assert.Equal(t, "2", missed.ID)
+	assert.Equal(t, realtime.EventRoll, missed.Event)
+	assert.Contains(t, missed.Data, `"label":"Missed"`)
+
+	require.Equal(t, 200, do("POST", "/characters/281", map[string]interface{}{
+		"name": "Quill", "level": 1, "hp": 8, "max_hp": 8,
+		"strength": 8, "dexterity": 14, "constitution": 12, "intelligence": 15, "wisdom": 10, "charisma": 13,
+	}))
+
+	// 事件之间可能穿插保活注释
+	frame := readFrame(t, r)
+	keepAlive := false
+	for frame.Comment != "" {
+		keepAlive = true
+		frame = readFrame(t, r)
+	}
+	assert.Equal(t, "3", frame.ID)
+	assert.Equal(t, realtime.EventCharacterCreated, frame.Event)
+	assert.Contains(t, frame.Data, `"id":`)
+	assert.NotContains(t, frame.Data, "Quill")
+
+	for !keepAlive {
+		keepAlive = readFrame(t, r).Comment == "keep-alive"
+	}
+
+	rec := httptest.NewRecorder()
+	badReq := httptest.NewRequest("GET", "/rooms/281/events", nil)
+	badReq.Header.Set("Last-Event-ID", "abc")
+	router.ServeHTTP(rec, badReq)
+	assert.Equal(t, 400, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Last-Event-ID")
+}
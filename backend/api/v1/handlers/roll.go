@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"time"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/dice"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/policy"
+	"trpg-sync/backend/infrastructure/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RollHandler 处理公开掷骰，种子始终由服务器生成，seed 仅供测试替换
+type RollHandler struct {
+	db      *gorm.DB
+	storage *storage.CharacterStorage
+	events  *event.Bus
+	seed    func() int64
+}
+
+func NewRollHandler(db *gorm.DB) *RollHandler {
+	return &RollHandler{
+		db:      db,
+		storage: storage.NewCharacterStorage(),
+		seed:    func() int64 { return time.Now().UnixNano() },
+	}
+}
+
+// WithEvents 设置领域事件总线，掷骰后发布事件
+func (h *RollHandler) WithEvents(bus *event.Bus) *RollHandler {
+	h.events = bus
+	return h
+}
+
+type RollRequest struct {
+	Formula     string `json:"formula" binding:"required"`
+	Label       string `json:"label"`
+	CharacterID uint   `json:"character_id"`
+}
+
+// Roll 在房间内公开掷骰，结果由服务器生成并广播，客户端不能指定种子；只有房间成员可以掷骰，指定人物卡时需要能查看该人物卡
+func (h *RollHandler) Roll(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	actor, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomRoll)
+	if !ok {
+		return
+	}
+	if targetRoom.IsArchived() {
+		response.Error(c, apperror.New(apperror.CodeRoomArchived, ""))
+		return
+	}
+
+	var req RollRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	formula, err := dice.ParseFormula(req.Formula)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeBadRequest, "Invalid dice formula"))
+		return
+	}
+
+	result := dice.RollResult{
+		Formula:     formula.String(),
+		Label:       req.Label,
+		CharacterID: req.CharacterID,
+		Seed:        h.seed(),
+		RolledAt:    time.Now(),
+	}
+	if req.CharacterID != 0 {
+		card, err := h.storage.LoadCharacter(targetRoom.ID, req.CharacterID)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		if !authorized(c, policy.Character(actor, policy.CharacterView, card)) {
+			return
+		}
+		result.CharacterName = card.Name
+	}
+
+	result.Total, result.Rolls = dice.NewRoller(result.Seed).Roll(formula)
+
+	h.events.PublishFrom(eventMeta(c), event.RollMade{Room: targetRoom.ID, Result: result})
+	response.OK(c, "Dice rolled successfully", result)
+}
@@ -1,123 +1,622 @@
 package handlers
 
 import (
+	"cmp"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/campaign"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/homebrew"
+	"trpg-sync/backend/domain/monster"
+	"trpg-sync/backend/domain/policy"
 	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/validation"
+	"trpg-sync/backend/infrastructure/search"
+	"trpg-sync/backend/infrastructure/storage"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type RoomHandler struct {
-	db *gorm.DB
+	db      *gorm.DB
+	search  *search.Index
+	storage *storage.CharacterStorage
+	copier  *roomCopier
+	events  *event.Bus
 }
 
 func NewRoomHandler(db *gorm.DB) *RoomHandler {
-	return &RoomHandler{db: db}
+	return &RoomHandler{
+		db:      db,
+		search:  search.NewIndex(db),
+		storage: storage.NewCharacterStorage().WithIndex(storage.NewCharacterIndex(db)),
+		copier:  newRoomCopier(db),
+	}
+}
+
+// WithEvents 设置领域事件总线，房间变更后发布事件
+func (h *RoomHandler) WithEvents(bus *event.Bus) *RoomHandler {
+	h.events = bus
+	return h
 }
 
+// CreateRoomRequest 状态只能为 active 或 paused，归档和取消归档使用专门的接口；玩家数由成员统计，不能设置
 type CreateRoomRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
-	RuleSystem  string `json:"rule_system"`
+	Name          string     `json:"name" binding:"required"`
+	Description   string     `json:"description"`
+	RuleSystem    string     `json:"rule_system"`
+	AbilityMethod string     `json:"ability_method"`
+	Status        string     `json:"status"`
+	CoverImage    string     `json:"cover_image"`
+	Tags          []string   `json:"tags"`
+	Schedule      string     `json:"schedule"`
+	MaxPlayers    int        `json:"max_players"`
+	LastPlayedAt  *time.Time `json:"last_played_at"`
+	// Password 只在创建时使用，之后通过 PUT /rooms/:id/password 修改
+	Password string `json:"password"`
+}
+
+// UpdateRoomRequest PUT 整体替换房间的可编辑字段，未提供的字段恢复为默认值（密码和邀请码不受影响）
+// 未提供状态时保持原状态
+type UpdateRoomRequest = CreateRoomRequest
+
+// PatchRoomRequest PATCH 只修改请求中出现的字段
+type PatchRoomRequest struct {
+	Name          *string    `json:"name"`
+	Description   *string    `json:"description"`
+	RuleSystem    *string    `json:"rule_system"`
+	AbilityMethod *string    `json:"ability_method"`
+	Status        *string    `json:"status"`
+	CoverImage    *string    `json:"cover_image"`
+	Tags          *[]string  `json:"tags"`
+	Schedule      *string    `json:"schedule"`
+	MaxPlayers    *int       `json:"max_players"`
+	LastPlayedAt  *time.Time `json:"last_played_at"`
 }
 
+// applyRoomRequest 用请求内容覆盖房间字段，规则系统和生成方式为空时使用默认值；状态由 applyStatus 处理
+func applyRoomRequest(target *room.Room, req CreateRoomRequest) {
+	target.Name = strings.TrimSpace(req.Name)
+	target.Description = req.Description
+	target.RuleSystem = cmp.Or(req.RuleSystem, room.DefaultRuleSystem)
+	target.AbilityMethod = cmp.Or(req.AbilityMethod, room.AbilityMethodFree)
+	target.CoverImage = req.CoverImage
+	target.Tags = room.NormalizeTags(req.Tags)
+	target.Schedule = req.Schedule
+	target.MaxPlayers = cmp.Or(req.MaxPlayers, room.DefaultMaxPlayers)
+	target.LastPlayedAt = req.LastPlayedAt
+}
+
+// CreateRoom 创建房间并生成邀请码，已登录时创建者成为房间 DM
 func (h *RoomHandler) CreateRoom(c *gin.Context) {
 	var req CreateRoomRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    400,
-			"message": err.Error(),
-			"data":    nil,
-		})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	newRoom := room.Room{
-		Name:        req.Name,
-		Description: req.Description,
-		RuleSystem:  req.RuleSystem,
+	newRoom := room.Room{Status: room.StatusActive}
+	applyRoomRequest(&newRoom, req)
+	if !applyStatus(c, &newRoom, req.Status) {
+		return
 	}
-
-	if err := h.db.Create(&newRoom).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to create room",
-			"data":    nil,
-		})
+	errs := newRoom.Validate()
+	errs = append(errs, room.ValidatePassword(req.Password)...)
+	if len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+	newRoom.RotateInviteCode()
+	if err := newRoom.SetPassword(req.Password); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to create room", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "Room created successfully",
-		"data":    newRoom,
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newRoom).Error; err != nil {
+			return err
+		}
+		return addDM(tx, newRoom.ID, currentUserID(c))
 	})
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to create room", err))
+		return
+	}
+	if err := h.search.Put(search.RoomDocument(&newRoom)); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to index room", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.RoomCreated{Room: newRoom})
+	response.OK(c, "Room created successfully", newRoom)
 }
 
+// GetRooms 分页获取房间列表
+// 支持 sort（name、created_at、last_played_at）和 order（asc、desc）排序，
+// 按 rule_system、status、tag 过滤，q 对名称和简介做子串搜索；
+// 未指定 status 时不返回已归档房间，include_archived=true 时一并返回；只返回当前用户所在的房间和没有 DM 的房间
 func (h *RoomHandler) GetRooms(c *gin.Context) {
+	page, errs := parsePagination(c)
+
+	sortField := c.DefaultQuery("sort", room.SortCreatedAt)
+	if sortField != room.SortName && sortField != room.SortCreatedAt && sortField != room.SortLastPlayed {
+		errs = append(errs, validation.NewError("sort", validation.CodeInvalidChoice, map[string]interface{}{
+			"choices": strings.Join([]string{room.SortName, room.SortCreatedAt, room.SortLastPlayed}, ", "),
+		}))
+	}
+	// 名称默认升序，时间默认最新的在前
+	defaultOrder := "desc"
+	if sortField == room.SortName {
+		defaultOrder = "asc"
+	}
+	order := c.DefaultQuery("order", defaultOrder)
+	if order != "asc" && order != "desc" {
+		errs = append(errs, validation.NewError("order", validation.CodeInvalidChoice, map[string]interface{}{"choices": "asc, desc"}))
+	}
+	status := c.Query("status")
+	if status != "" && !room.IsValidStatus(status) {
+		errs = append(errs, validation.NewError("status", validation.CodeInvalidChoice, map[string]interface{}{
+			"choices": strings.Join([]string{room.StatusActive, room.StatusPaused, room.StatusArchived}, ", "),
+		}))
+	}
+	includeArchived, err := strconv.ParseBool(c.DefaultQuery("include_archived", "false"))
+	if err != nil {
+		errs = append(errs, validation.NewError("include_archived", validation.CodeInvalidType, map[string]interface{}{"type": "bool"}))
+	}
+	if len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+
+	filters := func(db *gorm.DB) *gorm.DB {
+		db = db.Scopes(visibleRooms(currentUserID(c)))
+		if ruleSystem := c.Query("rule_system"); ruleSystem != "" {
+			db = db.Where("rule_system = ?", ruleSystem)
+		}
+		if status != "" {
+			db = db.Where("status = ?", status)
+		} else if !includeArchived {
+			db = db.Where("status <> ?", room.StatusArchived)
+		}
+		if tag := strings.TrimSpace(c.Query("tag")); tag != "" {
+			db = db.Where("EXISTS (SELECT 1 FROM json_each(rooms.tags) WHERE json_each.value = ?)", tag)
+		}
+		if text := strings.TrimSpace(c.Query("q")); text != "" {
+			pattern := likePattern(text)
+			db = db.Where(`(name LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`, pattern, pattern)
+		}
+		return db
+	}
+
+	var total int64
+	if err := h.db.Model(&room.Room{}).Scopes(filters).Count(&total).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get rooms", err))
+		return
+	}
+
 	var rooms []room.Room
-	if err := h.db.Find(&rooms).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to get rooms",
-			"data":    nil,
-		})
+	if err := h.db.Scopes(filters).
+		Order(roomOrder(sortField, order)).
+		Limit(page.PageSize).
+		Offset(page.Offset).
+		Find(&rooms).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get rooms", err))
+		return
+	}
+	if err := fillPlayerCounts(h.db, rooms); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get rooms", err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "Success",
-		"data":    rooms,
-	})
+	response.Success(c, newPageResult(rooms, total, page))
+}
+
+// roomOrder 生成排序子句，以 id 作为次序键保证分页稳定；从未开团的房间总是排在最后
+func roomOrder(sortField, order string) string {
+	switch sortField {
+	case room.SortName:
+		return "name COLLATE NOCASE " + order + ", id " + order
+	case room.SortLastPlayed:
+		return "last_played_at IS NULL, last_played_at " + order + ", id " + order
+	default:
+		return "created_at " + order + ", id " + order
+	}
 }
 
 func (h *RoomHandler) GetRoom(c *gin.Context) {
-	roomID := c.Param("id")
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomView); !ok {
+		return
+	}
+
+	response.Success(c, targetRoom)
+}
 
-	var room room.Room
-	if err := h.db.First(&room, roomID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"code":    404,
-			"message": "Room not found",
-			"data":    nil,
-		})
+// DeleteRoom 解散房间，房间内的成员、人物卡、属性骰、数据卡、遭遇、自制内容、分享链接和索引在同一事务中删除
+// 事务提交后删除房间的人物卡目录，避免房间 ID 被复用时旧数据重新出现
+func (h *RoomHandler) DeleteRoom(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomDelete); !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "Success",
-		"data":    room,
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{
+			&room.Member{}, &room.Transfer{}, &character.IndexEntry{}, &character.MemberRoll{}, &monster.StatBlock{}, &homebrew.Entry{},
+		} {
+			if err := tx.Where("room_id = ?", targetRoom.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		encounters := tx.Model(&encounter.Encounter{}).Select("id").Where("room_id = ?", targetRoom.ID)
+		if err := tx.Where("encounter_id IN (?)", encounters).Delete(&encounter.Combatant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("room_id = ?", targetRoom.ID).Delete(&encounter.Encounter{}).Error; err != nil {
+			return err
+		}
+		if err := deleteShareLinks(tx, targetRoom.ID, 0); err != nil {
+			return err
+		}
+		if err := search.NewIndex(tx).RemoveRoom(targetRoom.ID); err != nil {
+			return err
+		}
+		return tx.Delete(targetRoom).Error
 	})
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to delete room", err))
+		return
+	}
+	if err := h.storage.DeleteRoom(targetRoom.ID); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to delete room characters", err))
+		return
+	}
+	h.events.PublishFrom(eventMeta(c), event.RoomDeleted{Room: *targetRoom})
+
+	response.OK(c, "Room deleted successfully", nil)
 }
 
-func (h *RoomHandler) DeleteRoom(c *gin.Context) {
-	roomIDStr := c.Param("id")
+// UpdateRoom 整体更新房间信息
+func (h *RoomHandler) UpdateRoom(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomUpdate); !ok {
+		return
+	}
+	before := *targetRoom
 
-	var targetRoom room.Room
-	if err := h.db.First(&targetRoom, roomIDStr).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"code":    404,
-			"message": "Room not found",
-			"data":    nil,
-		})
+	var req UpdateRoomRequest
+	if !bindJSON(c, &req) {
 		return
 	}
+	applyRoomRequest(targetRoom, req)
+	if !applyStatus(c, targetRoom, req.Status) {
+		return
+	}
+	h.saveRoom(c, before, targetRoom)
+}
 
-	if err := h.db.Delete(&targetRoom).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    500,
-			"message": "Failed to delete room",
-			"data":    nil,
-		})
+// PatchRoom 部分更新房间信息，如只修改名称或标签
+func (h *RoomHandler) PatchRoom(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomUpdate); !ok {
 		return
 	}
+	before := *targetRoom
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    200,
-		"message": "Room deleted successfully",
-		"data":    nil,
-	})
+	var req PatchRoomRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if req.Name != nil {
+		targetRoom.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		targetRoom.Description = *req.Description
+	}
+	if req.RuleSystem != nil {
+		targetRoom.RuleSystem = cmp.Or(*req.RuleSystem, room.DefaultRuleSystem)
+	}
+	if req.AbilityMethod != nil {
+		targetRoom.AbilityMethod = *req.AbilityMethod
+	}
+	if req.Status != nil && !applyStatus(c, targetRoom, *req.Status) {
+		return
+	}
+	if req.CoverImage != nil {
+		targetRoom.CoverImage = *req.CoverImage
+	}
+	if req.Tags != nil {
+		targetRoom.Tags = room.NormalizeTags(*req.Tags)
+	}
+	if req.Schedule != nil {
+		targetRoom.Schedule = *req.Schedule
+	}
+	if req.MaxPlayers != nil {
+		targetRoom.MaxPlayers = *req.MaxPlayers
+	}
+	if req.LastPlayedAt != nil {
+		targetRoom.LastPlayedAt = req.LastPlayedAt
+	}
+	h.saveRoom(c, before, targetRoom)
+}
+
+// saveRoom 校验并保存房间，返回更新后的房间；before 为修改前的房间，用于发布事件
+func (h *RoomHandler) saveRoom(c *gin.Context, before room.Room, targetRoom *room.Room) {
+	if errs := targetRoom.Validate(); len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+	if err := h.db.Save(targetRoom).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to update room", err))
+		return
+	}
+	if err := h.search.Put(search.RoomDocument(targetRoom)); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to index room", err))
+		return
+	}
+	h.events.PublishFrom(eventMeta(c), event.RoomUpdated{Before: before, After: *targetRoom})
+
+	response.OK(c, "Room updated successfully", targetRoom)
+}
+
+// ArchiveRoom 归档房间，归档后房间仍可查看和导出，但人物卡只读
+func (h *RoomHandler) ArchiveRoom(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomUpdate); !ok {
+		return
+	}
+	before := *targetRoom
+
+	if !targetRoom.IsArchived() {
+		targetRoom.Archive(time.Now())
+	}
+	h.saveRoom(c, before, targetRoom)
+}
+
+// UnarchiveRoom 取消归档，房间恢复为进行中
+func (h *RoomHandler) UnarchiveRoom(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomUpdate); !ok {
+		return
+	}
+	before := *targetRoom
+
+	if targetRoom.IsArchived() {
+		targetRoom.Unarchive()
+	}
+	h.saveRoom(c, before, targetRoom)
+}
+
+// RoomExport 房间导出文件：房间信息、人物卡、怪物数据卡、遭遇和房间自制内容
+type RoomExport struct {
+	ExportedAt time.Time                 `json:"exported_at"`
+	Room       room.Room                 `json:"room"`
+	Characters []character.CharacterCard `json:"characters"`
+	StatBlocks []monster.StatBlock       `json:"stat_blocks"`
+	Encounters []encounter.Encounter     `json:"encounters"`
+	Homebrew   []compendium.Entry        `json:"homebrew"`
+}
+
+// ExportRoom 将房间的全部内容导出为 JSON 文件，已归档的房间同样可以导出
+func (h *RoomHandler) ExportRoom(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomExport); !ok {
+		return
+	}
+
+	export := RoomExport{
+		ExportedAt: time.Now(),
+		Room:       *targetRoom,
+		StatBlocks: []monster.StatBlock{},
+		Encounters: []encounter.Encounter{},
+		Homebrew:   []compendium.Entry{},
+	}
+
+	characters, err := h.storage.GetRoomCharacters(targetRoom.ID)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to export characters", err))
+		return
+	}
+	export.Characters = append([]character.CharacterCard{}, characters...)
+
+	if err := h.db.Where("room_id = ?", targetRoom.ID).Order("id").Find(&export.StatBlocks).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to export stat blocks", err))
+		return
+	}
+	if err := h.db.Preload("Combatants").Where("room_id = ?", targetRoom.ID).Order("id").Find(&export.Encounters).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to export encounters", err))
+		return
+	}
+
+	var entries []homebrew.Entry
+	if err := h.db.Where("room_id = ?", targetRoom.ID).Order("type, name").Find(&entries).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to export homebrew entries", err))
+		return
+	}
+	for _, entry := range entries {
+		export.Homebrew = append(export.Homebrew, entry.ToCompendium())
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, homebrew.Slugify(targetRoom.Name)))
+	c.JSON(http.StatusOK, export)
+}
+
+type CloneRoomRequest struct {
+	Name    string           `json:"name"`
+	Include campaign.Options `json:"include"`
+}
+
+// CloneRoom 复制房间设置到新房间，include 指定是否一并复制人物卡、数据卡、遭遇和自制内容；会话笔记暂不支持
+// 未提供名称时使用 "原名称 (copy)"；新房间总是处于进行中状态，不复制成员、邀请码和密码，已登录时操作者成为 DM
+func (h *RoomHandler) CloneRoom(c *gin.Context) {
+	source, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, source, policy.RoomExport); !ok {
+		return
+	}
+
+	var req CloneRoomRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+	if errs := req.Include.Validate(); len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = source.Name + " (copy)"
+	}
+	if !validateRoomName(c, source, name) {
+		return
+	}
+
+	newRoom, err := h.copier.clone(source, name, req.Include, currentUserID(c))
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to clone room", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.RoomCreated{Room: *newRoom})
+	response.OK(c, "Room cloned successfully", newRoom)
+}
+
+// validateRoomName 按房间规则校验复制或由模板创建的新房间名称
+func validateRoomName(c *gin.Context, base *room.Room, name string) bool {
+	candidate := *base
+	candidate.Name = name
+	candidate.Status = room.StatusActive
+	if errs := candidate.Validate(); len(errs) > 0 {
+		respondValidation(c, errs)
+		return false
+	}
+	return true
+}
+
+type UpdateAbilityMethodRequest struct {
+	AbilityMethod string `json:"ability_method" binding:"required"`
+}
+
+// UpdateAbilityMethod 设置房间允许的属性值生成方式，只影响之后的创建和更新
+func (h *RoomHandler) UpdateAbilityMethod(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomUpdate); !ok {
+		return
+	}
+	before := *targetRoom
+
+	var req UpdateAbilityMethodRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	targetRoom.AbilityMethod = req.AbilityMethod
+	h.saveRoom(c, before, targetRoom)
+}
+
+// findRoom 解析路径参数 :id 并加载房间，失败时直接返回错误响应
+func findRoom(c *gin.Context, db *gorm.DB) (*room.Room, bool) {
+	roomID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
+		return nil, false
+	}
+
+	var target room.Room
+	if err := db.First(&target, roomID).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeRoomNotFound, err))
+		return nil, false
+	}
+	rooms := []room.Room{target}
+	if err := fillPlayerCounts(db, rooms); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to load room", err))
+		return nil, false
+	}
+	return &rooms[0], true
+}
+
+// fillPlayerCounts 按成员表统计房间的玩家数（不含 DM）
+func fillPlayerCounts(db *gorm.DB, rooms []room.Room) error {
+	if len(rooms) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(rooms))
+	for i := range rooms {
+		ids[i] = rooms[i].ID
+	}
+
+	var counts []struct {
+		RoomID  uint
+		Players int
+	}
+	if err := db.Model(&room.Member{}).
+		Select("room_id, COUNT(*) AS players").
+		Where("room_id IN ? AND role = ?", ids, room.RolePlayer).
+		Group("room_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+	players := make(map[uint]int, len(counts))
+	for _, count := range counts {
+		players[count.RoomID] = count.Players
+	}
+	for i := range rooms {
+		rooms[i].PlayerCount = players[rooms[i].ID]
+	}
+	return nil
+}
+
+// applyStatus 通用更新接口只能在 active 和 paused 之间切换状态，状态为空或不变时不做修改，未知状态由 Validate 报错
+// 归档和取消归档需要维护归档时间，只能通过 archive、unarchive 接口
+func applyStatus(c *gin.Context, target *room.Room, status string) bool {
+	if status == "" || status == target.Status {
+		return true
+	}
+	if target.IsArchived() {
+		response.Error(c, apperror.New(apperror.CodeRoomArchived, "Use POST /rooms/:id/unarchive to unarchive the room"))
+		return false
+	}
+	if status == room.StatusArchived {
+		respondValidation(c, []validation.FieldError{validation.NewError("status", validation.CodeInvalidChoice, map[string]interface{}{
+			"choices": strings.Join([]string{room.StatusActive, room.StatusPaused}, ", "),
+		})})
+		return false
+	}
+	target.Status = status
+	return true
 }
@@ -1,26 +1,35 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
-
+	"time"
+
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/homebrew"
+	"trpg-sync/backend/domain/monster"
 	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/validation"
 	"trpg-sync/backend/testutil"
 
-	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestRoomHandler_CreateRoom(t *testing.T) {
-	db := testutil.SetupTestDB(t)
+	db := testutil.SetupMigratedDB(t)
 	defer func() {
 		sqlDB, _ := db.DB()
 		sqlDB.Close()
 	}()
 
-	db.AutoMigrate(&room.Room{})
-
 	handler := NewRoomHandler(db)
 	router := testutil.SetupTestRouter()
 	router.POST("/rooms", handler.CreateRoom)
@@ -47,19 +56,20 @@ func TestRoomHandler_CreateRoom(t *testing.T) {
 			}`,
 			expectedStatus: 400,
 		},
+		{
+			name: "属性生成方式非法",
+			requestBody: `{
+				"name": "Test Room",
+				"ability_method": "divine"
+			}`,
+			expectedStatus: 400,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest("POST", "/rooms", nil)
-			req.Body = nil
-			req.Header.Set("Content-Type", "application/json")
 			rec := httptest.NewRecorder()
-
-			c, _ := gin.CreateTestContext(rec)
-			c.Request = req
-			c.Request.Body = nil
-			c.Request.Header.Set("Content-Type", "application/json")
+			c := testutil.CreateTestContext(rec, "POST", "/rooms", tt.requestBody)
 
 			handler.CreateRoom(c)
 
@@ -69,14 +79,12 @@ func TestRoomHandler_CreateRoom(t *testing.T) {
 }
 
 func TestRoomHandler_GetRooms(t *testing.T) {
-	db := testutil.SetupTestDB(t)
+	db := testutil.SetupMigratedDB(t)
 	defer func() {
 		sqlDB, _ := db.DB()
 		sqlDB.Close()
 	}()
 
-	db.AutoMigrate(&room.Room{})
-
 	handler := NewRoomHandler(db)
 	router := testutil.SetupTestRouter()
 	router.GET("/rooms", handler.GetRooms)
@@ -107,14 +115,12 @@ func TestRoomHandler_GetRooms(t *testing.T) {
 }
 
 func TestRoomHandler_GetRoom(t *testing.T) {
-	db := testutil.SetupTestDB(t)
+	db := testutil.SetupMigratedDB(t)
 	defer func() {
 		sqlDB, _ := db.DB()
 		sqlDB.Close()
 	}()
 
-	db.AutoMigrate(&room.Room{})
-
 	handler := NewRoomHandler(db)
 	router := testutil.SetupTestRouter()
 	router.GET("/rooms/:id", handler.GetRoom)
@@ -161,14 +167,12 @@ func TestRoomHandler_GetRoom(t *testing.T) {
 }
 
 func TestRoomHandler_DeleteRoom(t *testing.T) {
-	db := testutil.SetupTestDB(t)
+	db := testutil.SetupMigratedDB(t)
 	defer func() {
 		sqlDB, _ := db.DB()
 		sqlDB.Close()
 	}()
 
-	db.AutoMigrate(&room.Room{})
-
 	handler := NewRoomHandler(db)
 	router := testutil.SetupTestRouter()
 	router.DELETE("/rooms/:id", handler.DeleteRoom)
@@ -193,3 +197,475 @@ func TestRoomHandler_DeleteRoom(t *testing.T) {
 	db.Model(&room.Room{}).Count(&count)
 	assert.Equal(t, int64(0), count)
 }
+
+func TestRoomHandler_DeleteRoomRemovesData(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	roomHandler := NewRoomHandler(db)
+	characterHandler := NewCharacterHandler(db)
+	router := testutil.SetupTestRouter()
+	router.DELETE("/rooms/:id", roomHandler.DeleteRoom)
+
+	roomID, otherID := uint(340), uint(341)
+	for _, id := range []uint{roomID, otherID} {
+		require.NoError(t, db.Create(&room.Room{ID: id, Name: "Doomed", RuleSystem: "DND5e", Status: room.StatusActive}).Error)
+		require.NoError(t, db.Create(&monster.StatBlock{RoomID: id, Name: "Goblin", ChallengeRating: "1/4"}).Error)
+		require.NoError(t, db.Create(&homebrew.Entry{RoomID: id, Type: string(compendium.TypeSpell), Slug: "homebrew-bolt", Name: "Homebrew Bolt"}).Error)
+		fight := encounter.Encounter{RoomID: id, Name: "Ambush"}
+		require.NoError(t, db.Create(&fight).Error)
+		require.NoError(t, db.Create(&encounter.Combatant{EncounterID: fight.ID, Name: "Goblin"}).Error)
+
+		path := characterHandler.storage.GetRoomPath(id)
+		require.NoError(t, os.RemoveAll(path))
+		t.Cleanup(func() { os.RemoveAll(path) })
+		require.NoError(t, characterHandler.storage.SaveCharacter(&character.CharacterCard{ID: 1, RoomID: id, Name: "Aria", Level: 1, MaxHP: 10, HP: 10}))
+	}
+
+	req := httptest.NewRequest("DELETE", "/rooms/340", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+
+	for _, model := range []interface{}{&character.IndexEntry{}, &monster.StatBlock{}, &homebrew.Entry{}, &encounter.Encounter{}} {
+		var count int64
+		require.NoError(t, db.Model(model).Where("room_id = ?", roomID).Count(&count).Error)
+		assert.Zero(t, count, "%T", model)
+		require.NoError(t, db.Model(model).Where("room_id = ?", otherID).Count(&count).Error)
+		assert.Equal(t, int64(1), count, "%T", model)
+	}
+	var combatants int64
+	require.NoError(t, db.Model(&encounter.Combatant{}).Count(&combatants).Error)
+	assert.Equal(t, int64(1), combatants)
+
+	_, err := os.Stat(characterHandler.storage.GetRoomPath(roomID))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(characterHandler.storage.GetRoomPath(otherID))
+	assert.NoError(t, err)
+}
+
+func TestRoomHandler_CreateRoom_FieldErrors(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	handler := NewRoomHandler(db)
+	router := testutil.SetupTestRouter()
+	router.POST("/rooms", handler.CreateRoom)
+
+	tests := []struct {
+		name            string
+		language        string
+		body            string
+		expectedField   string
+		expectedCode    string
+		expectedMessage string
+	}{
+		{
+			name:            "缺少名称（英文）",
+			language:        "en-US,en;q=0.9",
+			body:            `{"description": "A test room"}`,
+			expectedField:   "name",
+			expectedCode:    validation.CodeRequired,
+			expectedMessage: "is required",
+		},
+		{
+			name:            "缺少名称（中文）",
+			language:        "zh-CN,zh;q=0.9",
+			body:            `{"description": "A test room"}`,
+			expectedField:   "name",
+			expectedCode:    validation.CodeRequired,
+			expectedMessage: "不能为空",
+		},
+		{
+			name:            "字段类型错误",
+			body:            `{"name": 42}`,
+			expectedField:   "name",
+			expectedCode:    validation.CodeInvalidType,
+			expectedMessage: "must be of type string",
+		},
+		{
+			name:            "属性生成方式非法（中文）",
+			language:        "zh",
+			body:            `{"name": "Test Room", "ability_method": "divine"}`,
+			expectedField:   "ability_method",
+			expectedCode:    validation.CodeInvalidChoice,
+			expectedMessage: "必须是 free, point_buy, standard_array, rolled 之一",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/rooms", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tt.language)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, 400, rec.Code)
+
+			var resp struct {
+				Data struct {
+					Errors []validation.FieldError `json:"errors"`
+				} `json:"data"`
+			}
+			require.NoError(t, testutil.ParseResponse(rec, &resp))
+			require.Len(t, resp.Data.Errors, 1)
+			assert.Equal(t, tt.expectedField, resp.Data.Errors[0].Field)
+			assert.Equal(t, tt.expectedCode, resp.Data.Errors[0].Code)
+			assert.Equal(t, tt.expectedMessage, resp.Data.Errors[0].Message)
+		})
+	}
+}
+
+func TestRoomHandler_UpdateRoom(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	handler := NewRoomHandler(db)
+	router := testutil.SetupTestRouter()
+	router.PUT("/rooms/:id", handler.UpdateRoom)
+	router.PATCH("/rooms/:id", handler.PatchRoom)
+
+	testRoom := room.Room{
+		Name:          "Curse of Strahd",
+		Description:   "Gothic horror",
+		RuleSystem:    "DND5e",
+		AbilityMethod: room.AbilityMethodPointBuy,
+		Status:        room.StatusActive,
+		Tags:          []string{"horror"},
+	}
+	require.NoError(t, db.Create(&testRoom).Error)
+	// 玩家数由成员表统计；房间没有 DM，匿名请求可以修改（单人本地模式）
+	require.NoError(t, db.Create(&[]room.Member{
+		{RoomID: testRoom.ID, UserID: 2, Role: room.RolePlayer},
+		{RoomID: testRoom.ID, UserID: 3, Role: room.RolePlayer},
+	}).Error)
+
+	send := func(method, body string) (*httptest.ResponseRecorder, room.Room) {
+		req := httptest.NewRequest(method, "/rooms/1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var resp struct {
+			Data room.Room `json:"data"`
+		}
+		require.NoError(t, testutil.ParseResponse(rec, &resp))
+		return rec, resp.Data
+	}
+
+	t.Run("PATCH 只修改提供的字段", func(t *testing.T) {
+		rec, updated := send("PATCH", `{"name": "  Curse of Strahd (fixed)  ", "tags": ["horror", " gothic ", "horror", ""], "status": "paused"}`)
+
+		require.Equal(t, 200, rec.Code)
+		assert.Equal(t, "Curse of Strahd (fixed)", updated.Name)
+		assert.Equal(t, "Gothic horror", updated.Description)
+		assert.Equal(t, room.AbilityMethodPointBuy, updated.AbilityMethod)
+		assert.Equal(t, room.StatusPaused, updated.Status)
+		assert.Equal(t, []string{"horror", "gothic"}, updated.Tags)
+		assert.Equal(t, 2, updated.PlayerCount)
+	})
+
+	t.Run("PUT 整体替换字段", func(t *testing.T) {
+		rec, updated := send("PUT", `{
+			"name": "Tomb of Annihilation",
+			"cover_image": "https://example.com/cover.png",
+			"schedule": "Every other Friday 19:00",
+			"player_count": 5,
+			"last_played_at": "2026-10-01T19:00:00Z"
+		}`)
+
+		require.Equal(t, 200, rec.Code)
+		assert.Equal(t, "Tomb of Annihilation", updated.Name)
+		assert.Empty(t, updated.Description)
+		assert.Equal(t, room.DefaultRuleSystem, updated.RuleSystem)
+		assert.Equal(t, room.AbilityMethodFree, updated.AbilityMethod)
+		// 未提供状态时保持原状态，玩家数不能由请求设置
+		assert.Equal(t, room.StatusPaused, updated.Status)
+		assert.Empty(t, updated.Tags)
+		assert.Equal(t, "Every other Friday 19:00", updated.Schedule)
+		assert.Equal(t, 2, updated.PlayerCount)
+		require.NotNil(t, updated.LastPlayedAt)
+		assert.Equal(t, 2026, updated.LastPlayedAt.Year())
+
+		var stored room.Room
+		require.NoError(t, db.First(&stored, 1).Error)
+		assert.Equal(t, "Tomb of Annihilation", stored.Name)
+		assert.Equal(t, "https://example.com/cover.png", stored.CoverImage)
+	})
+
+	t.Run("非法字段返回逐字段错误且不保存", func(t *testing.T) {
+		req := httptest.NewRequest("PATCH", "/rooms/1", strings.NewReader(`{"name": "", "status": "deleted", "max_players": 0}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, 400, rec.Code)
+		var resp struct {
+			Data struct {
+				Errors []validation.FieldError `json:"errors"`
+			} `json:"data"`
+		}
+		require.NoError(t, testutil.ParseResponse(rec, &resp))
+		fields := make([]string, 0, len(resp.Data.Errors))
+		for _, fe := range resp.Data.Errors {
+			fields = append(fields, fe.Field)
+		}
+		assert.Equal(t, []string{"name", "status", "max_players"}, fields)
+
+		var stored room.Room
+		require.NoError(t, db.First(&stored, 1).Error)
+		assert.Equal(t, "Tomb of Annihilation", stored.Name)
+	})
+
+	t.Run("归档只能通过归档接口", func(t *testing.T) {
+		rec, _ := send("PATCH", `{"status": "archived"}`)
+		assert.Equal(t, 400, rec.Code)
+
+		archivedAt := time.Now()
+		require.NoError(t, db.Model(&room.Room{}).Where("id = ?", 1).Updates(map[string]interface{}{"status": room.StatusArchived, "archived_at": archivedAt}).Error)
+		rec, _ = send("PATCH", `{"status": "active"}`)
+		assert.Equal(t, 409, rec.Code)
+
+		rec, updated := send("PUT", `{"name": "Still Archived"}`)
+		require.Equal(t, 200, rec.Code)
+		assert.Equal(t, room.StatusArchived, updated.Status)
+		assert.NotNil(t, updated.ArchivedAt)
+	})
+
+	t.Run("房间不存在", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", "/rooms/999", strings.NewReader(`{"name": "Nope"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, 404, rec.Code)
+	})
+}
+
+func TestRoomHandler_GetRooms_Query(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	handler := NewRoomHandler(db)
+	router := testutil.SetupTestRouter()
+	router.GET("/rooms", handler.GetRooms)
+
+	played := func(day int) *time.Time {
+		at := time.Date(2026, 9, day, 19, 0, 0, 0, time.UTC)
+		return &at
+	}
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rooms := []room.Room{
+		{Name: "Curse of Strahd", Description: "Gothic horror in Barovia", RuleSystem: "DND5e", Status: room.StatusActive, Tags: []string{"horror"}, LastPlayedAt: played(20)},
+		{Name: "ancient tomb", Description: "Dungeon crawl", RuleSystem: "DND5e", Status: room.StatusPaused, Tags: []string{"dungeon"}},
+		{Name: "Masks of Nyarlathotep", Description: "Cosmic horror, 100% sanity loss", RuleSystem: "COC7", Status: room.StatusActive, Tags: []string{"horror", "investigation"}, LastPlayedAt: played(25)},
+		{Name: "Waterdeep", Description: "Urban heist", RuleSystem: "DND5e", Status: room.StatusArchived, LastPlayedAt: played(1)},
+	}
+	for i := range rooms {
+		rooms[i].AbilityMethod = room.AbilityMethodFree
+		rooms[i].CreatedAt = base.AddDate(0, 0, i)
+		require.NoError(t, db.Create(&rooms[i]).Error)
+	}
+
+	list := func(query string) (int, PageResult[room.Room]) {
+		req := httptest.NewRequest("GET", "/rooms"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var resp struct {
+			Data PageResult[room.Room] `json:"data"`
+		}
+		require.NoError(t, testutil.ParseResponse(rec, &resp))
+		return rec.Code, resp.Data
+	}
+	names := func(result PageResult[room.Room]) []string {
+		var got []string
+		for _, r := range result.Items {
+			got = append(got, r.Name)
+		}
+		return got
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+		total    int64
+	}{
+		{"默认按创建时间倒序且隐藏已归档", "", []string{"Masks of Nyarlathotep", "ancient tomb", "Curse of Strahd"}, 3},
+		{"包含已归档", "?include_archived=true", []string{"Waterdeep", "Masks of Nyarlathotep", "ancient tomb", "Curse of Strahd"}, 4},
+		{"只看已归档", "?status=archived", []string{"Waterdeep"}, 1},
+		{"按名称排序忽略大小写", "?sort=name&include_archived=true", []string{"ancient tomb", "Curse of Strahd", "Masks of Nyarlathotep", "Waterdeep"}, 4},
+		{"按最近开团排序，未开团的在最后", "?sort=last_played_at&include_archived=true", []string{"Masks of Nyarlathotep", "Curse of Strahd", "Waterdeep", "ancient tomb"}, 4},
+		{"按规则系统过滤", "?rule_system=COC7", []string{"Masks of Nyarlathotep"}, 1},
+		{"按状态过滤", "?status=paused", []string{"ancient tomb"}, 1},
+		{"按标签过滤", "?tag=horror&sort=name", []string{"Curse of Strahd", "Masks of Nyarlathotep"}, 2},
+		{"搜索名称和简介", "?q=HORROR&sort=name", []string{"Curse of Strahd", "Masks of Nyarlathotep"}, 2},
+		{"搜索转义通配符", "?q=100%25", []string{"Masks of Nyarlathotep"}, 1},
+		{"组合过滤", "?rule_system=DND5e&tag=horror&q=barovia", []string{"Curse of Strahd"}, 1},
+		{"分页", "?sort=name&page=2&page_size=3&include_archived=true", []string{"Waterdeep"}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, result := list(tt.query)
+			require.Equal(t, 200, code)
+			assert.Equal(t, tt.expected, names(result))
+			assert.Equal(t, tt.total, result.Total)
+		})
+	}
+
+	t.Run("cursor 翻页", func(t *testing.T) {
+		code, first := list("?sort=name&page_size=3&include_archived=true")
+		require.Equal(t, 200, code)
+		assert.Equal(t, 1, first.Page)
+		require.NotEmpty(t, first.NextCursor)
+
+		code, second := list("?sort=name&page_size=3&include_archived=true&cursor=" + first.NextCursor)
+		require.Equal(t, 200, code)
+		assert.Equal(t, []string{"Waterdeep"}, names(second))
+		assert.Equal(t, 2, second.Page)
+		assert.Empty(t, second.NextCursor)
+	})
+
+	t.Run("超出范围的页返回空列表", func(t *testing.T) {
+		code, result := list("?page=10")
+		require.Equal(t, 200, code)
+		assert.NotNil(t, result.Items)
+		assert.Empty(t, result.Items)
+		assert.Equal(t, int64(3), result.Total)
+	})
+
+	t.Run("非法参数", func(t *testing.T) {
+		for _, query := range []string{"?page=0", "?page_size=500", "?sort=owner", "?order=up", "?status=deleted", "?cursor=bogus", "?include_archived=maybe"} {
+			code, _ := list(query)
+			assert.Equal(t, 400, code, query)
+		}
+	})
+}
+
+func TestRoomHandler_ArchiveRoom(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	roomHandler := NewRoomHandler(db)
+	characterHandler := NewCharacterHandler(db)
+	router := testutil.SetupTestRouter()
+	router.GET("/rooms", roomHandler.GetRooms)
+	router.GET("/rooms/:id", roomHandler.GetRoom)
+	router.POST("/rooms/:id/archive", roomHandler.ArchiveRoom)
+	router.POST("/rooms/:id/unarchive", roomHandler.UnarchiveRoom)
+	router.GET("/rooms/:id/export", roomHandler.ExportRoom)
+	router.POST("/characters/:roomId", characterHandler.CreateCharacter)
+	router.POST("/characters/:roomId/generate", characterHandler.GenerateCharacter)
+	router.GET("/characters/:roomId/:charId", characterHandler.GetCharacter)
+	router.PUT("/characters/:roomId/:charId", characterHandler.UpdateCharacter)
+	router.DELETE("/characters/:roomId/:charId", characterHandler.DeleteCharacter)
+	router.POST("/characters/:roomId/:charId/ability-roll", characterHandler.RollAbilities)
+	router.POST("/characters/:roomId/:charId/references", characterHandler.AddReference)
+	router.DELETE("/characters/:roomId/:charId/references/:type/:slug", characterHandler.RemoveReference)
+
+	roomID := uint(250)
+	require.NoError(t, db.Create(&room.Room{ID: roomID, Name: "Finished Campaign", RuleSystem: "DND5e", AbilityMethod: room.AbilityMethodFree, Status: room.StatusActive}).Error)
+	require.NoError(t, db.Create(&monster.StatBlock{RoomID: roomID, Name: "Young Dragon", ChallengeRating: "10"}).Error)
+	path := characterHandler.storage.GetRoomCharactersPath(roomID)
+	require.NoError(t, os.RemoveAll(path))
+	t.Cleanup(func() { os.RemoveAll(path) })
+	require.NoError(t, characterHandler.storage.SaveCharacter(&character.CharacterCard{
+		ID: 1, RoomID: roomID, Name: "Aria", Level: 5, MaxHP: 30, HP: 30,
+		Strength: 10, Dexterity: 14, Constitution: 12, Intelligence: 10, Wisdom: 13, Charisma: 15,
+	}))
+
+	send := func(method, path string, body interface{}) (*httptest.ResponseRecorder, response.Body) {
+		req, err := testutil.MakeJSONRequest(method, path, body)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var resp response.Body
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return rec, resp
+	}
+
+	rec, _ := send("POST", "/rooms/250/archive", nil)
+	require.Equal(t, 200, rec.Code)
+
+	var archived room.Room
+	require.NoError(t, db.First(&archived, roomID).Error)
+	assert.Equal(t, room.StatusArchived, archived.Status)
+	require.NotNil(t, archived.ArchivedAt)
+
+	t.Run("人物卡修改被拒绝", func(t *testing.T) {
+		mutations := []struct {
+			method string
+			path   string
+			body   interface{}
+		}{
+			{"POST", "/characters/250", map[string]interface{}{"name": "New Hero"}},
+			{"POST", "/characters/250/generate", map[string]interface{}{"seed": 1}},
+			{"PUT", "/characters/250/1", map[string]interface{}{"name": "Aria the Bold"}},
+			{"DELETE", "/characters/250/1", nil},
+			{"POST", "/characters/250/1/ability-roll", nil},
+			{"POST", "/characters/250/1/references", map[string]interface{}{"type": "spell", "slug": "fire-bolt"}},
+			{"DELETE", "/characters/250/1/references/spell/fire-bolt", nil},
+		}
+		for _, m := range mutations {
+			rec, resp := send(m.method, m.path, m.body)
+			assert.Equal(t, 409, rec.Code, m.method+" "+m.path)
+			assert.Equal(t, apperror.CodeRoomArchived, resp.ErrorCode, m.method+" "+m.path)
+			assert.Equal(t, "Room is archived and read-only", resp.Message)
+		}
+
+		card, err := characterHandler.storage.LoadCharacter(roomID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Aria", card.Name)
+	})
+
+	t.Run("已归档房间仍可查看和导出", func(t *testing.T) {
+		rec, _ := send("GET", "/rooms/250", nil)
+		assert.Equal(t, 200, rec.Code)
+		rec, _ = send("GET", "/characters/250/1", nil)
+		assert.Equal(t, 200, rec.Code)
+
+		req := httptest.NewRequest("GET", "/rooms/250/export", nil)
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, 200, rec.Code)
+		assert.Equal(t, `attachment; filename="finished-campaign.json"`, rec.Header().Get("Content-Disposition"))
+
+		var export RoomExport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &export))
+		assert.Equal(t, "Finished Campaign", export.Room.Name)
+		assert.Equal(t, room.StatusArchived, export.Room.Status)
+		require.Len(t, export.Characters, 1)
+		assert.Equal(t, "Aria", export.Characters[0].Name)
+		require.Len(t, export.StatBlocks, 1)
+		assert.Equal(t, "Young Dragon", export.StatBlocks[0].Name)
+		assert.NotNil(t, export.Encounters)
+		assert.NotNil(t, export.Homebrew)
+	})
+
+	t.Run("默认列表隐藏已归档房间", func(t *testing.T) {
+		rec, resp := send("GET", "/rooms", nil)
+		require.Equal(t, 200, rec.Code)
+		assert.NotContains(t, rec.Body.String(), "Finished Campaign")
+		assert.Equal(t, 200, resp.Code)
+	})
+
+	t.Run("取消归档后可以修改", func(t *testing.T) {
+		rec, _ := send("POST", "/rooms/250/unarchive", nil)
+		require.Equal(t, 200, rec.Code)
+
+		var restored room.Room
+		require.NoError(t, db.First(&restored, roomID).Error)
+		assert.Equal(t, room.StatusActive, restored.Status)
+		assert.Nil(t, restored.ArchivedAt)
+
+		rec, _ = send("PUT", "/characters/250/1", map[string]interface{}{
+			"name": "Aria the Bold", "level": 5, "max_hp": 30, "hp": 30,
+			"strength": 10, "dexterity": 14, "constitution": 12, "intelligence": 10, "wisdom": 13, "charisma": 15,
+		})
+		assert.Equal(t, 200, rec.Code)
+	})
+
+	t.Run("房间不存在", func(t *testing.T) {
+		rec, resp := send("POST", "/rooms/999/archive", nil)
+		assert.Equal(t, 404, rec.Code)
+		assert.Equal(t, apperror.CodeRoomNotFound, resp.ErrorCode)
+	})
+}
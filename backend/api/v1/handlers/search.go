@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/validation"
+	"trpg-sync/backend/infrastructure/search"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 全文搜索每组结果数量
+const (
+	DefaultSearchLimit = 10
+	MaxSearchLimit     = 50
+)
+
+type SearchHandler struct {
+	index *search.Index
+}
+
+func NewSearchHandler(db *gorm.DB) *SearchHandler {
+	return &SearchHandler{index: search.NewIndex(db)}
+}
+
+// SearchResult 全文搜索响应，groups 按实体类型分组，组内按相关度排序
+type SearchResult struct {
+	Query  string         `json:"query"`
+	Groups []search.Group `json:"groups"`
+}
+
+// Search 全文搜索当前用户可见的房间和人物卡，可见范围与房间列表和人物卡搜索一致
+// type 参数以逗号分隔限定实体类型，limit 为每组返回的最大条数
+func (h *SearchHandler) Search(c *gin.Context) {
+	var errs []validation.FieldError
+
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		errs = append(errs, validation.NewError("q", validation.CodeRequired, nil))
+	}
+
+	types := search.Types
+	if raw := c.Query("type"); raw != "" {
+		types = nil
+		for _, entityType := range strings.Split(raw, ",") {
+			entityType = strings.TrimSpace(entityType)
+			if !slices.Contains(search.Types, entityType) {
+				errs = append(errs, validation.NewError("type", validation.CodeInvalidChoice, map[string]interface{}{
+					"choices": strings.Join(search.Types, ", "),
+				}))
+				break
+			}
+			if !slices.Contains(types, entityType) {
+				types = append(types, entityType)
+			}
+		}
+	}
+
+	limit := DefaultSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > MaxSearchLimit {
+			errs = append(errs, validation.NewError("limit", validation.CodeOutOfRange, map[string]interface{}{"min": 1, "max": MaxSearchLimit}))
+		} else {
+			limit = value
+		}
+	}
+	if len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+
+	userID := currentUserID(c)
+	groups, err := h.index.Search(text, types, limit, search.Scope{
+		search.TypeRoom:      visibleRooms(userID),
+		search.TypeCharacter: visibleCharacters(userID),
+	})
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to search", err))
+		return
+	}
+
+	response.Success(c, SearchResult{Query: text, Groups: groups})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/infrastructure/search"
+	"trpg-sync/backend/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchHandler_Search(t *testing.T) {
+	db := testutil.SetupMigratedDB(t)
+
+	roomHandler := NewRoomHandler(db)
+	characterHandler := NewCharacterHandler(db)
+	searchHandler := NewSearchHandler(db)
+	router := testutil.SetupTestRouter()
+	router.POST("/rooms", roomHandler.CreateRoom)
+	router.PATCH("/rooms/:id", roomHandler.PatchRoom)
+	router.DELETE("/rooms/:id", roomHandler.DeleteRoom)
+	router.DELETE("/characters/:roomId/:charId", characterHandler.DeleteCharacter)
+	router.GET("/search", searchHandler.Search)
+
+	send := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	find := func(query string) (int, SearchResult) {
+		req := httptest.NewRequest("GET", "/search"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var resp struct {
+			Data SearchResult `json:"data"`
+		}
+		require.NoError(t, testutil.ParseResponse(rec, &resp))
+		return rec.Code, resp.Data
+	}
+	group := func(result SearchResult, entityType string) search.Group {
+		for _, g := range result.Groups {
+			if g.Type == entityType {
+				return g
+			}
+		}
+		t.Fatalf("missing group %s", entityType)
+		return search.Group{}
+	}
+
+	// 房间先直接写入数据库，再通过 PATCH 建立索引
+	for _, id := range []uint{240, 241} {
+		require.NoError(t, db.Create(&room.Room{ID: id, Name: "Untitled", AbilityMethod: room.AbilityMethodFree, Status: room.StatusActive}).Error)
+		path := characterHandler.storage.GetRoomCharactersPath(id)
+		require.NoError(t, os.RemoveAll(path))
+		t.Cleanup(func() { os.RemoveAll(path) })
+	}
+	require.Equal(t, 200, send("PATCH", "/rooms/240", `{"name": "Curse of Strahd", "description": "Gothic horror in the mists of Barovia"}`))
+	require.Equal(t, 200, send("PATCH", "/rooms/241", `{"name": "Dragon Heist", "description": "A <b>Waterdeep</b> caper"}`))
+
+	cards := []character.CharacterCard{
+		{ID: 1, RoomID: 240, Name: "Ireena", Background: "Noble of Barovia", Equipment: "Rapier"},
+		{ID: 2, RoomID: 240, Name: "Van Richten", Background: "Monster hunter", Equipment: "Silvered dagger", Spells: "Cure Wounds, Hold Person"},
+		{ID: 1, RoomID: 241, Name: "Renaer", Background: "Noble", Equipment: "Hand crossbow"},
+	}
+	for i := range cards {
+		require.NoError(t, characterHandler.storage.SaveCharacter(&cards[i]))
+	}
+
+	t.Run("按实体类型分组并高亮", func(t *testing.T) {
+		code, result := find("?q=barovia")
+		require.Equal(t, 200, code)
+		assert.Equal(t, "barovia", result.Query)
+
+		rooms := group(result, search.TypeRoom)
+		require.Equal(t, int64(1), rooms.Total)
+		assert.Equal(t, "240", rooms.Hits[0].ID)
+		assert.Equal(t, "Curse of Strahd", rooms.Hits[0].Title)
+		assert.Contains(t, rooms.Hits[0].Snippet, "<mark>Barovia</mark>")
+
+		characters := group(result, search.TypeCharacter)
+		require.Equal(t, int64(1), characters.Total)
+		assert.Equal(t, "240/1", characters.Hits[0].ID)
+		assert.Equal(t, "Curse of Strahd", characters.Hits[0].RoomName)
+		assert.Equal(t, "Noble of <mark>Barovia</mark> · Rapier", characters.Hits[0].Snippet)
+	})
+
+	t.Run("前缀匹配、装备和法术", func(t *testing.T) {
+		_, result := find("?q=silver&type=character")
+		require.Len(t, result.Groups, 1)
+		assert.Equal(t, "Van Richten", result.Groups[0].Hits[0].Title)
+
+		_, result = find("?q=hold%20person&type=character")
+		require.Len(t, result.Groups[0].Hits, 1)
+		assert.Equal(t, "240/2", result.Groups[0].Hits[0].ID)
+	})
+
+	t.Run("修改房间后重新索引", func(t *testing.T) {
+		require.Equal(t, 200, send("PATCH", "/rooms/241", `{"name": "Noble Intrigue"}`))
+
+		_, result := find("?q=noble")
+		assert.Equal(t, int64(2), group(result, search.TypeCharacter).Total)
+		rooms := group(result, search.TypeRoom)
+		require.Len(t, rooms.Hits, 1)
+		assert.Equal(t, "<mark>Noble</mark> Intrigue", rooms.Hits[0].Title)
+
+		_, result = find("?q=dragon")
+		assert.Equal(t, int64(0), group(result, search.TypeRoom).Total)
+	})
+
+	t.Run("名称命中排在简介命中前面", func(t *testing.T) {
+		require.Equal(t, 200, send("POST", "/rooms", `{"name": "Mists", "description": "Short"}`))
+
+		_, result := find("?q=mists&type=room")
+		hits := result.Groups[0].Hits
+		require.Len(t, hits, 2)
+		assert.Equal(t, "<mark>Mists</mark>", hits[0].Title)
+		assert.Equal(t, "Curse of Strahd", hits[1].Title)
+		assert.Less(t, hits[0].Rank, hits[1].Rank)
+	})
+
+	t.Run("转义 HTML 和查询语法", func(t *testing.T) {
+		_, result := find("?q=waterdeep")
+		rooms := group(result, search.TypeRoom)
+		require.Len(t, rooms.Hits, 1)
+		assert.Equal(t, "A &lt;b&gt;<mark>Waterdeep</mark>&lt;/b&gt; caper", rooms.Hits[0].Snippet)
+
+		code, result := find(`?q=%22AND%20OR%20NEAR(`)
+		assert.Equal(t, 200, code)
+		assert.Equal(t, int64(0), group(result, search.TypeRoom).Total)
+	})
+
+	t.Run("删除后不再命中", func(t *testing.T) {
+		require.Equal(t, 200, send("DELETE", "/characters/240/2", ""))
+		_, result := find("?q=silvered")
+		assert.Equal(t, int64(0), group(result, search.TypeCharacter).Total)
+
+		require.Equal(t, 200, send("DELETE", "/rooms/240", ""))
+		_, result = find("?q=barovia")
+		assert.Equal(t, int64(0), group(result, search.TypeRoom).Total)
+		assert.Equal(t, int64(0), group(result, search.TypeCharacter).Total)
+	})
+
+	t.Run("非法参数", func(t *testing.T) {
+		for _, query := range []string{"", "?q=%20", "?q=x&type=note", "?q=x&limit=0"} {
+			code, _ := find(query)
+			assert.Equal(t, 400, code, query)
+		}
+	})
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"time"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/policy"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/share"
+	"trpg-sync/backend/infrastructure/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ShareHandler struct {
+	db      *gorm.DB
+	storage *storage.CharacterStorage
+}
+
+func NewShareHandler(db *gorm.DB) *ShareHandler {
+	return &ShareHandler{
+		db:      db,
+		storage: storage.NewCharacterStorage().WithIndex(storage.NewCharacterIndex(db)),
+	}
+}
+
+// CreateShareRequest expires_in_hours 为 0 时使用默认的 7 天；hidden_fields 为对外隐藏的字段，如 background
+type CreateShareRequest struct {
+	ExpiresInHours int      `json:"expires_in_hours"`
+	HiddenFields   []string `json:"hidden_fields"`
+}
+
+// ShareResponse 新建的分享链接，令牌只在创建时返回
+type ShareResponse struct {
+	share.Link
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// SharedCharacter 公开分享页面返回的人物卡，Character 已去掉隐藏字段
+type SharedCharacter struct {
+	Character map[string]interface{} `json:"character"`
+	ExpiresAt time.Time              `json:"expires_at"`
+}
+
+// CreateShare 为人物卡创建分享链接，可以查看人物卡的用户（所有者和 DM）都可以创建
+func (h *ShareHandler) CreateShare(c *gin.Context) {
+	card, ok := h.loadCharacter(c)
+	if !ok {
+		return
+	}
+
+	var req CreateShareRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+	if req.ExpiresInHours == 0 {
+		req.ExpiresInHours = share.DefaultExpiresInHours
+	}
+	hidden := share.NormalizeHiddenFields(req.HiddenFields)
+	if errs := share.Validate(req.ExpiresInHours, hidden); len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+
+	link := share.Link{
+		RoomID:       card.RoomID,
+		CharacterID:  card.ID,
+		CreatedBy:    currentUserID(c),
+		HiddenFields: hidden,
+		ExpiresAt:    time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour).Truncate(time.Second),
+	}
+	token := link.IssueToken()
+	if err := h.db.Create(&link).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to create share link", err))
+		return
+	}
+
+	response.OK(c, "Share link created successfully", ShareResponse{Link: link, Token: token, URL: "/api/v1/share/" + token})
+}
+
+// GetShares 返回人物卡的全部分享链接，包括已过期和已撤销的
+func (h *ShareHandler) GetShares(c *gin.Context) {
+	card, ok := h.loadCharacter(c)
+	if !ok {
+		return
+	}
+
+	links := []share.Link{}
+	if err := h.db.Where("room_id = ? AND character_id = ?", card.RoomID, card.ID).Order("id DESC").Find(&links).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get share links", err))
+		return
+	}
+	response.Success(c, links)
+}
+
+// RevokeShare 撤销分享链接，已撤销的链接再次撤销不报错
+func (h *ShareHandler) RevokeShare(c *gin.Context) {
+	card, ok := h.loadCharacter(c)
+	if !ok {
+		return
+	}
+	shareID, err := strconv.ParseUint(c.Param("shareId"), 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid share link ID"))
+		return
+	}
+
+	var link share.Link
+	if err := h.db.Where("room_id = ? AND character_id = ?", card.RoomID, card.ID).First(&link, shareID).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeShareLinkNotFound, err))
+		return
+	}
+	if link.RevokedAt == nil {
+		now := time.Now()
+		link.RevokedAt = &now
+		if err := h.db.Model(&link).Update("revoked_at", now).Error; err != nil {
+			response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to revoke share link", err))
+			return
+		}
+	}
+	response.OK(c, "Share link revoked successfully", link)
+}
+
+// GetSharedCharacter 公开接口，凭分享令牌只读查看人物卡；format=html 时返回渲染好的人物卡页面
+// 令牌无效、已过期、已撤销或人物卡已删除时统一返回 404
+func (h *ShareHandler) GetSharedCharacter(c *gin.Context) {
+	var link share.Link
+	if err := h.db.Where("hash = ?", share.HashToken(c.Param("token"))).First(&link).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeShareLinkNotFound, err))
+		return
+	}
+	if !link.Active(time.Now()) {
+		response.Error(c, apperror.New(apperror.CodeShareLinkNotFound, ""))
+		return
+	}
+
+	card, err := h.storage.LoadCharacter(link.RoomID, link.CharacterID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			err = apperror.New(apperror.CodeShareLinkNotFound, "")
+		}
+		response.Error(c, storageError("Failed to load shared character", err))
+		return
+	}
+
+	if c.Query("format") == "html" {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := sheetTemplate.Execute(c.Writer, sheetView{Card: card, Link: &link}); err != nil {
+			c.Error(err)
+		}
+		return
+	}
+
+	fields, err := link.Mask(card)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to load shared character", err))
+		return
+	}
+	response.Success(c, SharedCharacter{Character: fields, ExpiresAt: link.ExpiresAt})
+}
+
+// loadCharacter 加载路径中的人物卡并确认当前用户可以查看，失败时直接返回错误响应
+func (h *ShareHandler) loadCharacter(c *gin.Context) (*character.CharacterCard, bool) {
+	roomID, err := strconv.ParseUint(c.Param("roomId"), 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid room ID"))
+		return nil, false
+	}
+	characterID, err := strconv.ParseUint(c.Param("charId"), 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid character ID"))
+		return nil, false
+	}
+
+	var targetRoom room.Room
+	if err := h.db.First(&targetRoom, roomID).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeRoomNotFound, err))
+		return nil, false
+	}
+	actor, ok := authorizeRoom(c, h.db, &targetRoom, policy.RoomView)
+	if !ok {
+		return nil, false
+	}
+
+	card, err := h.storage.LoadCharacter(uint(roomID), uint(characterID))
+	if err != nil {
+		response.Error(c, storageError("Failed to load character", err))
+		return nil, false
+	}
+	if !authorized(c, policy.Character(actor, policy.CharacterView, card)) {
+		return nil, false
+	}
+	return card, true
+}
+
+// deleteShareLinks 删除人物卡或整个房间（characterID 为 0）的分享链接
+// 人物卡和房间的 ID 删除后可能被复用，旧链接不能指向新的人物卡
+func deleteShareLinks(db *gorm.DB, roomID, characterID uint) error {
+	query := db.Where("room_id = ?", roomID)
+	if characterID != 0 {
+		query = query.Where("character_id = ?", characterID)
+	}
+	return query.Delete(&share.Link{}).Error
+}
+
+// sheetView 人物卡页面的模板数据
+type sheetView struct {
+	Card *character.CharacterCard
+	Link *share.Link
+}
+
+// Shown 字段是否对外可见
+func (v sheetView) Shown(field string) bool {
+	return !v.Link.Hides(field)
+}
+
+// Modifier 属性调整值，带正负号
+func (v sheetView) Modifier(score int) string {
+	modifier := character.AbilityModifier(score)
+	if modifier >= 0 {
+		return "+" + strconv.Itoa(modifier)
+	}
+	return strconv.Itoa(modifier)
+}
+
+var sheetTemplate = template.Must(template.New("sheet").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Card.Name}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 760px; margin: 2rem auto; padding: 0 1rem; color: #222; }
+h1 { margin-bottom: 0.25rem; }
+.subtitle { color: #666; margin-top: 0; }
+.abilities { display: grid; grid-template-columns: repeat(6, 1fr); gap: 0.5rem; text-align: center; }
+.ability { border: 1px solid #ccc; border-radius: 6px; padding: 0.5rem; }
+.ability b { display: block; font-size: 1.4rem; }
+dl { display: grid; grid-template-columns: max-content 1fr; gap: 0.25rem 1rem; }
+dt { font-weight: bold; }
+dd { margin: 0; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Card.Name}}</h1>
+<p class="subtitle">
+{{- if .Shown "race"}}{{.Card.Race}} {{end}}
+{{- if .Shown "class"}}{{.Card.Class}} {{end}}
+{{- if .Shown "level"}}Lv {{.Card.Level}}{{end}}</p>
+<div class="abilities">
+{{- if .Shown "strength"}}<div class="ability">STR<b>{{.Card.Strength}}</b>{{.Modifier .Card.Strength}}</div>{{end}}
+{{- if .Shown "dexterity"}}<div class="ability">DEX<b>{{.Card.Dexterity}}</b>{{.Modifier .Card.Dexterity}}</div>{{end}}
+{{- if .Shown "constitution"}}<div class="ability">CON<b>{{.Card.Constitution}}</b>{{.Modifier .Card.Constitution}}</div>{{end}}
+{{- if .Shown "intelligence"}}<div class="ability">INT<b>{{.Card.Intelligence}}</b>{{.Modifier .Card.Intelligence}}</div>{{end}}
+{{- if .Shown "wisdom"}}<div class="ability">WIS<b>{{.Card.Wisdom}}</b>{{.Modifier .Card.Wisdom}}</div>{{end}}
+{{- if .Shown "charisma"}}<div class="ability">CHA<b>{{.Card.Charisma}}</b>{{.Modifier .Card.Charisma}}</div>{{end}}
+</div>
+<dl>
+{{- if .Shown "ac"}}<dt>AC</dt><dd>{{.Card.AC}}</dd>{{end}}
+{{- if .Shown "hp"}}<dt>HP</dt><dd>{{.Card.HP}}{{if .Shown "max_hp"}} / {{.Card.MaxHP}}{{end}}</dd>{{end}}
+{{- if .Shown "speed"}}<dt>Speed</dt><dd>{{.Card.Speed}}</dd>{{end}}
+{{- if .Shown "proficiency"}}<dt>Proficiency</dt><dd>+{{.Card.Proficiency}}</dd>{{end}}
+{{- if .Shown "alignment"}}<dt>Alignment</dt><dd>{{.Card.Alignment}}</dd>{{end}}
+{{- if .Shown "background"}}<dt>Background</dt><dd>{{.Card.Background}}</dd>{{end}}
+{{- if .Shown "skills"}}<dt>Skills</dt><dd>{{.Card.Skills}}</dd>{{end}}
+{{- if .Shown "saves"}}<dt>Saves</dt><dd>{{.Card.Saves}}</dd>{{end}}
+{{- if .Shown "equipment"}}<dt>Equipment</dt><dd>{{.Card.Equipment}}</dd>{{end}}
+{{- if .Shown "spells"}}<dt>Spells</dt><dd>{{.Card.Spells}}</dd>{{end}}
+{{- if and (.Shown "conditions") .Card.Conditions}}<dt>Conditions</dt><dd>{{range $i, $c := .Card.Conditions}}{{if $i}}, {{end}}{{$c}}{{end}}</dd>{{end}}
+</dl>
+</body>
+</html>
+`))
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/share"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createShare 以 as 的身份为人物卡创建分享链接，返回令牌和链接 ID
+func (s *permissionServer) createShare(t *testing.T, as string, charID int, body interface{}) (string, uint) {
+	rec, resp := s.do(t, as, "POST", fmt.Sprintf("/characters/%d/%d/share", permissionRoomID, charID), body)
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	data := resp.Data.(map[string]interface{})
+	token := data["token"].(string)
+	assert.Equal(t, "/api/v1/share/"+token, data["url"])
+	return token, uint(data["id"].(float64))
+}
+
+func TestShareHandler_CreateAndView(t *testing.T) {
+	s := setupPermissionServer(t)
+	token, id := s.createShare(t, "owner", 1, map[string]interface{}{"hidden_fields": []string{"background", "Spells"}})
+
+	// 数据库只保存令牌摘要
+	var link share.Link
+	require.NoError(t, s.db.First(&link, id).Error)
+	assert.Equal(t, share.HashToken(token), link.Hash)
+	assert.True(t, strings.HasPrefix(token, link.Hint))
+
+	rec, resp := s.do(t, "anonymous", "GET", "/share/"+token, nil)
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	shared := resp.Data.(map[string]interface{})
+	card := shared["character"].(map[string]interface{})
+	assert.Equal(t, "Owner Card", card["name"])
+	assert.NotContains(t, card, "background")
+	assert.NotContains(t, card, "spells")
+	assert.NotContains(t, card, "owner_id")
+	assert.NotEmpty(t, shared["expires_at"])
+
+	req := httptest.NewRequest("GET", "/share/"+token+"?format=html", nil)
+	html := httptest.NewRecorder()
+	s.router.ServeHTTP(html, req)
+	require.Equal(t, 200, html.Code)
+	assert.Contains(t, html.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, html.Body.String(), "<h1>Owner Card</h1>")
+	assert.NotContains(t, html.Body.String(), "Background")
+	assert.Contains(t, html.Body.String(), "Equipment")
+}
+
+func TestShareHandler_Permissions(t *testing.T) {
+	s := setupPermissionServer(t)
+	url := fmt.Sprintf("/characters/%d/1/share", permissionRoomID)
+
+	// 所有者和 DM 可以分享，其他人不行
+	s.createShare(t, "dm", 1, nil)
+	for as, status := range map[string]int{"player": 403, "outsider": 403, "anonymous": 401} {
+		rec, _ := s.do(t, as, "POST", url, nil)
+		assert.Equal(t, status, rec.Code, as)
+	}
+
+	rec, resp := s.do(t, "owner", "POST", url, map[string]interface{}{"hidden_fields": []string{"name"}})
+	assert.Equal(t, 400, rec.Code)
+	assert.Equal(t, apperror.CodeValidationFailed, resp.ErrorCode)
+	rec, _ = s.do(t, "owner", "POST", url, map[string]interface{}{"expires_in_hours": 24 * 365})
+	assert.Equal(t, 400, rec.Code)
+
+	rec, _ = s.do(t, "player", "GET", fmt.Sprintf("/characters/%d/1/shares", permissionRoomID), nil)
+	assert.Equal(t, 403, rec.Code)
+}
+
+func TestShareHandler_RevokeAndInvalid(t *testing.T) {
+	s := setupPermissionServer(t)
+	token, id := s.createShare(t, "owner", 1, nil)
+	other, _ := s.createShare(t, "owner", 1, nil)
+
+	rec, resp := s.do(t, "owner", "GET", fmt.Sprintf("/characters/%d/1/shares", permissionRoomID), nil)
+	require.Equal(t, 200, rec.Code)
+	assert.Len(t, resp.Data, 2)
+
+	revokeURL := fmt.Sprintf("/characters/%d/1/shares/%d", permissionRoomID, id)
+	rec, _ = s.do(t, "player", "DELETE", revokeURL, nil)
+	assert.Equal(t, 403, rec.Code)
+	rec, _ = s.do(t, "owner", "DELETE", revokeURL, nil)
+	require.Equal(t, 200, rec.Code)
+	// 链接属于人物卡 1，不能通过其它人物卡的路径撤销
+	rec, resp = s.do(t, "dm", "DELETE", fmt.Sprintf("/characters/%d/2/shares/%d", permissionRoomID, id), nil)
+	assert.Equal(t, 404, rec.Code)
+	assert.Equal(t, apperror.CodeShareLinkNotFound, resp.ErrorCode)
+
+	for name, bad := range map[string]string{
+		"revoked":  token,
+		"tampered": other + "x",
+		"garbage":  "not-a-token",
+		"access":   s.tokens["owner"],
+	} {
+		rec, resp := s.do(t, "anonymous", "GET", "/share/"+bad, nil)
+		assert.Equal(t, 404, rec.Code, name)
+		assert.Equal(t, apperror.CodeShareLinkNotFound, resp.ErrorCode, name)
+	}
+
+	rec, _ = s.do(t, "anonymous", "GET", "/share/"+other, nil)
+	assert.Equal(t, 200, rec.Code)
+
+	// 删除人物卡后链接失效
+	rec, _ = s.do(t, "owner", "DELETE", fmt.Sprintf("/characters/%d/1", permissionRoomID), nil)
+	require.Equal(t, 200, rec.Code)
+	rec, _ = s.do(t, "anonymous", "GET", "/share/"+other, nil)
+	assert.Equal(t, 404, rec.Code)
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/dice"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/monster"
+	"trpg-sync/backend/domain/policy"
+	"trpg-sync/backend/domain/validation"
+	compendiumstore "trpg-sync/backend/infrastructure/compendium"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type StatBlockHandler struct {
+	db      *gorm.DB
+	catalog *compendiumstore.Catalog
+	events  *event.Bus
+}
+
+func NewStatBlockHandler(db *gorm.DB) *StatBlockHandler {
+	return &StatBlockHandler{
+		db:      db,
+		catalog: compendiumstore.NewCatalog(compendiumstore.Default(), db),
+	}
+}
+
+// WithEvents 设置领域事件总线，数据卡变更后发布事件
+func (h *StatBlockHandler) WithEvents(bus *event.Bus) *StatBlockHandler {
+	h.events = bus
+	return h
+}
+
+// GetStatBlocks 获取房间内的怪物和 NPC 数据卡，支持 is_npc 参数过滤
+func (h *StatBlockHandler) GetStatBlocks(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomView); !ok {
+		return
+	}
+
+	query := h.db.Where("room_id = ?", targetRoom.ID)
+	if isNPC := c.Query("is_npc"); isNPC != "" {
+		query = query.Where("is_npc = ?", isNPC == "true")
+	}
+
+	var blocks []monster.StatBlock
+	if err := query.Order("name").Find(&blocks).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get stat blocks", err))
+		return
+	}
+
+	response.Success(c, blocks)
+}
+
+func (h *StatBlockHandler) GetStatBlock(c *gin.Context) {
+	block, ok := h.loadStatBlock(c, policy.RoomView)
+	if !ok {
+		return
+	}
+
+	response.Success(c, block)
+}
+
+func (h *StatBlockHandler) CreateStatBlock(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomManageEncounters); !ok {
+		return
+	}
+
+	var block monster.StatBlock
+	if !bindJSON(c, &block) {
+		return
+	}
+
+	block.ID = 0
+	block.RoomID = targetRoom.ID
+	if !normalizeStatBlock(c, &block) {
+		return
+	}
+
+	if err := h.db.Create(&block).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to create stat block", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.StatBlockCreated{Block: block})
+	response.OK(c, "Stat block created successfully", block)
+}
+
+func (h *StatBlockHandler) UpdateStatBlock(c *gin.Context) {
+	existing, ok := h.loadStatBlock(c, policy.RoomManageEncounters)
+	if !ok {
+		return
+	}
+
+	var block monster.StatBlock
+	if !bindJSON(c, &block) {
+		return
+	}
+
+	block.ID = existing.ID
+	block.RoomID = existing.RoomID
+	block.CreatedAt = existing.CreatedAt
+	if !normalizeStatBlock(c, &block) {
+		return
+	}
+
+	if err := h.db.Save(&block).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to update stat block", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.StatBlockUpdated{Before: *existing, After: block})
+	response.OK(c, "Stat block updated successfully", block)
+}
+
+func (h *StatBlockHandler) DeleteStatBlock(c *gin.Context) {
+	block, ok := h.loadStatBlock(c, policy.RoomManageEncounters)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(block).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to delete stat block", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.StatBlockDeleted{Block: *block})
+	response.OK(c, "Stat block deleted successfully", nil)
+}
+
+type CloneStatBlockRequest struct {
+	Slug  string `json:"slug" binding:"required"`
+	Name  string `json:"name"`
+	IsNPC bool   `json:"is_npc"`
+}
+
+// CloneStatBlock 从资料库（含房间自制内容）复制怪物数据卡到房间
+func (h *StatBlockHandler) CloneStatBlock(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomManageEncounters); !ok {
+		return
+	}
+
+	var req CloneStatBlockRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	entry, found, err := h.catalog.Get(compendium.TypeMonster, req.Slug, targetRoom.ID)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get compendium entry", err))
+		return
+	}
+	if !found {
+		response.Error(c, apperror.New(apperror.CodeCompendiumEntryNotFound, ""))
+		return
+	}
+
+	var block monster.StatBlock
+	if err := entry.Decode(&block); err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Invalid compendium monster data", err))
+		return
+	}
+
+	block.RoomID = targetRoom.ID
+	block.Name = entry.Name
+	if req.Name != "" {
+		block.Name = req.Name
+	}
+	block.IsNPC = req.IsNPC
+	block.CompendiumSlug = entry.Slug
+	if !normalizeStatBlock(c, &block) {
+		return
+	}
+
+	if err := h.db.Create(&block).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to create stat block", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.StatBlockCreated{Block: block})
+	response.OK(c, "Stat block cloned successfully", block)
+}
+
+// loadStatBlock 加载路径中的数据卡并检查当前用户对房间的操作权限，失败时直接返回错误响应
+func (h *StatBlockHandler) loadStatBlock(c *gin.Context, action policy.Action) (*monster.StatBlock, bool) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, action); !ok {
+		return nil, false
+	}
+
+	blockID, err := strconv.ParseUint(c.Param("blockId"), 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid stat block ID"))
+		return nil, false
+	}
+
+	var block monster.StatBlock
+	if err := h.db.Where("room_id = ?", targetRoom.ID).First(&block, blockID).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeStatBlockNotFound, err))
+		return nil, false
+	}
+	return &block, true
+}
+
+// normalizeStatBlock 校验名称、挑战等级和生命值公式，并补全经验值和平均生命值
+func normalizeStatBlock(c *gin.Context, block *monster.StatBlock) bool {
+	var errs []validation.FieldError
+	block.Name = strings.TrimSpace(block.Name)
+	if block.Name == "" {
+		errs = append(errs, validation.NewError("name", validation.CodeRequired, nil))
+	}
+
+	if block.ChallengeRating == "" {
+		block.ChallengeRating = "0"
+	}
+	if xp, ok := monster.ChallengeRatingXP(block.ChallengeRating); !ok {
+		errs = append(errs, validation.NewError("challenge_rating", validation.CodeOutOfRange, map[string]interface{}{"min": 0, "max": 30}))
+	} else if block.XP == 0 {
+		block.XP = xp
+	}
+
+	if block.HitDice != "" {
+		if formula, err := dice.ParseFormula(block.HitDice); err != nil {
+			errs = append(errs, validation.NewError("hit_dice", validation.CodeInvalidType, map[string]interface{}{"type": "dice formula"}))
+		} else {
+			block.HitDice = formula.String()
+			if block.HitPoints == 0 {
+				block.HitPoints = formula.Average()
+			}
+		}
+	}
+	if block.HitPoints < 1 {
+		block.HitPoints = 1
+	}
+
+	if len(errs) > 0 {
+		respondValidation(c, errs)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/campaign"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/policy"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TemplateHandler struct {
+	db     *gorm.DB
+	copier *roomCopier
+	events *event.Bus
+}
+
+func NewTemplateHandler(db *gorm.DB) *TemplateHandler {
+	return &TemplateHandler{db: db, copier: newRoomCopier(db)}
+}
+
+// WithEvents 设置领域事件总线，保存、删除模板和由模板创建房间后发布事件
+func (h *TemplateHandler) WithEvents(bus *event.Bus) *TemplateHandler {
+	h.events = bus
+	return h
+}
+
+// TemplateView 模板及其内容数量，模板库列表和详情都返回此结构
+type TemplateView struct {
+	campaign.Template
+	Summary campaign.Summary `json:"summary"`
+}
+
+type CreateTemplateRequest struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Include     campaign.Options `json:"include"`
+}
+
+// CreateTemplate 将房间保存为战役模板，名称和简介默认沿用房间；模板包含全部人物卡，与导出房间的权限相同
+func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
+	source, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, source, policy.RoomExport); !ok {
+		return
+	}
+
+	var req CreateTemplateRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	tmpl := campaign.Template{
+		OwnerID:      currentUserID(c),
+		Name:         cmp.Or(strings.TrimSpace(req.Name), source.Name),
+		Description:  cmp.Or(req.Description, source.Description),
+		RuleSystem:   source.RuleSystem,
+		Tags:         append([]string{}, source.Tags...),
+		SourceRoomID: source.ID,
+		Options:      req.Include.Normalize(),
+	}
+	if errs := append(tmpl.Validate(), req.Include.Validate()...); len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+
+	snap, err := h.copier.snapshot(source, tmpl.Options)
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to read room content", err))
+		return
+	}
+	tmpl.Snapshot = snap
+
+	if err := h.db.Create(&tmpl).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to create template", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.TemplateCreated{Template: tmpl})
+	response.OK(c, "Template created successfully", TemplateView{Template: tmpl, Summary: tmpl.Summary()})
+}
+
+// GetTemplates 分页获取当前用户可见的模板库，支持按 rule_system 过滤，q 对名称和简介做子串搜索
+func (h *TemplateHandler) GetTemplates(c *gin.Context) {
+	page, errs := parsePagination(c)
+	if len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+
+	visible := visibleTemplates(currentUserID(c))
+	filters := func(db *gorm.DB) *gorm.DB {
+		db = visible(db)
+		if ruleSystem := c.Query("rule_system"); ruleSystem != "" {
+			db = db.Where("rule_system = ?", ruleSystem)
+		}
+		if text := strings.TrimSpace(c.Query("q")); text != "" {
+			pattern := likePattern(text)
+			db = db.Where(`(name LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\')`, pattern, pattern)
+		}
+		return db
+	}
+
+	var total int64
+	if err := h.db.Model(&campaign.Template{}).Scopes(filters).Count(&total).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get templates", err))
+		return
+	}
+
+	var templates []campaign.Template
+	if err := h.db.Scopes(filters).
+		Order("name COLLATE NOCASE, id").
+		Limit(page.PageSize).
+		Offset(page.Offset).
+		Find(&templates).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get templates", err))
+		return
+	}
+
+	views := make([]TemplateView, len(templates))
+	for i := range templates {
+		views[i] = TemplateView{Template: templates[i], Summary: templates[i].Summary()}
+	}
+
+	response.Success(c, newPageResult(views, total, page))
+}
+
+func (h *TemplateHandler) GetTemplate(c *gin.Context) {
+	tmpl, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	response.Success(c, TemplateView{Template: *tmpl, Summary: tmpl.Summary()})
+}
+
+func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
+	tmpl, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(tmpl).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to delete template", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.TemplateDeleted{Template: *tmpl})
+	response.OK(c, "Template deleted successfully", nil)
+}
+
+type CreateRoomFromTemplateRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateRoomFromTemplate 由模板创建新房间，未提供名称时使用模板名称，已登录时操作者成为 DM
+func (h *TemplateHandler) CreateRoomFromTemplate(c *gin.Context) {
+	tmpl, ok := h.loadTemplate(c)
+	if !ok {
+		return
+	}
+
+	var req CreateRoomFromTemplateRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	name := cmp.Or(strings.TrimSpace(req.Name), tmpl.Name)
+	if !validateRoomName(c, &tmpl.Snapshot.Room, name) {
+		return
+	}
+
+	newRoom, err := h.copier.restore(tmpl.Snapshot, name, currentUserID(c))
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to create room from template", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.RoomCreated{Room: *newRoom})
+	response.OK(c, "Room created successfully", newRoom)
+}
+
+// loadTemplate 加载路径中的模板，其他用户的模板按不存在处理
+func (h *TemplateHandler) loadTemplate(c *gin.Context) (*campaign.Template, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid template ID"))
+		return nil, false
+	}
+
+	var tmpl campaign.Template
+	if err := h.db.Scopes(visibleTemplates(currentUserID(c))).First(&tmpl, id).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeTemplateNotFound, err))
+		return nil, false
+	}
+	return &tmpl, true
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"trpg-sync/backend/domain/campaign"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/homebrew"
+	"trpg-sync/backend/domain/monster"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/infrastructure/storage"
+	"trpg-sync/backend/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// setupCampaignRoom 创建带人物卡、数据卡、遭遇和自制内容的房间 260，新房间从 261 开始编号
+func setupCampaignRoom(t *testing.T) (*gorm.DB, *storage.CharacterStorage) {
+	db := testutil.SetupMigratedDB(t)
+
+	store := storage.NewCharacterStorage().WithIndex(storage.NewCharacterIndex(db))
+	for id := uint(260); id <= 265; id++ {
+		path := store.GetRoomCharactersPath(id)
+		require.NoError(t, os.RemoveAll(path))
+		t.Cleanup(func() { os.RemoveAll(path) })
+	}
+
+	require.NoError(t, db.Create(&room.Room{
+		ID: 260, Name: "Sunless Citadel", Description: "A classic one-shot", RuleSystem: "DND5e",
+		AbilityMethod: room.AbilityMethodPointBuy, Status: room.StatusArchived, Tags: []string{"one-shot"},
+	}).Error)
+	for _, card := range []character.CharacterCard{
+		{ID: 1, RoomID: 260, Name: "Pregen Fighter", Class: "Fighter", Level: 1},
+		{ID: 2, RoomID: 260, Name: "Pregen Wizard", Class: "Wizard", Level: 1},
+	} {
+		require.NoError(t, store.SaveCharacter(&card))
+	}
+
+	// 先占用一个数据卡 ID，确保复制后的数据卡 ID 与原 ID 不同
+	require.NoError(t, db.Create(&monster.StatBlock{RoomID: 999, Name: "Elsewhere"}).Error)
+	goblin := monster.StatBlock{RoomID: 260, Name: "Goblin", HitPoints: 7, ChallengeRating: "1/4"}
+	require.NoError(t, db.Create(&goblin).Error)
+	require.NoError(t, db.Create(&encounter.Encounter{
+		RoomID: 260, Name: "Goblin Ambush", Status: encounter.StatusPlanned,
+		Combatants: []encounter.Combatant{
+			{StatBlockID: goblin.ID, Name: "Goblin 1", Number: 1, HP: 7, MaxHP: 7},
+			{StatBlockID: goblin.ID, Name: "Goblin 2", Number: 2, HP: 3, MaxHP: 7},
+		},
+	}).Error)
+	require.NoError(t, db.Create(&homebrew.Entry{RoomID: 260, Type: "magic_item", Slug: "twig-blight-seed", Name: "Twig Blight Seed"}).Error)
+	return db, store
+}
+
+// assertCampaignCopy 检查新房间复制了设置和全部内容
+func assertCampaignCopy(t *testing.T, db *gorm.DB, store *storage.CharacterStorage, created room.Room, name string) {
+	assert.Equal(t, name, created.Name)
+	assert.Equal(t, "A classic one-shot", created.Description)
+	assert.Equal(t, room.AbilityMethodPointBuy, created.AbilityMethod)
+	assert.Equal(t, room.StatusActive, created.Status)
+	assert.Equal(t, []string{"one-shot"}, created.Tags)
+	assert.Zero(t, created.PlayerCount)
+	assert.Nil(t, created.ArchivedAt)
+
+	cards, err := store.GetRoomCharacters(created.ID)
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	for _, card := range cards {
+		assert.Equal(t, created.ID, card.RoomID)
+	}
+	var indexed int64
+	db.Model(&character.IndexEntry{}).Where("room_id = ?", created.ID).Count(&indexed)
+	assert.Equal(t, int64(2), indexed)
+
+	var blocks []monster.StatBlock
+	require.NoError(t, db.Where("room_id = ?", created.ID).Find(&blocks).Error)
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "Goblin", blocks[0].Name)
+
+	var encounters []encounter.Encounter
+	require.NoError(t, db.Preload("Combatants").Where("room_id = ?", created.ID).Find(&encounters).Error)
+	require.Len(t, encounters, 1)
+	require.Len(t, encounters[0].Combatants, 2)
+	for _, combatant := range encounters[0].Combatants {
+		assert.Equal(t, blocks[0].ID, combatant.StatBlockID)
+	}
+	assert.Equal(t, 3, encounters[0].Combatants[1].HP)
+
+	var entries []homebrew.Entry
+	require.NoError(t, db.Where("room_id = ?", created.ID).Find(&entries).Error)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "twig-blight-seed", entries[0].Slug)
+}
+
+func TestRoomHandler_CloneRoom(t *testing.T) {
+	db, store := setupCampaignRoom(t)
+
+	handler := NewRoomHandler(db)
+	router := testutil.SetupTestRouter()
+	router.POST("/rooms/:id/clone", handler.CloneRoom)
+
+	clone := func(body interface{}) (int, room.Room) {
+		req := httptest.NewRequest("POST", "/rooms/260/clone", nil)
+		if body != nil {
+			var err error
+			req, err = testutil.MakeJSONRequest("POST", "/rooms/260/clone", body)
+			require.NoError(t, err)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var resp struct {
+			Data room.Room `json:"data"`
+		}
+		require.NoError(t, testutil.ParseResponse(rec, &resp))
+		return rec.Code, resp.Data
+	}
+
+	t.Run("复制全部内容", func(t *testing.T) {
+		code, created := clone(map[string]interface{}{
+			"name":    "Sunless Citadel - Group B",
+			"include": map[string]bool{"characters": true, "encounters": true, "homebrew": true},
+		})
+		require.Equal(t, 200, code)
+		assert.Equal(t, uint(261), created.ID)
+		assertCampaignCopy(t, db, store, created, "Sunless Citadel - Group B")
+
+		// 源房间保持不变
+		cards, err := store.GetRoomCharacters(260)
+		require.NoError(t, err)
+		assert.Len(t, cards, 2)
+		var blocks int64
+		db.Model(&monster.StatBlock{}).Where("room_id = ?", 260).Count(&blocks)
+		assert.Equal(t, int64(1), blocks)
+	})
+
+	t.Run("默认只复制房间设置", func(t *testing.T) {
+		code, created := clone(nil)
+		require.Equal(t, 200, code)
+		assert.Equal(t, "Sunless Citadel (copy)", created.Name)
+		assert.Equal(t, room.AbilityMethodPointBuy, created.AbilityMethod)
+
+		cards, err := store.GetRoomCharacters(created.ID)
+		require.NoError(t, err)
+		assert.Empty(t, cards)
+		var count int64
+		db.Model(&encounter.Encounter{}).Where("room_id = ?", created.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("名称过长", func(t *testing.T) {
+		long := make([]byte, room.MaxNameLength+1)
+		for i := range long {
+			long[i] = 'a'
+		}
+		code, _ := clone(map[string]interface{}{"name": string(long)})
+		assert.Equal(t, 400, code)
+	})
+
+	t.Run("会话笔记暂不支持", func(t *testing.T) {
+		var count int64
+		db.Model(&room.Room{}).Count(&count)
+		code, _ := clone(map[string]interface{}{"include": map[string]bool{"characters": true, "notes": true}})
+		assert.Equal(t, 400, code)
+		var after int64
+		db.Model(&room.Room{}).Count(&after)
+		assert.Equal(t, count, after)
+	})
+}
+
+func TestTemplateHandler(t *testing.T) {
+	db, store := setupCampaignRoom(t)
+
+	handler := NewTemplateHandler(db)
+	router := testutil.SetupTestRouter()
+	router.POST("/rooms/:id/templates", handler.CreateTemplate)
+	router.GET("/templates", handler.GetTemplates)
+	router.GET("/templates/:id", handler.GetTemplate)
+	router.DELETE("/templates/:id", handler.DeleteTemplate)
+	router.POST("/templates/:id/rooms", handler.CreateRoomFromTemplate)
+
+	send := func(method, path string, body interface{}, out interface{}) int {
+		req, err := testutil.MakeJSONRequest(method, path, body)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if out != nil {
+			require.NoError(t, testutil.ParseResponse(rec, out))
+		}
+		return rec.Code
+	}
+
+	var created struct {
+		Data TemplateView `json:"data"`
+	}
+	require.Equal(t, 200, send("POST", "/rooms/260/templates", map[string]interface{}{
+		"include": map[string]bool{"characters": true, "encounters": true, "homebrew": true},
+	}, &created))
+	assert.Equal(t, "Sunless Citadel", created.Data.Name)
+	assert.Equal(t, uint(260), created.Data.SourceRoomID)
+	assert.True(t, created.Data.Options.StatBlocks, "复制遭遇时自动包含数据卡")
+	assert.Equal(t, campaign.Summary{Characters: 2, StatBlocks: 1, Encounters: 1, Homebrew: 1}, created.Data.Summary)
+
+	require.Equal(t, 200, send("POST", "/rooms/260/templates", map[string]interface{}{"name": "Bare Settings"}, nil))
+	assert.Equal(t, 400, send("POST", "/rooms/260/templates", map[string]interface{}{"include": map[string]bool{"notes": true}}, nil))
+
+	t.Run("模板库", func(t *testing.T) {
+		var list struct {
+			Data PageResult[TemplateView] `json:"data"`
+		}
+		require.Equal(t, 200, send("GET", "/templates", nil, &list))
+		require.Equal(t, int64(2), list.Data.Total)
+		assert.Equal(t, "Bare Settings", list.Data.Items[0].Name)
+		assert.Equal(t, campaign.Summary{}, list.Data.Items[0].Summary)
+
+		require.Equal(t, 200, send("GET", "/templates?q=citadel", nil, &list))
+		require.Len(t, list.Data.Items, 1)
+		assert.Equal(t, created.Data.ID, list.Data.Items[0].ID)
+	})
+
+	t.Run("由模板创建房间", func(t *testing.T) {
+		// 模板保存后源房间的修改不影响模板
+		require.NoError(t, db.Model(&room.Room{}).Where("id = ?", 260).Update("description", "Changed later").Error)
+
+		var resp struct {
+			Data room.Room `json:"data"`
+		}
+		require.Equal(t, 200, send("POST", "/templates/1/rooms", map[string]interface{}{"name": "Sunless Citadel - Group C"}, &resp))
+		assertCampaignCopy(t, db, store, resp.Data, "Sunless Citadel - Group C")
+
+		require.Equal(t, 200, send("POST", "/templates/1/rooms", nil, &resp))
+		assert.Equal(t, "Sunless Citadel", resp.Data.Name)
+	})
+
+	t.Run("删除模板", func(t *testing.T) {
+		require.Equal(t, 200, send("DELETE", "/templates/2", nil, nil))
+		assert.Equal(t, 404, send("GET", "/templates/2", nil, nil))
+		assert.Equal(t, 404, send("POST", "/templates/2/rooms", nil, nil))
+		assert.Equal(t, 400, send("GET", "/templates/abc", nil, nil))
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/user"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type APITokenHandler struct {
+	db *gorm.DB
+}
+
+func NewAPITokenHandler(db *gorm.DB) *APITokenHandler {
+	return &APITokenHandler{db: db}
+}
+
+// CreateAPITokenRequest scopes 如 rooms:read、rolls:write；expires_in_days 为 0 时使用默认的 30 天
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// APITokenResponse 新建的 API 令牌，明文令牌只在创建时返回
+type APITokenResponse struct {
+	user.APIToken
+	Token string `json:"token"`
+}
+
+// CreateAPIToken 为当前用户创建 API 令牌，令牌拥有与用户相同的房间权限，再按权限范围限制可访问的接口
+func (h *APITokenHandler) CreateAPIToken(c *gin.Context) {
+	current, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	var req CreateAPITokenRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.ExpiresInDays == 0 {
+		req.ExpiresInDays = user.DefaultTokenExpiresIn
+	}
+	scopes := user.NormalizeScopes(req.Scopes)
+	if errs := user.ValidateAPIToken(req.Name, scopes, req.ExpiresInDays); len(errs) > 0 {
+		respondValidation(c, errs)
+		return
+	}
+
+	expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays).Truncate(time.Second)
+	token, plain := user.NewAPIToken(current.ID, req.Name, scopes, expiresAt)
+	if err := h.db.Create(&token).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to create API token", err))
+		return
+	}
+
+	response.OK(c, "API token created successfully", APITokenResponse{APIToken: token, Token: plain})
+}
+
+// GetAPITokens 返回当前用户的全部 API 令牌，包括已过期和已撤销的
+func (h *APITokenHandler) GetAPITokens(c *gin.Context) {
+	current, ok := requireUser(c)
+	if !ok {
+		return
+	}
+
+	tokens := []user.APIToken{}
+	if err := h.db.Where("user_id = ?", current.ID).Order("id DESC").Find(&tokens).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to get API tokens", err))
+		return
+	}
+	response.Success(c, tokens)
+}
+
+// RevokeAPIToken 撤销当前用户的 API 令牌，已撤销的令牌再次撤销不报错
+func (h *APITokenHandler) RevokeAPIToken(c *gin.Context) {
+	current, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		response.Error(c, apperror.New(apperror.CodeInvalidID, "Invalid API token ID"))
+		return
+	}
+
+	var token user.APIToken
+	if err := h.db.Where("user_id = ?", current.ID).First(&token, tokenID).Error; err != nil {
+		response.Error(c, recordError(apperror.CodeAPITokenNotFound, err))
+		return
+	}
+	if token.RevokedAt == nil {
+		now := time.Now()
+		token.RevokedAt = &now
+		if err := h.db.Model(&token).Update("revoked_at", now).Error; err != nil {
+			response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to revoke API token", err))
+			return
+		}
+	}
+	response.OK(c, "API token revoked successfully", token)
+}
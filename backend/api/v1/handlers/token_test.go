@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createAPIToken 以 as 的身份创建 API 令牌，并以 name 登记到测试服务器的令牌表中
+func (s *permissionServer) createAPIToken(t *testing.T, as, name string, scopes ...string) uint {
+	rec, resp := s.do(t, as, "POST", "/user/tokens", map[string]interface{}{"name": name, "scopes": scopes})
+	require.Equal(t, 200, rec.Code, rec.Body.String())
+	data := resp.Data.(map[string]interface{})
+	token := data["token"].(string)
+	assert.True(t, strings.HasPrefix(token, user.APITokenPrefix))
+	assert.True(t, strings.HasPrefix(token, data["hint"].(string)))
+	assert.NotContains(t, data, "hash")
+	s.tokens[name] = token
+	return uint(data["id"].(float64))
+}
+
+func TestAPITokenHandler_Scopes(t *testing.T) {
+	s := setupPermissionServer(t)
+	s.createAPIToken(t, "owner", "reader", user.ScopeRoomsRead, user.ScopeCharactersRead)
+	room := fmt.Sprintf("/rooms/%d", permissionRoomID)
+	card := fmt.Sprintf("/characters/%d/1", permissionRoomID)
+
+	rec, _ := s.do(t, "reader", "GET", room, nil)
+	assert.Equal(t, 200, rec.Code, rec.Body.String())
+	rec, _ = s.do(t, "reader", "GET", card, nil)
+	assert.Equal(t, 200, rec.Code, rec.Body.String())
+
+	// 令牌的权限范围不足
+	rec, resp := s.do(t, "reader", "PUT", card, map[string]interface{}{"name": "Edited", "level": 2})
+	assert.Equal(t, 403, rec.Code)
+	assert.Equal(t, apperror.CodeInsufficientScope, resp.ErrorCode)
+	assert.Contains(t, resp.Message, user.ScopeCharactersWrite)
+
+	// 解散房间需要单独的管理权限，rooms:write 不够
+	s.createAPIToken(t, "dm", "writer", user.ScopeRoomsWrite)
+	rec, resp = s.do(t, "writer", "DELETE", room, nil)
+	assert.Equal(t, 403, rec.Code)
+	assert.Equal(t, apperror.CodeInsufficientScope, resp.ErrorCode)
+	assert.Contains(t, resp.Message, user.ScopeRoomsAdmin)
+
+	// 令牌不能管理令牌
+	rec, resp = s.do(t, "reader", "GET", "/user/tokens", nil)
+	assert.Equal(t, 403, rec.Code)
+	assert.Equal(t, apperror.CodeInsufficientScope, resp.ErrorCode)
+
+	// 权限范围之外仍按令牌所属用户的房间身份鉴权
+	rec, resp = s.do(t, "reader", "GET", fmt.Sprintf("/characters/%d/2", permissionRoomID), nil)
+	assert.Equal(t, 403, rec.Code)
+	assert.Equal(t, apperror.CodeForbidden, resp.ErrorCode)
+
+	rec, resp = s.do(t, "owner", "GET", "/user/tokens", nil)
+	require.Equal(t, 200, rec.Code)
+	tokens := resp.Data.([]interface{})
+	require.Len(t, tokens, 1)
+	listed := tokens[0].(map[string]interface{})
+	assert.Equal(t, "reader", listed["name"])
+	assert.NotEmpty(t, listed["last_used_at"])
+	assert.NotContains(t, listed, "token")
+	assert.NotContains(t, listed, "hash")
+}
+
+func TestAPITokenHandler_RevokeAndExpire(t *testing.T) {
+	s := setupPermissionServer(t)
+	id := s.createAPIToken(t, "dm", "bot", user.ScopeRoomsRead)
+	room := fmt.Sprintf("/rooms/%d", permissionRoomID)
+	rec, _ := s.do(t, "bot", "GET", room, nil)
+	require.Equal(t, 200, rec.Code)
+
+	// 其他用户看不到也不能撤销
+	rec, resp := s.do(t, "player", "DELETE", fmt.Sprintf("/user/tokens/%d", id), nil)
+	assert.Equal(t, 404, rec.Code)
+	assert.Equal(t, apperror.CodeAPITokenNotFound, resp.ErrorCode)
+	rec, resp = s.do(t, "player", "GET", "/user/tokens", nil)
+	require.Equal(t, 200, rec.Code)
+	assert.Empty(t, resp.Data)
+
+	rec, _ = s.do(t, "dm", "DELETE", fmt.Sprintf("/user/tokens/%d", id), nil)
+	require.Equal(t, 200, rec.Code)
+	rec, resp = s.do(t, "bot", "GET", room, nil)
+	assert.Equal(t, 401, rec.Code)
+	assert.Equal(t, apperror.CodeUnauthorized, resp.ErrorCode)
+
+	id = s.createAPIToken(t, "dm", "expired", user.ScopeRoomsRead)
+	require.NoError(t, s.db.Model(&user.APIToken{}).Where("id = ?", id).Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	rec, resp = s.do(t, "expired", "GET", room, nil)
+	assert.Equal(t, 401, rec.Code)
+	assert.Equal(t, apperror.CodeTokenExpired, resp.ErrorCode)
+
+	s.tokens["forged"] = user.APITokenPrefix + "not-a-real-token"
+	rec, _ = s.do(t, "forged", "GET", room, nil)
+	assert.Equal(t, 401, rec.Code)
+}
+
+func TestAPITokenHandler_Validation(t *testing.T) {
+	s := setupPermissionServer(t)
+	for name, body := range map[string]map[string]interface{}{
+		"missing name":  {"scopes": []string{user.ScopeRoomsRead}},
+		"no scopes":     {"name": "bot"},
+		"unknown scope": {"name": "bot", "scopes": []string{"rooms:delete"}},
+		"expiry":        {"name": "bot", "scopes": []string{user.ScopeRoomsRead}, "expires_in_days": user.MaxTokenExpiresIn + 1},
+	} {
+		rec, resp := s.do(t, "owner", "POST", "/user/tokens", body)
+		assert.Equal(t, 400, rec.Code, name)
+		assert.Equal(t, apperror.CodeValidationFailed, resp.ErrorCode, name)
+	}
+
+	rec, _ := s.do(t, "anonymous", "POST", "/user/tokens", map[string]interface{}{"name": "bot", "scopes": []string{user.ScopeRoomsRead}})
+	assert.Equal(t, 401, rec.Code)
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"time"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/policy"
+	"trpg-sync/backend/domain/room"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TransferRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// GetTransfer 返回房间待确认的 DM 转让，成员可见
+func (h *MemberHandler) GetTransfer(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	if _, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomView); !ok {
+		return
+	}
+
+	transfer, err := pendingTransfer(h.db, targetRoom.ID)
+	if err != nil {
+		response.Error(c, recordError(apperror.CodeTransferNotFound, err))
+		return
+	}
+	response.Success(c, transfer)
+}
+
+// RequestTransfer DM 发起转让，新 DM 必须是房间中的玩家，确认后才生效
+// 已有待确认的转让时以新的请求替换
+func (h *MemberHandler) RequestTransfer(c *gin.Context) {
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+	actor, ok := authorizeRoom(c, h.db, targetRoom, policy.RoomManageMembers)
+	if !ok {
+		return
+	}
+	if !actor.IsDM() {
+		response.Error(c, apperror.New(apperror.CodeMemberConflict, "The room has no DM to transfer"))
+		return
+	}
+
+	var req TransferRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.UserID == actor.UserID {
+		response.Error(c, apperror.New(apperror.CodeMemberConflict, "You are already the DM"))
+		return
+	}
+	if _, err := findMember(h.db, targetRoom.ID, req.UserID); err != nil {
+		response.Error(c, recordError(apperror.CodeMemberNotFound, err))
+		return
+	}
+
+	transfer := room.NewTransfer(targetRoom.ID, actor.UserID, req.UserID, time.Now())
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("room_id = ?", targetRoom.ID).Delete(&room.Transfer{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&transfer).Error
+	})
+	if err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to request DM transfer", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.DMTransferRequested{Transfer: transfer})
+	response.OK(c, "DM transfer requested", transfer)
+}
+
+// AcceptTransfer 新 DM 确认转让，双方身份在同一事务中互换，原 DM 成为玩家
+func (h *MemberHandler) AcceptTransfer(c *gin.Context) {
+	current, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+
+	transfer, err := pendingTransfer(h.db, targetRoom.ID)
+	if err != nil {
+		response.Error(c, recordError(apperror.CodeTransferNotFound, err))
+		return
+	}
+	if transfer.ToUserID != current.ID {
+		response.Error(c, apperror.New(apperror.CodeForbidden, "Only the new DM can accept the transfer"))
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		demoted := tx.Model(&room.Member{}).
+			Where("room_id = ? AND user_id = ? AND role = ?", transfer.RoomID, transfer.FromUserID, room.RoleDM).
+			Update("role", room.RolePlayer)
+		if demoted.Error != nil {
+			return demoted.Error
+		}
+		if demoted.RowsAffected == 0 {
+			return apperror.New(apperror.CodeMemberConflict, "The requesting user is no longer the DM")
+		}
+		promoted := tx.Model(&room.Member{}).
+			Where("room_id = ? AND user_id = ?", transfer.RoomID, transfer.ToUserID).
+			Update("role", room.RoleDM)
+		if promoted.Error != nil {
+			return promoted.Error
+		}
+		if promoted.RowsAffected == 0 {
+			return apperror.New(apperror.CodeMemberNotFound, "")
+		}
+		return tx.Delete(transfer).Error
+	})
+	if err != nil {
+		response.Error(c, storageError("Failed to transfer DM", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.DMTransferred{Transfer: *transfer})
+	response.OK(c, "DM transferred successfully", transfer)
+}
+
+// CancelTransfer DM 取消或新 DM 拒绝待确认的转让
+func (h *MemberHandler) CancelTransfer(c *gin.Context) {
+	current, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	targetRoom, ok := findRoom(c, h.db)
+	if !ok {
+		return
+	}
+
+	transfer, err := pendingTransfer(h.db, targetRoom.ID)
+	if err != nil {
+		response.Error(c, recordError(apperror.CodeTransferNotFound, err))
+		return
+	}
+	if current.ID != transfer.FromUserID && current.ID != transfer.ToUserID {
+		response.Error(c, apperror.New(apperror.CodeForbidden, "Only the DM or the new DM can cancel the transfer"))
+		return
+	}
+
+	if err := h.db.Delete(transfer).Error; err != nil {
+		response.Error(c, apperror.Wrap(apperror.CodeInternal, "Failed to cancel DM transfer", err))
+		return
+	}
+
+	h.events.PublishFrom(eventMeta(c), event.DMTransferCancelled{Transfer: *transfer, CancelledBy: current.ID})
+	response.OK(c, "DM transfer cancelled", nil)
+}
+
+// pendingTransfer 加载房间待确认的 DM 转让，已过期的视为不存在
+func pendingTransfer(db *gorm.DB, roomID uint) (*room.Transfer, error) {
+	var transfer room.Transfer
+	if err := db.Where("room_id = ?", roomID).First(&transfer).Error; err != nil {
+		return nil, err
+	}
+	if transfer.Expired(time.Now()) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &transfer, nil
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/audit"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/infrastructure/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// joinAll 让指定用户通过邀请码加入房间
+func (s *memberTestServer) joinAll(t *testing.T, roomID uint, names ...string) {
+	code := s.inviteCode(t, "dm", roomID)
+	for _, name := range names {
+		rec, _ := s.do(t, name, "POST", roomURL(roomID, "/join"), map[string]interface{}{"invite_code": code})
+		require.Equal(t, 200, rec.Code, rec.Body.String())
+	}
+}
+
+// roles 返回房间成员的用户名到身份的映射
+func (s *memberTestServer) roles(t *testing.T, roomID uint) map[string]string {
+	var members []room.Member
+	require.NoError(t, s.db.Where("room_id = ?", roomID).Find(&members).Error)
+	names := make(map[uint]string, len(s.users))
+	for name, id := range s.users {
+		names[id] = name
+	}
+	roles := make(map[string]string, len(members))
+	for _, member := range members {
+		roles[names[member.UserID]] = member.Role
+	}
+	return roles
+}
+
+func (s *memberTestServer) auditActions(t *testing.T, roomID uint) []string {
+	var actions []string
+	require.NoError(t, s.db.Model(&audit.Entry{}).Where("room_id = ?", roomID).Order("id").Pluck("action", &actions).Error)
+	return actions
+}
+
+func TestMemberHandler_TransferDM(t *testing.T) {
+	s := setupMemberServer(t, "dm", "alice", "bob", "outsider")
+	target := s.createRoom(t, "dm", map[string]interface{}{"name": "Curse of Strahd"})
+	s.joinAll(t, target.ID, "alice", "bob")
+	transferURL := roomURL(target.ID, "/transfer")
+
+	t.Run("request", func(t *testing.T) {
+		rec, resp := s.do(t, "alice", "GET", transferURL, nil)
+		assert.Equal(t, 404, rec.Code)
+		assert.Equal(t, apperror.CodeTransferNotFound, resp.ErrorCode)
+
+		rec, resp = s.do(t, "alice", "POST", transferURL, map[string]interface{}{"user_id": s.users["bob"]})
+		assert.Equal(t, 403, rec.Code)
+		assert.Equal(t, apperror.CodeForbidden, resp.ErrorCode)
+
+		rec, resp = s.do(t, "dm", "POST", transferURL, map[string]interface{}{"user_id": s.users["outsider"]})
+		assert.Equal(t, 404, rec.Code)
+		assert.Equal(t, apperror.CodeMemberNotFound, resp.ErrorCode)
+
+		rec, resp = s.do(t, "dm", "POST", transferURL, map[string]interface{}{"user_id": s.users["dm"]})
+		assert.Equal(t, 409, rec.Code)
+		assert.Equal(t, apperror.CodeMemberConflict, resp.ErrorCode)
+
+		rec, _ = s.do(t, "dm", "POST", transferURL, map[string]interface{}{"user_id": s.users["bob"]})
+		require.Equal(t, 200, rec.Code, rec.Body.String())
+		// 新的请求替换待确认的请求
+		rec, _ = s.do(t, "dm", "POST", transferURL, map[string]interface{}{"user_id": s.users["alice"]})
+		require.Equal(t, 200, rec.Code, rec.Body.String())
+
+		rec, resp = s.do(t, "bob", "GET", transferURL, nil)
+		require.Equal(t, 200, rec.Code)
+		pending := resp.Data.(map[string]interface{})
+		assert.Equal(t, float64(s.users["alice"]), pending["to_user_id"])
+		assert.Equal(t, float64(s.users["dm"]), pending["from_user_id"])
+	})
+
+	t.Run("only the new DM accepts", func(t *testing.T) {
+		rec, _ := s.do(t, "bob", "POST", transferURL+"/accept", nil)
+		assert.Equal(t, 403, rec.Code)
+		rec, _ = s.do(t, "bob", "DELETE", transferURL, nil)
+		assert.Equal(t, 403, rec.Code)
+		rec, _ = s.do(t, "", "POST", transferURL+"/accept", nil)
+		assert.Equal(t, 401, rec.Code)
+		assert.Equal(t, room.RoleDM, s.roles(t, target.ID)["dm"])
+	})
+
+	t.Run("accept", func(t *testing.T) {
+		rec, _ := s.do(t, "alice", "POST", transferURL+"/accept", nil)
+		require.Equal(t, 200, rec.Code, rec.Body.String())
+		assert.Equal(t, map[string]string{"dm": room.RolePlayer, "alice": room.RoleDM, "bob": room.RolePlayer}, s.roles(t, target.ID))
+
+		rec, _ = s.do(t, "alice", "GET", transferURL, nil)
+		assert.Equal(t, 404, rec.Code)
+
+		// 原 DM 失去管理权限
+		rec, _ = s.do(t, "dm", "GET", roomURL(target.ID, "/invite"), nil)
+		assert.Equal(t, 403, rec.Code)
+		rec, _ = s.do(t, "alice", "GET", roomURL(target.ID, "/invite"), nil)
+		assert.Equal(t, 200, rec.Code)
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		rec, _ := s.do(t, "alice", "POST", transferURL, map[string]interface{}{"user_id": s.users["dm"]})
+		require.Equal(t, 200, rec.Code)
+		rec, _ = s.do(t, "dm", "DELETE", transferURL, nil)
+		require.Equal(t, 200, rec.Code)
+		rec, resp := s.do(t, "dm", "POST", transferURL+"/accept", nil)
+		assert.Equal(t, 404, rec.Code)
+		assert.Equal(t, apperror.CodeTransferNotFound, resp.ErrorCode)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		rec, _ := s.do(t, "alice", "POST", transferURL, map[string]interface{}{"user_id": s.users["bob"]})
+		require.Equal(t, 200, rec.Code)
+		require.NoError(t, s.db.Model(&room.Transfer{}).Where("room_id = ?", target.ID).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+		rec, resp := s.do(t, "bob", "POST", transferURL+"/accept", nil)
+		assert.Equal(t, 404, rec.Code)
+		assert.Equal(t, apperror.CodeTransferNotFound, resp.ErrorCode)
+		assert.Equal(t, room.RoleDM, s.roles(t, target.ID)["alice"])
+	})
+
+	t.Run("kicking the new DM cancels the transfer", func(t *testing.T) {
+		rec, _ := s.do(t, "alice", "POST", transferURL, map[string]interface{}{"user_id": s.users["bob"]})
+		require.Equal(t, 200, rec.Code)
+		rec, _ = s.do(t, "alice", "POST", roomURL(target.ID, fmt.Sprintf("/kick/%d", s.users["bob"])), nil)
+		require.Equal(t, 200, rec.Code)
+		rec, _ = s.do(t, "alice", "GET", transferURL, nil)
+		assert.Equal(t, 404, rec.Code)
+	})
+
+	assert.Contains(t, s.events, "room.transfer_requested")
+	assert.Contains(t, s.events, "room.dm_transferred")
+	assert.Contains(t, s.events, "room.transfer_cancelled")
+
+	assert.Equal(t, []string{
+		"room.created", "member.joined", "member.joined",
+		"room.transfer_requested", "room.transfer_requested", "room.dm_transferred",
+		"room.transfer_requested", "room.transfer_cancelled",
+		"room.transfer_requested",
+		"room.transfer_requested", "member.kicked",
+	}, s.auditActions(t, target.ID))
+
+	var accepted audit.Entry
+	require.NoError(t, s.db.Where("room_id = ? AND action = ?", target.ID, "room.dm_transferred").First(&accepted).Error)
+	assert.Equal(t, s.users["alice"], accepted.ActorID)
+	assert.Equal(t, audit.EntityRoom, accepted.EntityType)
+	assert.Equal(t, float64(s.users["dm"]), accepted.Details["from_user_id"])
+}
+
+func TestMemberHandler_KickCards(t *testing.T) {
+	s := setupMemberServer(t, "dm", "alice", "bob")
+
+	roomID := uint(320)
+	cards := storage.NewCharacterStorage().WithIndex(storage.NewCharacterIndex(s.db))
+	require.NoError(t, os.RemoveAll(cards.GetRoomCharactersPath(roomID)))
+	defer os.RemoveAll(cards.GetRoomCharactersPath(roomID))
+	// 新建的房间 ID 接着已有的最大 ID，避免人物卡目录与其它测试冲突
+	require.NoError(t, s.db.Create(&room.Room{ID: roomID - 1, Name: "Seed Room"}).Error)
+	target := s.createRoom(t, "dm", map[string]interface{}{"name": "Out of the Abyss"})
+	require.Equal(t, roomID, target.ID)
+	s.joinAll(t, roomID, "alice", "bob")
+
+	for i, owner := range []string{"alice", "bob", "dm"} {
+		card := &character.CharacterCard{ID: uint(i + 1), RoomID: roomID, OwnerID: s.users[owner], Name: owner + "'s hero", Level: 1}
+		require.NoError(t, cards.SaveCharacter(card))
+	}
+	load := func(id uint) *character.CharacterCard {
+		card, err := cards.LoadCharacter(roomID, id)
+		require.NoError(t, err)
+		return card
+	}
+	kickURL := func(name, mode string) string {
+		return roomURL(roomID, fmt.Sprintf("/kick/%d%s", s.users[name], mode))
+	}
+
+	rec, resp := s.do(t, "dm", "POST", kickURL("alice", "?cards=burn"), nil)
+	assert.Equal(t, 400, rec.Code)
+	assert.Equal(t, apperror.CodeValidationFailed, resp.ErrorCode)
+	assert.Equal(t, room.RolePlayer, s.roles(t, roomID)["alice"])
+
+	t.Run("archive by default", func(t *testing.T) {
+		rec, resp := s.do(t, "dm", "POST", kickURL("alice", ""), nil)
+		require.Equal(t, 200, rec.Code, rec.Body.String())
+		result := resp.Data.(map[string]interface{})
+		assert.Equal(t, room.KickCardsArchive, result["cards"])
+		assert.Equal(t, []interface{}{float64(1)}, result["characters"])
+
+		card := load(1)
+		assert.True(t, card.IsArchived())
+		assert.Equal(t, s.users["alice"], card.OwnerID)
+		assert.False(t, load(3).IsArchived())
+	})
+
+	t.Run("transfer to the DM", func(t *testing.T) {
+		rec, resp := s.do(t, "dm", "POST", kickURL("bob", "?cards=transfer"), nil)
+		require.Equal(t, 200, rec.Code, rec.Body.String())
+		assert.Equal(t, []interface{}{float64(2)}, resp.Data.(map[string]interface{})["characters"])
+
+		card := load(2)
+		assert.False(t, card.IsArchived())
+		assert.Equal(t, s.users["dm"], card.OwnerID)
+	})
+
+	t.Run("rejoin restores archived cards", func(t *testing.T) {
+		s.joinAll(t, roomID, "alice")
+		card := load(1)
+		assert.False(t, card.IsArchived())
+		assert.Equal(t, s.users["alice"], card.OwnerID)
+	})
+
+	var kicks []audit.Entry
+	require.NoError(t, s.db.Where("room_id = ? AND action = ?", roomID, "member.kicked").Order("id").Find(&kicks).Error)
+	require.Len(t, kicks, 2)
+	assert.Equal(t, s.users["dm"], kicks[0].ActorID)
+	assert.Equal(t, audit.EntityMember, kicks[0].EntityType)
+	assert.Equal(t, s.users["alice"], kicks[0].EntityID)
+	assert.Equal(t, room.KickCardsArchive, kicks[0].Details["cards"])
+	assert.Equal(t, room.KickCardsTransfer, kicks[1].Details["cards"])
+}
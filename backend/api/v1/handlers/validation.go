@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"trpg-sync/backend/api/response"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// 校验错误中使用 JSON 字段名，便于前端对应到输入框
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// bindJSON 解析请求体，失败时返回逐字段的校验错误
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		respondValidation(c, bindErrors(err))
+		return false
+	}
+	return true
+}
+
+// bindOptionalJSON 与 bindJSON 相同，但允许请求体为空（全部使用默认值）
+func bindOptionalJSON(c *gin.Context, obj interface{}) bool {
+	if c.Request.ContentLength == 0 {
+		return true
+	}
+	return bindJSON(c, obj)
+}
+
+// respondValidation 按 Accept-Language 返回本地化的字段错误列表
+func respondValidation(c *gin.Context, errs []validation.FieldError) {
+	lang := validation.ParseLanguage(c.GetHeader("Accept-Language"))
+	response.Error(c, apperror.New(apperror.CodeValidationFailed, validation.Summary(lang)).WithData(gin.H{
+		"errors": validation.Localize(errs, lang),
+	}))
+}
+
+// bindErrors 将 JSON 解析错误和 binding 标签校验错误转换为字段错误
+func bindErrors(err error) []validation.FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		errs := make([]validation.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			errs = append(errs, tagError(fe))
+		}
+		return errs
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []validation.FieldError{
+			validation.NewError(typeErr.Field, validation.CodeInvalidType, map[string]interface{}{"type": typeErr.Type.String()}),
+		}
+	}
+
+	return []validation.FieldError{validation.NewError("", validation.CodeInvalidJSON, nil)}
+}
+
+func tagError(fe validator.FieldError) validation.FieldError {
+	switch fe.Tag() {
+	case "required":
+		return validation.NewError(fe.Field(), validation.CodeRequired, nil)
+	case "oneof":
+		return validation.NewError(fe.Field(), validation.CodeInvalidChoice, map[string]interface{}{
+			"choices": strings.Join(strings.Fields(fe.Param()), ", "),
+		})
+	case "min", "gte":
+		return validation.NewError(fe.Field(), validation.CodeTooSmall, map[string]interface{}{"min": fe.Param()})
+	case "max", "lte":
+		// 字符串和集合的 max 是长度上限，数值的 max 是取值上限（binding 标签不带下限时按 0 计）
+		switch fe.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			return validation.NewError(fe.Field(), validation.CodeTooLong, map[string]interface{}{"max": fe.Param()})
+		default:
+			return validation.NewError(fe.Field(), validation.CodeOutOfRange, map[string]interface{}{"min": 0, "max": fe.Param()})
+		}
+	default:
+		return validation.NewError(fe.Field(), fe.Tag(), nil)
+	}
+}
+
+// validateCharacter 校验人物卡基本字段，并按房间生成方式和种族加值校验属性值
+func validateCharacter(c *gin.Context, char *character.CharacterCard, method string, race character.RaceBonuses) bool {
+	errs := append(char.Validate(), character.ValidateAbilities(char, method, race)...)
+	if len(errs) == 0 {
+		return true
+	}
+	respondValidation(c, errs)
+	return false
+}
@@ -1,27 +1,162 @@
 package v1
 
 import (
+	"trpg-sync/backend/api/middleware"
 	"trpg-sync/backend/api/v1/handlers"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/infrastructure/auth"
+	"trpg-sync/backend/infrastructure/realtime"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(r *gin.Engine, db *gorm.DB) {
-	api := r.Group("/api/v1")
+// Dependencies 路由依赖的服务
+// Bus 为领域事件总线，所有修改数据的处理器向其发布事件；Hub 为实时连接使用的房间事件中心
+// AuthRequired 为 false 时接口允许匿名访问（单人本地模式），携带的令牌仍会被校验
+// AllowedOrigins 为允许跨域建立 WebSocket 连接的来源，同源页面无需配置
+type Dependencies struct {
+	DB             *gorm.DB
+	Bus            *event.Bus
+	Hub            *realtime.Hub
+	Tokens         *auth.Issuer
+	AuthRequired   bool
+	AllowedOrigins []string
+}
+
+// SetupRoutes 注册 API 路由，错误码目录、登录注册和分享链接无需认证，其余路由经过认证中间件
+func SetupRoutes(r *gin.Engine, deps Dependencies) {
+	db, bus, hub := deps.DB, deps.Bus, deps.Hub
+	public := r.Group("/api/v1")
+	api := r.Group("/api/v1", middleware.Auth(db, deps.Tokens, deps.AuthRequired))
+
+	// 错误码目录
+	errorHandler := handlers.NewErrorHandler()
+	public.GET("/errors", errorHandler.GetErrorCodes)
+
+	// 用户认证路由
+	authHandler := handlers.NewAuthHandler(db, deps.Tokens)
+	public.POST("/auth/register", authHandler.Register)
+	public.POST("/auth/login", authHandler.Login)
+	public.POST("/auth/refresh", authHandler.Refresh)
+	api.GET("/user/profile", authHandler.GetProfile)
+
+	// 个人 API 令牌路由，只能使用登录令牌管理
+	apiTokenHandler := handlers.NewAPITokenHandler(db)
+	api.POST("/user/tokens", apiTokenHandler.CreateAPIToken)
+	api.GET("/user/tokens", apiTokenHandler.GetAPITokens)
+	api.DELETE("/user/tokens/:id", apiTokenHandler.RevokeAPIToken)
 
 	// 房间路由
-	roomHandler := handlers.NewRoomHandler(db)
+	roomHandler := handlers.NewRoomHandler(db).WithEvents(bus)
 	api.POST("/rooms", roomHandler.CreateRoom)
 	api.GET("/rooms", roomHandler.GetRooms)
 	api.GET("/rooms/:id", roomHandler.GetRoom)
+	api.PUT("/rooms/:id", roomHandler.UpdateRoom)
+	api.PATCH("/rooms/:id", roomHandler.PatchRoom)
 	api.DELETE("/rooms/:id", roomHandler.DeleteRoom)
+	api.PUT("/rooms/:id/ability-method", roomHandler.UpdateAbilityMethod)
+	api.POST("/rooms/:id/archive", roomHandler.ArchiveRoom)
+	api.POST("/rooms/:id/unarchive", roomHandler.UnarchiveRoom)
+	api.GET("/rooms/:id/export", roomHandler.ExportRoom)
+	api.POST("/rooms/:id/clone", roomHandler.CloneRoom)
+
+	// 房间成员路由
+	memberHandler := handlers.NewMemberHandler(db).WithEvents(bus)
+	api.GET("/rooms/:id/members", memberHandler.GetMembers)
+	api.POST("/rooms/:id/join", memberHandler.JoinRoom)
+	api.POST("/rooms/:id/leave", memberHandler.LeaveRoom)
+	api.POST("/rooms/:id/kick/:userId", memberHandler.KickMember)
+	api.GET("/rooms/:id/transfer", memberHandler.GetTransfer)
+	api.POST("/rooms/:id/transfer", memberHandler.RequestTransfer)
+	api.POST("/rooms/:id/transfer/accept", memberHandler.AcceptTransfer)
+	api.DELETE("/rooms/:id/transfer", memberHandler.CancelTransfer)
+	api.GET("/rooms/:id/invite", memberHandler.GetInvite)
+	api.POST("/rooms/:id/invite/rotate", memberHandler.RotateInvite)
+	api.PUT("/rooms/:id/password", memberHandler.UpdatePassword)
+
+	// 战役模板路由
+	templateHandler := handlers.NewTemplateHandler(db).WithEvents(bus)
+	api.POST("/rooms/:id/templates", templateHandler.CreateTemplate)
+	api.GET("/templates", templateHandler.GetTemplates)
+	api.GET("/templates/:id", templateHandler.GetTemplate)
+	api.DELETE("/templates/:id", templateHandler.DeleteTemplate)
+	api.POST("/templates/:id/rooms", templateHandler.CreateRoomFromTemplate)
 
 	// 人物卡路由 - 使用独立路径避免Gin路由冲突
-	characterHandler := handlers.NewCharacterHandler()
+	characterHandler := handlers.NewCharacterHandler(db).WithEvents(bus)
+	api.GET("/characters/search", characterHandler.SearchCharacters)
 	api.POST("/characters/:roomId", characterHandler.CreateCharacter)
+	api.POST("/characters/:roomId/generate", characterHandler.GenerateCharacter)
 	api.GET("/characters/:roomId", characterHandler.GetCharacters)
 	api.GET("/characters/:roomId/:charId", characterHandler.GetCharacter)
 	api.PUT("/characters/:roomId/:charId", characterHandler.UpdateCharacter)
 	api.DELETE("/characters/:roomId/:charId", characterHandler.DeleteCharacter)
+	api.POST("/characters/:roomId/:charId/ability-roll", characterHandler.RollAbilities)
+	api.POST("/characters/:roomId/:charId/references", characterHandler.AddReference)
+	api.DELETE("/characters/:roomId/:charId/references/:type/:slug", characterHandler.RemoveReference)
+	api.GET("/rooms/:id/party", characterHandler.GetParty)
+
+	// 人物卡分享链接路由，凭令牌查看的接口无需登录
+	shareHandler := handlers.NewShareHandler(db)
+	api.POST("/characters/:roomId/:charId/share", shareHandler.CreateShare)
+	api.GET("/characters/:roomId/:charId/shares", shareHandler.GetShares)
+	api.DELETE("/characters/:roomId/:charId/shares/:shareId", shareHandler.RevokeShare)
+	public.GET("/share/:token", shareHandler.GetSharedCharacter)
+
+	// 审计日志路由
+	auditHandler := handlers.NewAuditHandler(db)
+	api.GET("/audit", auditHandler.GetAuditLog)
+
+	// 资料库路由（内置 SRD 5.1 + 自制内容）
+	compendiumHandler := handlers.NewCompendiumHandler(db)
+	api.GET("/compendium", compendiumHandler.GetTypes)
+	api.GET("/compendium/:type", compendiumHandler.SearchEntries)
+	api.GET("/compendium/:type/:slug", compendiumHandler.GetEntry)
+	api.GET("/rooms/:id/character-options", compendiumHandler.GetCharacterOptions)
+
+	// 怪物与 NPC 数据卡路由
+	statBlockHandler := handlers.NewStatBlockHandler(db).WithEvents(bus)
+	api.GET("/rooms/:id/statblocks", statBlockHandler.GetStatBlocks)
+	api.POST("/rooms/:id/statblocks", statBlockHandler.CreateStatBlock)
+	api.POST("/rooms/:id/statblocks/clone", statBlockHandler.CloneStatBlock)
+	api.GET("/rooms/:id/statblocks/:blockId", statBlockHandler.GetStatBlock)
+	api.PUT("/rooms/:id/statblocks/:blockId", statBlockHandler.UpdateStatBlock)
+	api.DELETE("/rooms/:id/statblocks/:blockId", statBlockHandler.DeleteStatBlock)
+
+	// 遭遇路由
+	encounterHandler := handlers.NewEncounterHandler(db).WithEvents(bus)
+	api.GET("/rooms/:id/encounters", encounterHandler.GetEncounters)
+	api.POST("/rooms/:id/encounters", encounterHandler.CreateEncounter)
+	api.GET("/rooms/:id/encounters/:encounterId", encounterHandler.GetEncounter)
+	api.PUT("/rooms/:id/encounters/:encounterId", encounterHandler.UpdateEncounter)
+	api.DELETE("/rooms/:id/encounters/:encounterId", encounterHandler.DeleteEncounter)
+	api.POST("/rooms/:id/encounters/:encounterId/spawn", encounterHandler.SpawnCombatants)
+	api.PATCH("/rooms/:id/encounters/:encounterId/combatants/:combatantId", encounterHandler.UpdateCombatant)
+	api.DELETE("/rooms/:id/encounters/:encounterId/combatants/:combatantId", encounterHandler.DeleteCombatant)
+	api.POST("/rooms/:id/encounters/:encounterId/next-turn", encounterHandler.NextTurn)
+	api.POST("/rooms/:id/encounter-difficulty", encounterHandler.CalculateDifficulty)
+
+	// 掷骰路由
+	rollHandler := handlers.NewRollHandler(db).WithEvents(bus)
+	api.POST("/rooms/:id/rolls", rollHandler.Roll)
+
+	// 实时同步路由
+	realtimeHandler := handlers.NewRealtimeHandler(db, hub).WithAllowedOrigins(deps.AllowedOrigins)
+	api.GET("/rooms/:id/ws", realtimeHandler.Connect)
+	api.GET("/rooms/:id/events", realtimeHandler.Events)
+
+	// 自制内容路由
+	homebrewHandler := handlers.NewHomebrewHandler(db).WithEvents(bus)
+	api.GET("/homebrew", homebrewHandler.GetHomebrew)
+	api.POST("/homebrew", homebrewHandler.CreateHomebrew)
+	api.GET("/homebrew/export", homebrewHandler.ExportHomebrew)
+	api.POST("/homebrew/import", homebrewHandler.ImportHomebrew)
+	api.GET("/homebrew/:id", homebrewHandler.GetHomebrewEntry)
+	api.PUT("/homebrew/:id", homebrewHandler.UpdateHomebrew)
+	api.DELETE("/homebrew/:id", homebrewHandler.DeleteHomebrew)
+
+	// 全文搜索路由
+	searchHandler := handlers.NewSearchHandler(db)
+	api.GET("/search", searchHandler.Search)
 }
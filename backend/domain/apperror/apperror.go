@@ -0,0 +1,160 @@
+package apperror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Code 应用错误码，与 HTTP 状态码一起返回，前端按错误码区分具体原因
+type Code string
+
+// 通用错误码
+const (
+	CodeBadRequest       Code = "BAD_REQUEST"
+	CodeValidationFailed Code = "VALIDATION_FAILED"
+	CodeInvalidID        Code = "INVALID_ID"
+	CodeInternal         Code = "INTERNAL_ERROR"
+)
+
+// 业务错误码
+const (
+	CodeRoomNotFound            Code = "ROOM_NOT_FOUND"
+	CodeRoomArchived            Code = "ROOM_ARCHIVED"
+	CodeCharacterNotFound       Code = "CHARACTER_NOT_FOUND"
+	CodeCharacterConflict       Code = "CHARACTER_CONFLICT"
+	CodeAbilityMethodNotAllowed Code = "ABILITY_METHOD_NOT_ALLOWED"
+	CodeReferenceNotFound       Code = "REFERENCE_NOT_FOUND"
+	CodeCompendiumTypeNotFound  Code = "COMPENDIUM_TYPE_NOT_FOUND"
+	CodeCompendiumTypeUnknown   Code = "COMPENDIUM_TYPE_UNKNOWN"
+	CodeCompendiumEntryNotFound Code = "COMPENDIUM_ENTRY_NOT_FOUND"
+	CodeHomebrewNotFound        Code = "HOMEBREW_NOT_FOUND"
+	CodeHomebrewConflict        Code = "HOMEBREW_CONFLICT"
+	CodeStatBlockNotFound       Code = "STAT_BLOCK_NOT_FOUND"
+	CodeEncounterNotFound       Code = "ENCOUNTER_NOT_FOUND"
+	CodeCombatantNotFound       Code = "COMBATANT_NOT_FOUND"
+	CodeTemplateNotFound        Code = "TEMPLATE_NOT_FOUND"
+	CodeUnauthorized            Code = "UNAUTHORIZED"
+	CodeTokenExpired            Code = "TOKEN_EXPIRED"
+	CodeInvalidCredentials      Code = "INVALID_CREDENTIALS"
+	CodeEmailTaken              Code = "EMAIL_TAKEN"
+	CodeForbidden               Code = "FORBIDDEN"
+	CodeInviteCodeInvalid       Code = "INVITE_CODE_INVALID"
+	CodeRoomPasswordIncorrect   Code = "ROOM_PASSWORD_INCORRECT"
+	CodeRoomFull                Code = "ROOM_FULL"
+	CodeMemberNotFound          Code = "MEMBER_NOT_FOUND"
+	CodeMemberConflict          Code = "MEMBER_CONFLICT"
+	CodeTransferNotFound        Code = "TRANSFER_NOT_FOUND"
+	CodeCharacterArchived       Code = "CHARACTER_ARCHIVED"
+	CodeShareLinkNotFound       Code = "SHARE_LINK_NOT_FOUND"
+	CodeAPITokenNotFound        Code = "API_TOKEN_NOT_FOUND"
+	CodeInsufficientScope       Code = "INSUFFICIENT_SCOPE"
+)
+
+// Definition 错误码目录中的一项
+type Definition struct {
+	Code        Code   `json:"code"`
+	Status      int    `json:"status"`
+	Message     string `json:"message"`
+	Description string `json:"description"`
+}
+
+// definitions 错误码目录，GET /api/v1/errors 按此顺序返回
+var definitions = []Definition{
+	{CodeBadRequest, http.StatusBadRequest, "Invalid request", "请求参数不合法，具体原因见 message"},
+	{CodeValidationFailed, http.StatusBadRequest, "Validation failed", "字段校验失败，data.errors 为 {field, code, message} 列表"},
+	{CodeInvalidID, http.StatusBadRequest, "Invalid ID", "路径中的 ID 不是合法的数字"},
+	{CodeInternal, http.StatusInternalServerError, "Internal server error", "服务器内部错误"},
+	{CodeRoomNotFound, http.StatusNotFound, "Room not found", "房间不存在"},
+	{CodeRoomArchived, http.StatusConflict, "Room is archived and read-only", "房间已归档，人物卡只读；取消归档后才能修改"},
+	{CodeCharacterNotFound, http.StatusNotFound, "Character not found", "人物卡不存在"},
+	{CodeCharacterConflict, http.StatusConflict, "Character conflict", "人物卡当前状态不允许该操作，如已有掷骰记录时重掷"},
+	{CodeAbilityMethodNotAllowed, http.StatusBadRequest, "Ability method not allowed in this room", "房间设置的属性值生成方式不允许该操作"},
+	{CodeReferenceNotFound, http.StatusNotFound, "Reference not found", "人物卡上没有该资料库引用"},
+	{CodeCompendiumTypeNotFound, http.StatusNotFound, "Unknown compendium type", "路径中的资料库类型不存在"},
+	{CodeCompendiumTypeUnknown, http.StatusBadRequest, "Unknown compendium type", "请求体中的资料库类型不存在"},
+	{CodeCompendiumEntryNotFound, http.StatusNotFound, "Compendium entry not found", "资料库条目不存在（含房间自制内容）"},
+	{CodeHomebrewNotFound, http.StatusNotFound, "Homebrew entry not found", "自制内容不存在"},
+	{CodeHomebrewConflict, http.StatusConflict, "Homebrew entry with the same slug already exists", "同一作用域内已有相同类型和 slug 的自制内容"},
+	{CodeStatBlockNotFound, http.StatusNotFound, "Stat block not found", "怪物或 NPC 数据卡不存在"},
+	{CodeEncounterNotFound, http.StatusNotFound, "Encounter not found", "遭遇不存在"},
+	{CodeCombatantNotFound, http.StatusNotFound, "Combatant not found", "遭遇中的实例不存在"},
+	{CodeTemplateNotFound, http.StatusNotFound, "Template not found", "战役模板不存在"},
+	{CodeUnauthorized, http.StatusUnauthorized, "Authentication required", "未登录或令牌无效，需要在 Authorization 头中携带 Bearer 访问令牌"},
+	{CodeTokenExpired, http.StatusUnauthorized, "Token expired", "令牌已过期，访问令牌过期后使用刷新令牌换取新令牌"},
+	{CodeInvalidCredentials, http.StatusUnauthorized, "Invalid email or password", "邮箱或密码错误"},
+	{CodeEmailTaken, http.StatusConflict, "Email already registered", "邮箱已被注册"},
+	{CodeForbidden, http.StatusForbidden, "Permission denied", "当前用户没有该操作的权限，如非成员访问房间、玩家修改他人的人物卡或非 DM 修改房间设置"},
+	{CodeInviteCodeInvalid, http.StatusForbidden, "Invalid invite code", "邀请码错误或已被 DM 重新生成"},
+	{CodeRoomPasswordIncorrect, http.StatusForbidden, "Incorrect room password", "房间设置了密码，未提供或密码错误"},
+	{CodeRoomFull, http.StatusConflict, "Room is full", "房间玩家数已达到上限"},
+	{CodeMemberNotFound, http.StatusNotFound, "Member not found", "该用户不是房间成员"},
+	{CodeMemberConflict, http.StatusConflict, "Member conflict", "成员当前身份不允许该操作，如 DM 退出房间或踢出自己"},
+	{CodeTransferNotFound, http.StatusNotFound, "No pending DM transfer", "房间没有待确认的 DM 转让，或转让已过期"},
+	{CodeCharacterArchived, http.StatusConflict, "Character is archived and read-only", "人物卡的所有者已被踢出房间，人物卡归档为只读；玩家重新加入后恢复"},
+	{CodeShareLinkNotFound, http.StatusNotFound, "Share link not found", "分享链接不存在、已过期或已被撤销"},
+	{CodeAPITokenNotFound, http.StatusNotFound, "API token not found", "API 令牌不存在或不属于当前用户"},
+	{CodeInsufficientScope, http.StatusForbidden, "API token scope does not allow this request", "API 令牌未被授予该接口所需的权限范围，如用只有 rooms:read 的令牌修改人物卡；令牌管理接口只能用登录令牌访问"},
+}
+
+// Catalogue 返回全部错误码定义
+func Catalogue() []Definition {
+	return append([]Definition(nil), definitions...)
+}
+
+// Lookup 返回错误码定义，未知错误码按内部错误处理
+func Lookup(code Code) Definition {
+	for _, def := range definitions {
+		if def.Code == code {
+			return def
+		}
+	}
+	if code != CodeInternal {
+		return Lookup(CodeInternal)
+	}
+	return Definition{Code: CodeInternal, Status: http.StatusInternalServerError, Message: "Internal server error"}
+}
+
+// Error 应用错误，Err 为底层原因（只用于日志，不返回给客户端）
+type Error struct {
+	Code    Code
+	Message string
+	Data    interface{}
+	Err     error
+}
+
+// New 创建应用错误，message 为空时使用错误码目录中的默认消息
+func New(code Code, message string) *Error {
+	if message == "" {
+		message = Lookup(code).Message
+	}
+	return &Error{Code: code, Message: message}
+}
+
+// Wrap 创建带底层原因的应用错误
+func Wrap(code Code, message string, err error) *Error {
+	appErr := New(code, message)
+	appErr.Err = err
+	return appErr
+}
+
+// WithData 附带返回给客户端的数据
+func (e *Error) WithData(data interface{}) *Error {
+	e.Data = data
+	return e
+}
+
+// Status 返回错误码对应的 HTTP 状态码
+func (e *Error) Status() int {
+	return Lookup(e.Code).Status
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// 审计记录的对象类型
+const (
+	EntityRoom      = "room"
+	EntityMember    = "member"
+	EntityCharacter = "character"
+)
+
+// EntityTypes 全部对象类型，用于校验查询参数
+var EntityTypes = []string{EntityRoom, EntityMember, EntityCharacter}
+
+// ignoredFields 每次修改都会变化的时间戳，不计入差异
+var ignoredFields = []string{"created_at", "updated_at"}
+
+// Entry 审计日志，只追加不修改（数据库触发器禁止 UPDATE），超过保留期限后整条删除
+// Action 为领域事件名称，ActorID 为执行操作的用户（匿名为 0），EntityID 为对象的 ID（成员为用户 ID）
+// Changes 为修改前后有变化的字段：新建时 before 为 null，删除时 after 为 null
+type Entry struct {
+	ID         uint                   `json:"id" gorm:"primaryKey"`
+	RoomID     uint                   `json:"room_id" gorm:"not null;default:0;index"`
+	ActorID    uint                   `json:"actor_id" gorm:"not null;default:0;index"`
+	Action     string                 `json:"action" gorm:"size:64;not null;index"`
+	EntityType string                 `json:"entity_type" gorm:"size:32;not null;index:idx_audit_entity"`
+	EntityID   uint                   `json:"entity_id" gorm:"not null;default:0;index:idx_audit_entity"`
+	Changes    map[string]Change      `json:"changes,omitempty" gorm:"serializer:json"`
+	Details    map[string]interface{} `json:"details,omitempty" gorm:"serializer:json"`
+	IP         string                 `json:"ip" gorm:"size:64"`
+	CreatedAt  time.Time              `json:"created_at" gorm:"index"`
+}
+
+func (Entry) TableName() string {
+	return "audit_log"
+}
+
+// Change 单个字段修改前后的值
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Diff 按 JSON 字段比较修改前后的对象，返回有变化的字段；before 或 after 为 nil 表示新建或删除
+// 不输出 JSON 的字段（如房间密码哈希）不会出现在差异中
+func Diff(before, after interface{}) (map[string]Change, error) {
+	old, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	current, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for key, value := range old {
+		if !reflect.DeepEqual(value, current[key]) {
+			changes[key] = Change{Before: value, After: current[key]}
+		}
+	}
+	for key, value := range current {
+		if _, ok := old[key]; !ok && value != nil {
+			changes[key] = Change{Before: nil, After: value}
+		}
+	}
+	for _, key := range ignoredFields {
+		delete(changes, key)
+	}
+	return changes, nil
+}
+
+// fields 将对象转换为 JSON 字段映射，nil（包括 nil 指针）返回空映射
+func fields(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// IsEntityType 判断对象类型是否合法
+func IsEntityType(entityType string) bool {
+	return slices.Contains(EntityTypes, entityType)
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/room"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff_Update(t *testing.T) {
+	before := character.CharacterCard{ID: 1, Name: "Grog", HP: 40, MaxHP: 52, Conditions: []string{"raging"}}
+	after := before.Clone()
+	after.HP = 12
+	after.Conditions = nil
+
+	changes, err := Diff(before, after)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Change{
+		"hp":         {Before: float64(40), After: float64(12)},
+		"conditions": {Before: []interface{}{"raging"}, After: nil},
+	}, changes)
+}
+
+func TestDiff_CreateAndDelete(t *testing.T) {
+	created := room.Room{ID: 3, Name: "Vox Machina", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	created.SetPassword("secret")
+
+	changes, err := Diff(nil, created)
+	require.NoError(t, err)
+	assert.Equal(t, Change{Before: nil, After: "Vox Machina"}, changes["name"])
+	assert.NotContains(t, changes, "created_at")
+	assert.NotContains(t, changes, "password_hash")
+	assert.NotContains(t, changes, "archived_at")
+
+	var deleted *character.CharacterCard
+	changes, err = Diff(deleted, nil)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	changes, err = Diff(&character.CharacterCard{ID: 2, Name: "Scanlan"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Change{Before: "Scanlan", After: nil}, changes["name"])
+}
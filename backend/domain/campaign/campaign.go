@@ -0,0 +1,109 @@
+package campaign
+
+import (
+	"strings"
+	"time"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/homebrew"
+	"trpg-sync/backend/domain/monster"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/validation"
+)
+
+// 模板字段长度限制
+const (
+	MaxNameLength        = room.MaxNameLength
+	MaxDescriptionLength = room.MaxDescriptionLength
+)
+
+// Options 克隆房间或保存模板时要复制的内容，房间设置总是复制
+// 数据卡的备注（NPC 笔记）随数据卡复制；仓库中还没有会话笔记，Notes 为 true 时校验报错，而不是静默忽略
+type Options struct {
+	Characters bool `json:"characters"`
+	StatBlocks bool `json:"stat_blocks"`
+	Encounters bool `json:"encounters"`
+	Homebrew   bool `json:"homebrew"`
+	Notes      bool `json:"notes"`
+}
+
+// Normalize 遭遇中的实例引用房间的数据卡，复制遭遇时一并复制数据卡
+func (o Options) Normalize() Options {
+	if o.Encounters {
+		o.StatBlocks = true
+	}
+	return o
+}
+
+// Validate 校验复制选项，字段名带 include. 前缀
+func (o Options) Validate() []validation.FieldError {
+	if o.Notes {
+		return []validation.FieldError{validation.NewError("include.notes", validation.CodeUnsupported, nil)}
+	}
+	return nil
+}
+
+// Snapshot 房间内容快照，ID 和时间戳在恢复时重新生成
+type Snapshot struct {
+	Room       room.Room                 `json:"room"`
+	Characters []character.CharacterCard `json:"characters"`
+	StatBlocks []monster.StatBlock       `json:"stat_blocks"`
+	Encounters []encounter.Encounter     `json:"encounters"`
+	Homebrew   []homebrew.Entry          `json:"homebrew"`
+}
+
+// Template 可重复使用的战役模板，由房间保存而来，可以据此创建新房间
+// 快照中包含房间的全部人物卡，模板只对保存它的用户可见；OwnerID 为 0 表示未登录时保存，所有人可见
+type Template struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	OwnerID      uint      `json:"owner_id" gorm:"not null;default:0;index"`
+	Name         string    `json:"name" gorm:"not null"`
+	Description  string    `json:"description"`
+	RuleSystem   string    `json:"rule_system" gorm:"not null;default:'DND5e'"`
+	Tags         []string  `json:"tags" gorm:"serializer:json"`
+	SourceRoomID uint      `json:"source_room_id" gorm:"index"`
+	Options      Options   `json:"options" gorm:"serializer:json"`
+	Snapshot     Snapshot  `json:"-" gorm:"serializer:json"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (Template) TableName() string {
+	return "room_templates"
+}
+
+// Summary 模板库中展示的内容数量
+type Summary struct {
+	Characters int `json:"characters"`
+	StatBlocks int `json:"stat_blocks"`
+	Encounters int `json:"encounters"`
+	Homebrew   int `json:"homebrew"`
+}
+
+// Summary 统计模板中各类内容的数量
+func (t *Template) Summary() Summary {
+	return Summary{
+		Characters: len(t.Snapshot.Characters),
+		StatBlocks: len(t.Snapshot.StatBlocks),
+		Encounters: len(t.Snapshot.Encounters),
+		Homebrew:   len(t.Snapshot.Homebrew),
+	}
+}
+
+// Validate 校验模板名称和简介长度
+func (t *Template) Validate() []validation.FieldError {
+	var errs []validation.FieldError
+
+	name := strings.TrimSpace(t.Name)
+	switch {
+	case name == "":
+		errs = append(errs, validation.NewError("name", validation.CodeRequired, nil))
+	case len([]rune(name)) > MaxNameLength:
+		errs = append(errs, validation.NewError("name", validation.CodeTooLong, map[string]interface{}{"max": MaxNameLength}))
+	}
+
+	if len([]rune(t.Description)) > MaxDescriptionLength {
+		errs = append(errs, validation.NewError("description", validation.CodeTooLong, map[string]interface{}{"max": MaxDescriptionLength}))
+	}
+	return errs
+}
@@ -0,0 +1,230 @@
+package character
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/dice"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/validation"
+)
+
+// 属性值范围
+const (
+	MinAbilityScore = 1
+	MaxAbilityScore = 30
+)
+
+// AbilityRollFormula 服务器掷属性骰使用的公式：4d6 去掉最低
+const AbilityRollFormula = "4d6dl1"
+
+// AbilityRoll 服务器掷出的属性骰记录，保存在人物卡上供核对，不可由客户端修改
+type AbilityRoll struct {
+	Formula  string    `json:"formula"`
+	Seed     int64     `json:"seed"`
+	Values   []int     `json:"values"`
+	Rolls    [][]int   `json:"rolls"`
+	RolledAt time.Time `json:"rolled_at"`
+}
+
+// RollAbilities 按种子掷六组属性骰
+func RollAbilities(seed int64) *AbilityRoll {
+	formula, _ := dice.ParseFormula(AbilityRollFormula)
+	roller := dice.NewRoller(seed)
+
+	roll := &AbilityRoll{
+		Formula:  AbilityRollFormula,
+		Seed:     seed,
+		RolledAt: time.Now(),
+	}
+	for range Abilities {
+		total, rolls := roller.Roll(formula)
+		roll.Values = append(roll.Values, total)
+		roll.Rolls = append(roll.Rolls, rolls)
+	}
+	return roll
+}
+
+// MemberRoll 成员在房间中的属性骰，每名成员在每个房间只掷一次
+// 该成员之后新建的人物卡都沿用这组结果，删除人物卡后重新创建不能换一组属性
+type MemberRoll struct {
+	RoomID    uint        `json:"room_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID    uint        `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Roll      AbilityRoll `json:"roll" gorm:"serializer:json;not null"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+func (MemberRoll) TableName() string {
+	return "ability_rolls"
+}
+
+// Scores 按属性名返回六项属性值
+func (c *CharacterCard) Scores() map[string]int {
+	return map[string]int{
+		AbilityStrength:     c.Strength,
+		AbilityDexterity:    c.Dexterity,
+		AbilityConstitution: c.Constitution,
+		AbilityIntelligence: c.Intelligence,
+		AbilityWisdom:       c.Wisdom,
+		AbilityCharisma:     c.Charisma,
+	}
+}
+
+// SetScores 按 Abilities 的顺序设置六项属性值
+func (c *CharacterCard) SetScores(values []int) {
+	c.Strength = values[0]
+	c.Dexterity = values[1]
+	c.Constitution = values[2]
+	c.Intelligence = values[3]
+	c.Wisdom = values[4]
+	c.Charisma = values[5]
+}
+
+// AssignRolledScores 按 Abilities 的顺序填入服务器掷骰结果，并加上属性加值
+func (c *CharacterCard) AssignRolledScores() {
+	if c.AbilityRoll == nil || len(c.AbilityRoll.Values) != len(Abilities) {
+		return
+	}
+
+	values := make([]int, len(Abilities))
+	for i, ability := range Abilities {
+		values[i] = c.AbilityRoll.Values[i] + c.AbilityBonuses[ability]
+	}
+	c.SetScores(values)
+}
+
+// RaceBonuses 种族（含亚种）提供的属性加值，Fixed 为固定加值，Choices 为可自选分配的 +1 项数
+// 零值表示没有任何加值，用于资料库中找不到的种族
+type RaceBonuses struct {
+	Fixed   map[string]int
+	Choices int
+}
+
+// FindRaceBonuses 按人物卡上的种族在种族条目中查找属性加值，种族可以是种族或亚种的名称或 slug
+// 亚种的加值与所属种族的加值叠加；races 按资料库顺序排列（SRD 在前、自制内容在后），同名时自制内容优先
+func FindRaceBonuses(races []compendium.Entry, name string) (RaceBonuses, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return RaceBonuses{}, false
+	}
+	for _, entry := range slices.Backward(races) {
+		var race compendium.RaceData
+		if entry.Decode(&race) != nil {
+			continue
+		}
+		bonuses := RaceBonuses{Fixed: copyBonuses(race.AbilityBonuses), Choices: race.AbilityBonusChoices}
+		if strings.EqualFold(entry.Name, name) || strings.EqualFold(entry.Slug, name) {
+			return bonuses, true
+		}
+		for _, subrace := range race.Subraces {
+			if strings.EqualFold(subrace.Name, name) || strings.EqualFold(subrace.Slug, name) {
+				for ability, bonus := range subrace.AbilityBonuses {
+					bonuses.Fixed[ability] += bonus
+				}
+				return bonuses, true
+			}
+		}
+	}
+	return RaceBonuses{}, false
+}
+
+// ValidateAbilities 按房间的属性生成方式校验人物卡属性值
+// AbilityBonuses 必须来自种族：每项不超过种族的固定加值，自选的 +1 只能加在没有固定加值的属性上且不超过可选项数
+// 属性值减去 AbilityBonuses 后得到基础值，再按生成方式核对
+func ValidateAbilities(card *CharacterCard, method string, race RaceBonuses) []validation.FieldError {
+	var errs []validation.FieldError
+
+	bonusAbilities := make([]string, 0, len(card.AbilityBonuses))
+	for ability := range card.AbilityBonuses {
+		bonusAbilities = append(bonusAbilities, ability)
+	}
+	sort.Strings(bonusAbilities)
+
+	chosen := 0
+	for _, ability := range bonusAbilities {
+		bonus := card.AbilityBonuses[ability]
+		field := "ability_bonuses." + ability
+		if !slices.Contains(Abilities, ability) {
+			errs = append(errs, validation.NewError(field, validation.CodeUnknownAbility, nil))
+			continue
+		}
+		limit := race.Fixed[ability]
+		if limit == 0 && race.Choices > 0 {
+			limit = 1
+		}
+		if bonus < 0 || bonus > limit {
+			errs = append(errs, validation.NewError(field, validation.CodeOutOfRange, map[string]interface{}{"min": 0, "max": limit}))
+			continue
+		}
+		if race.Fixed[ability] == 0 && bonus > 0 {
+			chosen++
+		}
+	}
+	if chosen > race.Choices {
+		errs = append(errs, validation.NewError("ability_bonuses", validation.CodeBonusChoices, map[string]interface{}{"choices": race.Choices}))
+	}
+
+	scores := card.Scores()
+	for _, ability := range Abilities {
+		if scores[ability] < MinAbilityScore || scores[ability] > MaxAbilityScore {
+			errs = append(errs, validation.NewError(ability, validation.CodeOutOfRange, map[string]interface{}{"min": MinAbilityScore, "max": MaxAbilityScore}))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	base := make([]int, 0, len(Abilities))
+	for _, ability := range Abilities {
+		base = append(base, scores[ability]-card.AbilityBonuses[ability])
+	}
+
+	switch method {
+	case room.AbilityMethodPointBuy:
+		for i, ability := range Abilities {
+			if base[i] < 8 || base[i] > 15 {
+				errs = append(errs, validation.NewError(ability, validation.CodePointBuyRange, nil))
+			}
+		}
+		if len(errs) == 0 {
+			if cost, _ := PointBuyCost(base); cost > PointBuyBudget {
+				errs = append(errs, validation.NewError("abilities", validation.CodePointBuyBudget, map[string]interface{}{"cost": cost, "budget": PointBuyBudget}))
+			}
+		}
+	case room.AbilityMethodStandardArray:
+		if !sameValues(base, StandardArray) {
+			errs = append(errs, validation.NewError("abilities", validation.CodeStandardArrayMismatch, nil))
+		}
+	case room.AbilityMethodRolled:
+		if card.AbilityRoll == nil {
+			errs = append(errs, validation.NewError("ability_roll", validation.CodeRollMissing, nil))
+		} else if !sameValues(base, card.AbilityRoll.Values) {
+			errs = append(errs, validation.NewError("abilities", validation.CodeRollMismatch, map[string]interface{}{"values": formatValues(card.AbilityRoll.Values)}))
+		}
+	}
+	return errs
+}
+
+// formatValues 将数值列表格式化为 "15, 14, 13"
+func formatValues(values []int) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = strconv.Itoa(value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sameValues 判断两组数值在忽略顺序时是否相同
+func sameValues(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	x := append([]int(nil), a...)
+	y := append([]int(nil), b...)
+	sort.Ints(x)
+	sort.Ints(y)
+	return slices.Equal(x, y)
+}
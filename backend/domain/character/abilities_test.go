@@ -0,0 +1,98 @@
+package character
+
+import (
+	"encoding/json"
+	"testing"
+
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/validation"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAbilities(t *testing.T) {
+	card := func(values ...int) *CharacterCard {
+		c := &CharacterCard{}
+		c.SetScores(values)
+		return c
+	}
+
+	assert.Empty(t, ValidateAbilities(card(15, 14, 13, 12, 10, 8), room.AbilityMethodPointBuy, RaceBonuses{}))
+	assert.Empty(t, ValidateAbilities(card(13, 13, 13, 12, 12, 12), room.AbilityMethodPointBuy, RaceBonuses{}))
+	assert.Empty(t, ValidateAbilities(card(30, 1, 1, 1, 1, 1), room.AbilityMethodFree, RaceBonuses{}))
+
+	errs := ValidateAbilities(card(14, 14, 14, 14, 14, 14), room.AbilityMethodPointBuy, RaceBonuses{})
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, validation.CodePointBuyBudget, errs[0].Code)
+	}
+
+	dragonborn := RaceBonuses{Fixed: map[string]int{"strength": 2, "charisma": 1}}
+	bonused := card(17, 14, 13, 12, 10, 8)
+	bonused.AbilityBonuses = map[string]int{"strength": 2, "luck": 1}
+	errs = ValidateAbilities(bonused, room.AbilityMethodStandardArray, dragonborn)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "ability_bonuses.luck", errs[0].Field)
+	}
+
+	delete(bonused.AbilityBonuses, "luck")
+	assert.Empty(t, ValidateAbilities(bonused, room.AbilityMethodStandardArray, dragonborn))
+
+	rolled := card(12, 11, 10, 9, 8, 7)
+	errs = ValidateAbilities(rolled, room.AbilityMethodRolled, RaceBonuses{})
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, validation.CodeRollMissing, errs[0].Code)
+	}
+
+	rolled.AbilityRoll = RollAbilities(3)
+	rolled.AssignRolledScores()
+	assert.Empty(t, ValidateAbilities(rolled, room.AbilityMethodRolled, RaceBonuses{}))
+	rolled.Strength++
+	assert.NotEmpty(t, ValidateAbilities(rolled, room.AbilityMethodRolled, RaceBonuses{}))
+}
+
+func TestValidateAbilities_RaceBonuses(t *testing.T) {
+	// 每项 +2 会把标准属性组抬高到 17、16、15、14、12、10
+	inflated := &CharacterCard{}
+	inflated.SetScores([]int{17, 16, 15, 14, 12, 10})
+	inflated.AbilityBonuses = map[string]int{}
+	for _, ability := range Abilities {
+		inflated.AbilityBonuses[ability] = 2
+	}
+	errs := ValidateAbilities(inflated, room.AbilityMethodStandardArray, RaceBonuses{})
+	if assert.Len(t, errs, len(Abilities)) {
+		assert.Equal(t, validation.CodeOutOfRange, errs[0].Code)
+	}
+
+	halfElf := RaceBonuses{Fixed: map[string]int{"charisma": 2}, Choices: 2}
+	card := &CharacterCard{}
+	card.SetScores([]int{16, 15, 13, 12, 10, 10})
+	card.AbilityBonuses = map[string]int{"strength": 1, "dexterity": 1, "charisma": 2}
+	assert.Empty(t, ValidateAbilities(card, room.AbilityMethodStandardArray, halfElf))
+
+	card.Constitution++
+	card.AbilityBonuses["constitution"] = 1
+	errs = ValidateAbilities(card, room.AbilityMethodStandardArray, halfElf)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "ability_bonuses", errs[0].Field)
+		assert.Equal(t, validation.CodeBonusChoices, errs[0].Code)
+	}
+}
+
+func TestFindRaceBonuses(t *testing.T) {
+	races := []compendium.Entry{
+		{Slug: "dwarf", Name: "Dwarf", Data: json.RawMessage(`{"ability_bonuses":{"constitution":2},"subraces":[{"slug":"hill-dwarf","name":"Hill Dwarf","ability_bonuses":{"wisdom":1}}]}`)},
+		{Slug: "half-elf", Name: "Half-Elf", Data: json.RawMessage(`{"ability_bonuses":{"charisma":2},"ability_bonus_choices":2}`)},
+	}
+
+	bonuses, ok := FindRaceBonuses(races, "hill dwarf")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]int{"constitution": 2, "wisdom": 1}, bonuses.Fixed)
+
+	bonuses, ok = FindRaceBonuses(races, "half-elf")
+	assert.True(t, ok)
+	assert.Equal(t, 2, bonuses.Choices)
+
+	_, ok = FindRaceBonuses(races, "Warforged")
+	assert.False(t, ok)
+}
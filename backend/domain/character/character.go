@@ -1,27 +1,133 @@
 package character
 
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"trpg-sync/backend/domain/validation"
+)
+
+// 人物卡字段范围
+const (
+	MinLevel      = 1
+	MaxLevel      = 20
+	MaxNameLength = 100
+)
+
 type CharacterCard struct {
-	ID           uint      `json:"id"`
-	RoomID       uint      `json:"room_id"`
-	Name         string    `json:"name"`
-	Race         string    `json:"race"`
-	Class        string    `json:"class"`
-	Level        int       `json:"level"`
-	Background   string    `json:"background"`
-	Alignment    string    `json:"alignment"`
-	Strength     int       `json:"strength"`
-	Dexterity    int       `json:"dexterity"`
-	Constitution int       `json:"constitution"`
-	Intelligence int       `json:"intelligence"`
-	Wisdom       int       `json:"wisdom"`
-	Charisma     int       `json:"charisma"`
-	AC           int       `json:"ac"`
-	HP           int       `json:"hp"`
-	MaxHP        int       `json:"max_hp"`
-	Speed        int       `json:"speed"`
-	Proficiency  int       `json:"proficiency"`
-	Skills       string    `json:"skills"`
-	Saves        string    `json:"saves"`
-	Equipment    string    `json:"equipment"`
-	Spells       string    `json:"spells"`
+	ID           uint            `json:"id"`
+	RoomID       uint            `json:"room_id"`
+	OwnerID      uint            `json:"owner_id"`
+	Name         string          `json:"name"`
+	Race         string          `json:"race"`
+	Class        string          `json:"class"`
+	Level        int             `json:"level"`
+	Background   string          `json:"background"`
+	Alignment    string          `json:"alignment"`
+	Strength     int             `json:"strength"`
+	Dexterity    int             `json:"dexterity"`
+	Constitution int             `json:"constitution"`
+	Intelligence int             `json:"intelligence"`
+	Wisdom       int             `json:"wisdom"`
+	Charisma     int             `json:"charisma"`
+	AC           int             `json:"ac"`
+	HP           int             `json:"hp"`
+	MaxHP        int             `json:"max_hp"`
+	Speed        int             `json:"speed"`
+	Proficiency  int             `json:"proficiency"`
+	Skills       string          `json:"skills"`
+	Saves        string          `json:"saves"`
+	Equipment    string          `json:"equipment"`
+	Spells       string          `json:"spells"`
+	References   []CompendiumRef `json:"references,omitempty"`
+	// AbilityBonuses 种族等来源的属性加值，校验时从属性值中扣除
+	AbilityBonuses map[string]int `json:"ability_bonuses,omitempty"`
+	// AbilityRoll 服务器掷出的属性骰记录（rolled 方式）
+	AbilityRoll *AbilityRoll `json:"ability_roll,omitempty"`
+	// Conditions 当前状态，如 poisoned、prone
+	Conditions []string `json:"conditions,omitempty"`
+	// Resources 剩余的职业资源
+	Resources []Resource `json:"resources,omitempty"`
+	// ArchivedAt 所有者被踢出房间时归档的时间，归档的人物卡只读
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// CompendiumRef 人物卡对资料库条目的引用
+type CompendiumRef struct {
+	Type   string `json:"type"`
+	Slug   string `json:"slug"`
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+// HasReference 判断是否已引用指定条目
+func (c *CharacterCard) HasReference(refType, slug string) bool {
+	for _, ref := range c.References {
+		if ref.Type == refType && ref.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveReference 移除指定引用，返回是否存在
+func (c *CharacterCard) RemoveReference(refType, slug string) bool {
+	for i, ref := range c.References {
+		if ref.Type == refType && ref.Slug == slug {
+			c.References = append(c.References[:i], c.References[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// IsArchived 人物卡是否已归档
+func (c *CharacterCard) IsArchived() bool {
+	return c.ArchivedAt != nil
+}
+
+// Clone 复制人物卡，切片和映射不与原人物卡共享；AbilityRoll 生成后不再修改，直接共用
+func (c *CharacterCard) Clone() CharacterCard {
+	clone := *c
+	clone.References = slices.Clone(c.References)
+	clone.AbilityBonuses = maps.Clone(c.AbilityBonuses)
+	clone.Conditions = slices.Clone(c.Conditions)
+	clone.Resources = slices.Clone(c.Resources)
+	return clone
+}
+
+// AbilityModifier 计算属性调整值（向下取整）
+func AbilityModifier(score int) int {
+	if score >= 10 {
+		return (score - 10) / 2
+	}
+	return (score - 11) / 2
+}
+
+// Validate 校验人物卡的基本字段：名称、等级 1-20、生命值不超过最大生命值、状态和资源
+// 属性值范围和生成方式由 ValidateAbilities 校验
+func (c *CharacterCard) Validate() []validation.FieldError {
+	var errs []validation.FieldError
+
+	name := strings.TrimSpace(c.Name)
+	switch {
+	case name == "":
+		errs = append(errs, validation.NewError("name", validation.CodeRequired, nil))
+	case len([]rune(name)) > MaxNameLength:
+		errs = append(errs, validation.NewError("name", validation.CodeTooLong, map[string]interface{}{"max": MaxNameLength}))
+	}
+
+	if c.Level < MinLevel || c.Level > MaxLevel {
+		errs = append(errs, validation.NewError("level", validation.CodeOutOfRange, map[string]interface{}{"min": MinLevel, "max": MaxLevel}))
+	}
+	if c.MaxHP < 0 {
+		errs = append(errs, validation.NewError("max_hp", validation.CodeTooSmall, map[string]interface{}{"min": 0}))
+	}
+	if c.HP < 0 {
+		errs = append(errs, validation.NewError("hp", validation.CodeTooSmall, map[string]interface{}{"min": 0}))
+	} else if c.HP > c.MaxHP {
+		errs = append(errs, validation.NewError("hp", validation.CodeExceedsMax, map[string]interface{}{"other": "max_hp"}))
+	}
+	return append(errs, c.validateTracking()...)
 }
@@ -0,0 +1,483 @@
+package character
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/dice"
+)
+
+// 属性值生成方式
+const (
+	MethodRoll          = "roll"
+	MethodStandardArray = "standard_array"
+	MethodPointBuy      = "point_buy"
+)
+
+// 属性名称，与资料库数据中的键一致
+const (
+	AbilityStrength     = "strength"
+	AbilityDexterity    = "dexterity"
+	AbilityConstitution = "constitution"
+	AbilityIntelligence = "intelligence"
+	AbilityWisdom       = "wisdom"
+	AbilityCharisma     = "charisma"
+)
+
+// Abilities 六项属性
+var Abilities = []string{
+	AbilityStrength,
+	AbilityDexterity,
+	AbilityConstitution,
+	AbilityIntelligence,
+	AbilityWisdom,
+	AbilityCharisma,
+}
+
+// AllSkills 全部技能
+var AllSkills = []string{
+	"Acrobatics", "Animal Handling", "Arcana", "Athletics", "Deception", "History",
+	"Insight", "Intimidation", "Investigation", "Medicine", "Nature", "Perception",
+	"Performance", "Persuasion", "Religion", "Sleight of Hand", "Stealth", "Survival",
+}
+
+// Alignments 阵营
+var Alignments = []string{
+	"Lawful Good", "Neutral Good", "Chaotic Good",
+	"Lawful Neutral", "Neutral", "Chaotic Neutral",
+	"Lawful Evil", "Neutral Evil", "Chaotic Evil",
+}
+
+// StandardArray 标准属性组
+var StandardArray = []int{15, 14, 13, 12, 10, 8}
+
+// PointBuyBudget 购点法总点数
+const PointBuyBudget = 27
+
+// pointBuyCost 购点法中 8-15 各属性值的累计花费
+var pointBuyCost = map[int]int{8: 0, 9: 1, 10: 2, 11: 3, 12: 4, 13: 5, 14: 7, 15: 9}
+
+// GenerateOptions 随机生成人物卡的参数，种族、职业、背景为空时随机选择
+type GenerateOptions struct {
+	Name       string
+	Method     string
+	Level      int
+	Race       string
+	Subrace    string
+	Class      string
+	Background string
+	Seed       int64
+	// Values 预先确定的六个属性值（如服务器掷骰记录），设置时忽略 Method
+	Values []int
+}
+
+// Generator 根据资料库条目随机生成人物卡，相同种子和条目得到相同结果
+type Generator struct {
+	Races       []compendium.Entry
+	Classes     []compendium.Entry
+	Backgrounds []compendium.Entry
+	Equipment   []compendium.Entry
+}
+
+// Generate 生成一张完整的人物卡（不含 ID 和 RoomID）
+func (g *Generator) Generate(opts GenerateOptions) (*CharacterCard, error) {
+	if opts.Method == "" {
+		opts.Method = MethodRoll
+	}
+	if opts.Level == 0 {
+		opts.Level = 1
+	}
+	if opts.Level < 1 || opts.Level > 20 {
+		return nil, fmt.Errorf("level must be between 1 and 20")
+	}
+
+	roller := dice.NewRoller(opts.Seed)
+
+	raceEntry, err := pickEntry(roller, g.Races, opts.Race, "race")
+	if err != nil {
+		return nil, err
+	}
+	classEntry, err := pickEntry(roller, g.Classes, opts.Class, "class")
+	if err != nil {
+		return nil, err
+	}
+	backgroundEntry, err := pickEntry(roller, g.Backgrounds, opts.Background, "background")
+	if err != nil {
+		return nil, err
+	}
+
+	var race compendium.RaceData
+	var class compendium.ClassData
+	var background compendium.BackgroundData
+	if err := raceEntry.Decode(&race); err != nil {
+		return nil, fmt.Errorf("invalid race data: %w", err)
+	}
+	if err := classEntry.Decode(&class); err != nil {
+		return nil, fmt.Errorf("invalid class data: %w", err)
+	}
+	if err := backgroundEntry.Decode(&background); err != nil {
+		return nil, fmt.Errorf("invalid background data: %w", err)
+	}
+	if class.HitDie == 0 {
+		return nil, fmt.Errorf("class %s has no hit die", classEntry.Slug)
+	}
+
+	raceName := raceEntry.Name
+	bonuses := copyBonuses(race.AbilityBonuses)
+	if len(race.Subraces) > 0 {
+		subrace, err := pickSubrace(roller, race.Subraces, opts.Subrace)
+		if err != nil {
+			return nil, err
+		}
+		raceName = subrace.Name
+		for ability, bonus := range subrace.AbilityBonuses {
+			bonuses[ability] += bonus
+		}
+	} else if opts.Subrace != "" {
+		return nil, fmt.Errorf("race %s has no subraces", raceEntry.Slug)
+	}
+
+	priority := abilityPriority(roller, class)
+
+	values := append([]int(nil), opts.Values...)
+	if len(values) == 0 {
+		values, err = generateAbilityValues(roller, opts.Method)
+		if err != nil {
+			return nil, err
+		}
+	} else if len(values) != len(Abilities) {
+		return nil, fmt.Errorf("expected %d ability values", len(Abilities))
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(values)))
+	scores := make(map[string]int, len(Abilities))
+	for i, ability := range priority {
+		scores[ability] = values[i]
+	}
+
+	// 半精灵等种族可自选属性加值，按职业优先级分配给尚未获得加值的属性
+	choices := race.AbilityBonusChoices
+	for _, ability := range priority {
+		if choices == 0 {
+			break
+		}
+		if bonuses[ability] == 0 {
+			bonuses[ability] = 1
+			choices--
+		}
+	}
+	for ability, bonus := range bonuses {
+		if bonus == 0 {
+			delete(bonuses, ability)
+			continue
+		}
+		scores[ability] = min(scores[ability]+bonus, 20)
+	}
+
+	card := &CharacterCard{
+		Name:         strings.TrimSpace(opts.Name),
+		Race:         raceName,
+		Class:        classEntry.Name,
+		Level:        opts.Level,
+		Background:   backgroundEntry.Name,
+		Alignment:    Alignments[roller.Intn(len(Alignments))],
+		Strength:     scores[AbilityStrength],
+		Dexterity:    scores[AbilityDexterity],
+		Constitution: scores[AbilityConstitution],
+		Intelligence: scores[AbilityIntelligence],
+		Wisdom:       scores[AbilityWisdom],
+		Charisma:     scores[AbilityCharisma],
+		Speed:        race.Speed,
+		Proficiency:  ProficiencyBonus(opts.Level),
+	}
+	if len(bonuses) > 0 {
+		card.AbilityBonuses = bonuses
+	}
+	if card.Name == "" {
+		card.Name = raceName + " " + classEntry.Name
+	}
+
+	// 1 级取生命骰最大值，之后每级取平均值（向上取整），每级至少 1 点
+	conMod := AbilityModifier(card.Constitution)
+	card.MaxHP = max(class.HitDie+conMod, 1)
+	for level := 2; level <= opts.Level; level++ {
+		card.MaxHP += max(class.HitDie/2+1+conMod, 1)
+	}
+	card.HP = card.MaxHP
+
+	saves := make([]string, 0, len(class.SavingThrows))
+	for _, ability := range class.SavingThrows {
+		saves = append(saves, abilityName(ability))
+	}
+	card.Saves = strings.Join(saves, ", ")
+
+	skills := pickSkills(roller, background.SkillProficiencies, race, class)
+	card.Skills = strings.Join(skills, ", ")
+
+	equipment := g.resolveEquipment(append(append([]string(nil), class.StartingEquipment...), background.Equipment...))
+	names := make([]string, 0, len(equipment))
+	for _, item := range equipment {
+		names = append(names, item.Name)
+	}
+	card.Equipment = strings.Join(names, ", ")
+	card.AC = ArmorClass(scores, class.UnarmoredDefense, equipment)
+
+	for _, entry := range []compendium.Entry{raceEntry, classEntry, backgroundEntry} {
+		card.References = append(card.References, refFromEntry(entry))
+	}
+	for _, item := range equipment {
+		if !card.HasReference(string(item.Type), item.Slug) {
+			card.References = append(card.References, refFromEntry(item))
+		}
+	}
+
+	return card, nil
+}
+
+// ProficiencyBonus 返回等级对应的熟练加值
+func ProficiencyBonus(level int) int {
+	if level < 1 {
+		level = 1
+	}
+	return 2 + (level-1)/4
+}
+
+// ArmorClass 根据穿戴的护甲和盾牌计算护甲等级
+// 未穿护甲时使用职业的无甲防御属性（如野蛮人的体质），盾牌额外加值
+func ArmorClass(scores map[string]int, unarmoredDefense string, equipment []compendium.Entry) int {
+	dexMod := AbilityModifier(scores[AbilityDexterity])
+	ac := 10 + dexMod
+	wearingArmor := false
+	shield := 0
+
+	for _, item := range equipment {
+		var data compendium.EquipmentData
+		if err := item.Decode(&data); err != nil || data.ArmorClass == nil {
+			continue
+		}
+
+		switch data.Category {
+		case compendium.CategoryShield:
+			shield = max(shield, data.ArmorClass.Base)
+		case compendium.CategoryLightArmor, compendium.CategoryMediumArmor, compendium.CategoryHeavyArmor:
+			value := data.ArmorClass.Base
+			if data.ArmorClass.DexBonus {
+				bonus := dexMod
+				if data.ArmorClass.MaxBonus > 0 {
+					bonus = min(bonus, data.ArmorClass.MaxBonus)
+				}
+				value += bonus
+			}
+			if !wearingArmor || value > ac {
+				ac = value
+			}
+			wearingArmor = true
+		}
+	}
+
+	if !wearingArmor && unarmoredDefense != "" {
+		ac = 10 + dexMod + AbilityModifier(scores[unarmoredDefense])
+		// 武僧的无甲防御不能与盾牌同时使用
+		if unarmoredDefense == AbilityWisdom {
+			shield = 0
+		}
+	}
+	return ac + shield
+}
+
+// generateAbilityValues 按生成方式得到六个属性值（未分配）
+func generateAbilityValues(roller *dice.Roller, method string) ([]int, error) {
+	switch method {
+	case MethodRoll:
+		formula := dice.Formula{Count: 4, Sides: 6, DropLowest: 1}
+		values := make([]int, len(Abilities))
+		for i := range values {
+			values[i], _ = roller.Roll(formula)
+		}
+		return values, nil
+	case MethodStandardArray:
+		return append([]int(nil), StandardArray...), nil
+	case MethodPointBuy:
+		return randomPointBuy(roller), nil
+	default:
+		return nil, fmt.Errorf("unknown ability method: %s", method)
+	}
+}
+
+// randomPointBuy 从 8 开始随机加点，直到 27 点用完或无法再加
+func randomPointBuy(roller *dice.Roller) []int {
+	values := []int{8, 8, 8, 8, 8, 8}
+	points := PointBuyBudget
+	for {
+		var candidates []int
+		for i, value := range values {
+			if value < 15 && pointBuyCost[value+1]-pointBuyCost[value] <= points {
+				candidates = append(candidates, i)
+			}
+		}
+		if len(candidates) == 0 {
+			return values
+		}
+		i := candidates[roller.Intn(len(candidates))]
+		points -= pointBuyCost[values[i]+1] - pointBuyCost[values[i]]
+		values[i]++
+	}
+}
+
+// PointBuyCost 返回一组属性值的购点花费，超出 8-15 范围时返回 false
+func PointBuyCost(values []int) (int, bool) {
+	total := 0
+	for _, value := range values {
+		cost, ok := pointBuyCost[value]
+		if !ok {
+			return 0, false
+		}
+		total += cost
+	}
+	return total, true
+}
+
+// abilityPriority 属性分配顺序：主属性、豁免属性、体质，其余随机
+func abilityPriority(roller *dice.Roller, class compendium.ClassData) []string {
+	var order []string
+	add := func(ability string) {
+		if ability == "" {
+			return
+		}
+		for _, existing := range order {
+			if existing == ability {
+				return
+			}
+		}
+		order = append(order, ability)
+	}
+
+	add(class.PrimaryAbility)
+	add(class.SpellcastingAbility)
+	add(AbilityConstitution)
+	for _, ability := range class.SavingThrows {
+		add(ability)
+	}
+
+	rest := make([]string, 0, len(Abilities))
+	for _, ability := range Abilities {
+		rest = append(rest, ability)
+	}
+	for i := len(rest) - 1; i > 0; i-- {
+		j := roller.Intn(i + 1)
+		rest[i], rest[j] = rest[j], rest[i]
+	}
+	for _, ability := range rest {
+		add(ability)
+	}
+	return order
+}
+
+// pickSkills 背景和种族技能固定获得，职业技能从可选列表中随机选择不重复的项
+func pickSkills(roller *dice.Roller, backgroundSkills []string, race compendium.RaceData, class compendium.ClassData) []string {
+	var skills []string
+	has := func(skill string) bool {
+		for _, existing := range skills {
+			if existing == skill {
+				return true
+			}
+		}
+		return false
+	}
+	choose := func(options []string, n int) {
+		var available []string
+		for _, option := range options {
+			if !has(option) {
+				available = append(available, option)
+			}
+		}
+		for ; n > 0 && len(available) > 0; n-- {
+			i := roller.Intn(len(available))
+			skills = append(skills, available[i])
+			available = append(available[:i], available[i+1:]...)
+		}
+	}
+
+	for _, skill := range append(append([]string(nil), backgroundSkills...), race.SkillProficiencies...) {
+		if !has(skill) {
+			skills = append(skills, skill)
+		}
+	}
+	choose(class.SkillChoices, class.NumSkills)
+	choose(AllSkills, race.SkillChoices)
+
+	sort.Strings(skills)
+	return skills
+}
+
+// resolveEquipment 将装备 slug 解析为资料库条目，同名时后出现的自制内容优先，找不到的条目跳过
+func (g *Generator) resolveEquipment(slugs []string) []compendium.Entry {
+	bySlug := make(map[string]compendium.Entry, len(g.Equipment))
+	for _, entry := range g.Equipment {
+		bySlug[entry.Slug] = entry
+	}
+
+	var items []compendium.Entry
+	seen := make(map[string]bool)
+	for _, slug := range slugs {
+		entry, ok := bySlug[slug]
+		if !ok || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		items = append(items, entry)
+	}
+	return items
+}
+
+func pickEntry(roller *dice.Roller, entries []compendium.Entry, slug string, kind string) (compendium.Entry, error) {
+	if slug != "" {
+		for _, entry := range entries {
+			if entry.Slug == slug {
+				return entry, nil
+			}
+		}
+		return compendium.Entry{}, fmt.Errorf("unknown %s: %s", kind, slug)
+	}
+	if len(entries) == 0 {
+		return compendium.Entry{}, fmt.Errorf("no %s available", kind)
+	}
+	return entries[roller.Intn(len(entries))], nil
+}
+
+func pickSubrace(roller *dice.Roller, subraces []compendium.SubraceData, slug string) (compendium.SubraceData, error) {
+	if slug == "" {
+		return subraces[roller.Intn(len(subraces))], nil
+	}
+	for _, subrace := range subraces {
+		if subrace.Slug == slug {
+			return subrace, nil
+		}
+	}
+	return compendium.SubraceData{}, fmt.Errorf("unknown subrace: %s", slug)
+}
+
+func copyBonuses(bonuses map[string]int) map[string]int {
+	result := make(map[string]int, len(bonuses))
+	for ability, bonus := range bonuses {
+		result[ability] = bonus
+	}
+	return result
+}
+
+func refFromEntry(entry compendium.Entry) CompendiumRef {
+	return CompendiumRef{
+		Type:   string(entry.Type),
+		Slug:   entry.Slug,
+		Name:   entry.Name,
+		Source: entry.Source,
+	}
+}
+
+// abilityName 将属性键转为首字母大写的显示名称
+func abilityName(ability string) string {
+	if ability == "" {
+		return ability
+	}
+	return strings.ToUpper(ability[:1]) + ability[1:]
+}
@@ -0,0 +1,96 @@
+package character
+
+import (
+	"testing"
+
+	"trpg-sync/backend/domain/compendium"
+	compendiumstore "trpg-sync/backend/infrastructure/compendium"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func srdGenerator() *Generator {
+	store := compendiumstore.Default()
+	return &Generator{
+		Races:       store.List(compendium.TypeRace),
+		Classes:     store.List(compendium.TypeClass),
+		Backgrounds: store.List(compendium.TypeBackground),
+		Equipment:   store.List(compendium.TypeEquipment),
+	}
+}
+
+func TestGenerator_Reproducible(t *testing.T) {
+	generator := srdGenerator()
+
+	first, err := generator.Generate(GenerateOptions{Seed: 42, Level: 5})
+	require.NoError(t, err)
+	second, err := generator.Generate(GenerateOptions{Seed: 42, Level: 5})
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, 3, first.Proficiency)
+	assert.Equal(t, first.MaxHP, first.HP)
+	assert.NotEmpty(t, first.Skills)
+	assert.NotEmpty(t, first.Equipment)
+}
+
+func TestGenerator_Constrained(t *testing.T) {
+	generator := srdGenerator()
+
+	card, err := generator.Generate(GenerateOptions{
+		Method:     MethodStandardArray,
+		Race:       "dwarf",
+		Subrace:    "hill-dwarf",
+		Class:      "fighter",
+		Background: "acolyte",
+		Seed:       1,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Hill Dwarf", card.Race)
+	assert.Equal(t, "Fighter", card.Class)
+	assert.Equal(t, "Hill Dwarf Fighter", card.Name)
+	// 主属性力量取 15，体质 14 + 2
+	assert.Equal(t, 15, card.Strength)
+	assert.Equal(t, 16, card.Constitution)
+	assert.Equal(t, 25, card.Speed)
+	// 战士 1 级：10 + 体质调整值 3
+	assert.Equal(t, 13, card.MaxHP)
+	// 链甲 16 + 盾牌 2
+	assert.Equal(t, 18, card.AC)
+	assert.Equal(t, "Strength, Constitution", card.Saves)
+	assert.Contains(t, card.Skills, "Insight")
+	assert.Contains(t, card.Skills, "Religion")
+	assert.Contains(t, card.Equipment, "Holy Symbol")
+	assert.True(t, card.HasReference(string(compendium.TypeClass), "fighter"))
+
+	_, err = generator.Generate(GenerateOptions{Class: "artificer"})
+	assert.Error(t, err)
+
+	_, err = generator.Generate(GenerateOptions{Method: "divine-gift"})
+	assert.Error(t, err)
+}
+
+func TestGenerator_PointBuy(t *testing.T) {
+	generator := srdGenerator()
+
+	for seed := int64(0); seed < 20; seed++ {
+		card, err := generator.Generate(GenerateOptions{Method: MethodPointBuy, Race: "human", Seed: seed})
+		require.NoError(t, err)
+
+		// 人类每项属性 +1，还原后应为合法的购点结果
+		base := []int{card.Strength - 1, card.Dexterity - 1, card.Constitution - 1, card.Intelligence - 1, card.Wisdom - 1, card.Charisma - 1}
+		cost, ok := PointBuyCost(base)
+		require.True(t, ok, "seed %d: %v", seed, base)
+		assert.LessOrEqual(t, cost, PointBuyBudget)
+	}
+}
+
+func TestArmorClass_UnarmoredDefense(t *testing.T) {
+	scores := map[string]int{AbilityDexterity: 14, AbilityConstitution: 16, AbilityWisdom: 16}
+
+	assert.Equal(t, 15, ArmorClass(scores, AbilityConstitution, nil))
+	assert.Equal(t, 15, ArmorClass(scores, AbilityWisdom, nil))
+	assert.Equal(t, 12, ArmorClass(scores, "", nil))
+}
@@ -0,0 +1,37 @@
+package character
+
+import (
+	"strings"
+	"time"
+)
+
+// IndexEntry 人物卡索引，人物卡保存或删除时同步更新，用于跨房间搜索而无需遍历文件
+type IndexEntry struct {
+	RoomID      uint      `json:"room_id" gorm:"primaryKey;autoIncrement:false"`
+	CharacterID uint      `json:"character_id" gorm:"primaryKey;autoIncrement:false"`
+	OwnerID     uint      `json:"owner_id" gorm:"not null;default:0;index"`
+	Name        string    `json:"name" gorm:"not null;index"`
+	Race        string    `json:"race" gorm:"index"`
+	Class       string    `json:"class" gorm:"index"`
+	Level       int       `json:"level" gorm:"index"`
+	Alignment   string    `json:"alignment"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (IndexEntry) TableName() string {
+	return "character_index"
+}
+
+// NewIndexEntry 从人物卡提取索引字段
+func NewIndexEntry(card *CharacterCard) IndexEntry {
+	return IndexEntry{
+		RoomID:      card.RoomID,
+		CharacterID: card.ID,
+		OwnerID:     card.OwnerID,
+		Name:        strings.TrimSpace(card.Name),
+		Race:        strings.TrimSpace(card.Race),
+		Class:       strings.TrimSpace(card.Class),
+		Level:       card.Level,
+		Alignment:   strings.TrimSpace(card.Alignment),
+	}
+}
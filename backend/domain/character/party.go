@@ -0,0 +1,161 @@
+package character
+
+import (
+	"fmt"
+	"strings"
+	"trpg-sync/backend/domain/validation"
+)
+
+// 资源恢复方式，为空表示需要手动恢复
+const (
+	RechargeShortRest = "short_rest"
+	RechargeLongRest  = "long_rest"
+)
+
+// Resource 可消耗的职业资源，如法术位、气、狂暴次数
+type Resource struct {
+	Name     string `json:"name"`
+	Current  int    `json:"current"`
+	Max      int    `json:"max"`
+	Recharge string `json:"recharge,omitempty"`
+}
+
+// PartyMember 队伍总览中的一名角色
+type PartyMember struct {
+	ID                  uint       `json:"id"`
+	Name                string     `json:"name"`
+	Race                string     `json:"race"`
+	Class               string     `json:"class"`
+	Level               int        `json:"level"`
+	HP                  int        `json:"hp"`
+	MaxHP               int        `json:"max_hp"`
+	AC                  int        `json:"ac"`
+	PassivePerception   int        `json:"passive_perception"`
+	SpellcastingAbility string     `json:"spellcasting_ability,omitempty"`
+	SpellSaveDC         *int       `json:"spell_save_dc"`
+	Conditions          []string   `json:"conditions"`
+	Resources           []Resource `json:"resources"`
+}
+
+// Party 房间的队伍总览
+type Party struct {
+	RoomID       uint          `json:"room_id"`
+	Members      []PartyMember `json:"members"`
+	AverageLevel float64       `json:"average_level"`
+	TotalHP      int           `json:"total_hp"`
+	TotalMaxHP   int           `json:"total_max_hp"`
+}
+
+// NewParty 汇总房间内的人物卡，spellcasting 为职业名称到施法属性的映射（非施法职业不在其中）
+func NewParty(roomID uint, cards []CharacterCard, spellcasting map[string]string) Party {
+	party := Party{RoomID: roomID, Members: make([]PartyMember, 0, len(cards))}
+	totalLevel := 0
+	for i := range cards {
+		member := NewPartyMember(&cards[i], spellcasting[cards[i].Class])
+		party.Members = append(party.Members, member)
+		party.TotalHP += member.HP
+		party.TotalMaxHP += member.MaxHP
+		totalLevel += member.Level
+	}
+	if len(cards) > 0 {
+		party.AverageLevel = float64(totalLevel) / float64(len(cards))
+	}
+	return party
+}
+
+// ActiveCards 过滤出仍在队伍中的人物卡，已归档（如玩家被踢出）的人物卡不计入队伍
+func ActiveCards(cards []CharacterCard) []CharacterCard {
+	active := make([]CharacterCard, 0, len(cards))
+	for _, card := range cards {
+		if !card.IsArchived() {
+			active = append(active, card)
+		}
+	}
+	return active
+}
+
+// NewPartyMember 计算单个角色的总览数据
+func NewPartyMember(card *CharacterCard, spellcastingAbility string) PartyMember {
+	member := PartyMember{
+		ID:                  card.ID,
+		Name:                card.Name,
+		Race:                card.Race,
+		Class:               card.Class,
+		Level:               card.Level,
+		HP:                  card.HP,
+		MaxHP:               card.MaxHP,
+		AC:                  card.AC,
+		PassivePerception:   card.PassivePerception(),
+		SpellcastingAbility: spellcastingAbility,
+		SpellSaveDC:         card.SpellSaveDC(spellcastingAbility),
+		Conditions:          append([]string{}, card.Conditions...),
+		Resources:           append([]Resource{}, card.Resources...),
+	}
+	return member
+}
+
+// ProficiencyModifier 人物卡上填写的熟练加值，未填写时按等级计算
+func (c *CharacterCard) ProficiencyModifier() int {
+	if c.Proficiency > 0 {
+		return c.Proficiency
+	}
+	return ProficiencyBonus(max(c.Level, MinLevel))
+}
+
+// HasSkill 判断技能熟练项（逗号分隔）中是否包含指定技能，忽略大小写
+func (c *CharacterCard) HasSkill(skill string) bool {
+	for _, item := range strings.Split(c.Skills, ",") {
+		if strings.EqualFold(strings.TrimSpace(item), skill) {
+			return true
+		}
+	}
+	return false
+}
+
+// PassivePerception 被动察觉：10 + 感知调整值，熟练察觉时再加熟练加值
+func (c *CharacterCard) PassivePerception() int {
+	value := 10 + AbilityModifier(c.Wisdom)
+	if c.HasSkill("Perception") {
+		value += c.ProficiencyModifier()
+	}
+	return value
+}
+
+// SpellSaveDC 法术豁免难度：8 + 熟练加值 + 施法属性调整值，非施法者返回 nil
+func (c *CharacterCard) SpellSaveDC(ability string) *int {
+	score, ok := c.Scores()[ability]
+	if !ok {
+		return nil
+	}
+	dc := 8 + c.ProficiencyModifier() + AbilityModifier(score)
+	return &dc
+}
+
+// validateTracking 校验状态和资源
+func (c *CharacterCard) validateTracking() []validation.FieldError {
+	var errs []validation.FieldError
+	for i, condition := range c.Conditions {
+		if strings.TrimSpace(condition) == "" {
+			errs = append(errs, validation.NewError(fmt.Sprintf("conditions[%d]", i), validation.CodeRequired, nil))
+		}
+	}
+	for i, resource := range c.Resources {
+		field := fmt.Sprintf("resources[%d]", i)
+		if strings.TrimSpace(resource.Name) == "" {
+			errs = append(errs, validation.NewError(field+".name", validation.CodeRequired, nil))
+		}
+		if resource.Max < 0 {
+			errs = append(errs, validation.NewError(field+".max", validation.CodeTooSmall, map[string]interface{}{"min": 0}))
+		} else if resource.Current < 0 || resource.Current > resource.Max {
+			errs = append(errs, validation.NewError(field+".current", validation.CodeOutOfRange, map[string]interface{}{"min": 0, "max": resource.Max}))
+		}
+		switch resource.Recharge {
+		case "", RechargeShortRest, RechargeLongRest:
+		default:
+			errs = append(errs, validation.NewError(field+".recharge", validation.CodeInvalidChoice, map[string]interface{}{
+				"choices": RechargeShortRest + ", " + RechargeLongRest,
+			}))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,62 @@
+package character
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewParty(t *testing.T) {
+	cleric := CharacterCard{
+		ID: 1, Name: "Mira", Class: "Cleric", Level: 5,
+		HP: 20, MaxHP: 38, AC: 18, Wisdom: 16,
+		Skills:     "Insight, perception",
+		Conditions: []string{"poisoned"},
+		Resources:  []Resource{{Name: "Spell slots (3rd)", Current: 1, Max: 2, Recharge: RechargeLongRest}},
+	}
+	fighter := CharacterCard{
+		ID: 2, Name: "Brakk", Class: "Fighter", Level: 3, Proficiency: 3,
+		HP: 28, MaxHP: 28, AC: 16, Wisdom: 9,
+	}
+
+	party := NewParty(7, []CharacterCard{cleric, fighter}, map[string]string{"Cleric": AbilityWisdom})
+
+	require.Len(t, party.Members, 2)
+	assert.Equal(t, uint(7), party.RoomID)
+	assert.Equal(t, 4.0, party.AverageLevel)
+	assert.Equal(t, 48, party.TotalHP)
+	assert.Equal(t, 66, party.TotalMaxHP)
+
+	mira := party.Members[0]
+	assert.Equal(t, 16, mira.PassivePerception)
+	require.NotNil(t, mira.SpellSaveDC)
+	assert.Equal(t, 14, *mira.SpellSaveDC)
+	assert.Equal(t, []string{"poisoned"}, mira.Conditions)
+	assert.Equal(t, 1, mira.Resources[0].Current)
+
+	brakk := party.Members[1]
+	assert.Equal(t, 9, brakk.PassivePerception)
+	assert.Nil(t, brakk.SpellSaveDC)
+	assert.Empty(t, brakk.Conditions)
+	assert.NotNil(t, brakk.Resources)
+
+	assert.Empty(t, NewParty(7, nil, nil).Members)
+}
+
+func TestCharacterCard_ValidateTracking(t *testing.T) {
+	card := CharacterCard{
+		Name: "Mira", Level: 1,
+		Conditions: []string{"prone", " "},
+		Resources: []Resource{
+			{Name: "Ki", Current: 3, Max: 2},
+			{Name: "", Current: 0, Max: 1, Recharge: "dawn"},
+		},
+	}
+
+	fields := make([]string, 0)
+	for _, err := range card.Validate() {
+		fields = append(fields, err.Field)
+	}
+	assert.Equal(t, []string{"conditions[1]", "resources[0].current", "resources[1].name", "resources[1].recharge"}, fields)
+}
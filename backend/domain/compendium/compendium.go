@@ -0,0 +1,158 @@
+package compendium
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// EntryType 资料条目类型，同时作为 API 路径中的 :type
+type EntryType string
+
+const (
+	TypeSpell      EntryType = "spells"
+	TypeEquipment  EntryType = "equipment"
+	TypeMagicItem  EntryType = "magic-items"
+	TypeRace       EntryType = "races"
+	TypeClass      EntryType = "classes"
+	TypeSubclass   EntryType = "subclasses"
+	TypeBackground EntryType = "backgrounds"
+	TypeCondition  EntryType = "conditions"
+	TypeMonster    EntryType = "monsters"
+)
+
+// SourceSRD 内置 SRD 5.1 数据的来源标识
+const SourceSRD = "SRD 5.1"
+
+// Types 返回所有支持的条目类型
+func Types() []EntryType {
+	return []EntryType{
+		TypeSpell,
+		TypeEquipment,
+		TypeMagicItem,
+		TypeRace,
+		TypeClass,
+		TypeSubclass,
+		TypeBackground,
+		TypeCondition,
+		TypeMonster,
+	}
+}
+
+// ParseType 校验并转换条目类型
+func ParseType(s string) (EntryType, bool) {
+	for _, t := range Types() {
+		if string(t) == s {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// Entry 资料库条目
+// Level/School 仅对法术有意义，Classes 用于法术和子职业，其余类型特有的属性放在 Data 中
+type Entry struct {
+	Type        EntryType       `json:"type"`
+	Slug        string          `json:"slug"`
+	Name        string          `json:"name"`
+	Source      string          `json:"source"`
+	Description string          `json:"description"`
+	Level       *int            `json:"level,omitempty"`
+	School      string          `json:"school,omitempty"`
+	Classes     []string        `json:"classes,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+}
+
+// Decode 将 Data 解析到指定结构
+func (e Entry) Decode(v interface{}) error {
+	if len(e.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.Data, v)
+}
+
+// Query 资料库搜索条件
+type Query struct {
+	Text   string
+	Level  *int
+	School string
+	Class  string
+}
+
+// Search 按条件过滤条目，并按相关度排序
+// 全文搜索要求每个关键词都出现在名称或描述中，名称命中的条目排在前面
+func Search(entries []Entry, q Query) []Entry {
+	terms := strings.Fields(strings.ToLower(q.Text))
+
+	type scored struct {
+		entry Entry
+		score int
+	}
+	var matched []scored
+
+	for _, e := range entries {
+		if q.Level != nil && (e.Level == nil || *e.Level != *q.Level) {
+			continue
+		}
+		if q.School != "" && !strings.EqualFold(e.School, q.School) {
+			continue
+		}
+		if q.Class != "" && !containsFold(e.Classes, q.Class) {
+			continue
+		}
+
+		score, ok := scoreEntry(e, terms)
+		if !ok {
+			continue
+		}
+		matched = append(matched, scored{entry: e, score: score})
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if matched[i].score != matched[j].score {
+			return matched[i].score > matched[j].score
+		}
+		return matched[i].entry.Name < matched[j].entry.Name
+	})
+
+	result := make([]Entry, 0, len(matched))
+	for _, m := range matched {
+		result = append(result, m.entry)
+	}
+	return result
+}
+
+// scoreEntry 计算条目与关键词的相关度，任一关键词未命中时返回 false
+func scoreEntry(e Entry, terms []string) (int, bool) {
+	if len(terms) == 0 {
+		return 0, true
+	}
+
+	name := strings.ToLower(e.Name)
+	desc := strings.ToLower(e.Description)
+
+	score := 0
+	if name == strings.Join(terms, " ") {
+		score += 100
+	}
+	for _, term := range terms {
+		switch {
+		case strings.Contains(name, term):
+			score += 10
+		case strings.Contains(desc, term):
+			score++
+		default:
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+func containsFold(values []string, target string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, target) {
+			return true
+		}
+	}
+	return false
+}
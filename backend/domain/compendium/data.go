@@ -0,0 +1,66 @@
+package compendium
+
+// 以下结构对应各类条目 Data 字段的内容，通过 Entry.Decode 解析
+
+// RaceData 种族数据
+type RaceData struct {
+	Size                string         `json:"size"`
+	Speed               int            `json:"speed"`
+	AbilityBonuses      map[string]int `json:"ability_bonuses"`
+	AbilityBonusChoices int            `json:"ability_bonus_choices"`
+	SkillProficiencies  []string       `json:"skill_proficiencies"`
+	SkillChoices        int            `json:"skill_choices"`
+	Languages           []string       `json:"languages"`
+	Traits              []string       `json:"traits"`
+	Subraces            []SubraceData  `json:"subraces"`
+}
+
+// SubraceData 亚种数据
+type SubraceData struct {
+	Slug           string         `json:"slug"`
+	Name           string         `json:"name"`
+	AbilityBonuses map[string]int `json:"ability_bonuses"`
+	Traits         []string       `json:"traits"`
+}
+
+// ClassData 职业数据，StartingEquipment 为装备条目的 slug
+type ClassData struct {
+	HitDie              int      `json:"hit_die"`
+	PrimaryAbility      string   `json:"primary_ability"`
+	SavingThrows        []string `json:"saving_throws"`
+	SkillChoices        []string `json:"skill_choices"`
+	NumSkills           int      `json:"num_skills"`
+	SpellcastingAbility string   `json:"spellcasting_ability"`
+	UnarmoredDefense    string   `json:"unarmored_defense"`
+	StartingEquipment   []string `json:"starting_equipment"`
+}
+
+// BackgroundData 背景数据，Equipment 为装备条目的 slug
+type BackgroundData struct {
+	SkillProficiencies []string `json:"skill_proficiencies"`
+	Equipment          []string `json:"equipment"`
+	Feature            string   `json:"feature"`
+}
+
+// 装备类别
+const (
+	CategoryLightArmor  = "light-armor"
+	CategoryMediumArmor = "medium-armor"
+	CategoryHeavyArmor  = "heavy-armor"
+	CategoryShield      = "shield"
+)
+
+// EquipmentData 装备数据，护甲和盾牌带有 ArmorClass
+type EquipmentData struct {
+	Category   string     `json:"category"`
+	Cost       string     `json:"cost"`
+	Weight     float64    `json:"weight"`
+	ArmorClass *ArmorData `json:"armor_class"`
+}
+
+// ArmorData 护甲等级，MaxBonus 为 0 表示敏捷加值无上限
+type ArmorData struct {
+	Base     int  `json:"base"`
+	DexBonus bool `json:"dex_bonus"`
+	MaxBonus int  `json:"max_bonus"`
+}
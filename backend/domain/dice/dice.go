@@ -0,0 +1,139 @@
+package dice
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formula 形如 "2d8+4" 的骰子公式，DropLowest 表示去掉最低的几个骰子（如 "4d6dl1"）
+type Formula struct {
+	Count      int
+	Sides      int
+	Modifier   int
+	DropLowest int
+}
+
+var formulaPattern = regexp.MustCompile(`^(\d*)d(\d+)(?:(dl|kh)(\d+))?\s*(?:([+-])\s*(\d+))?$`)
+
+// ParseFormula 解析骰子公式，也接受纯数字（如 "7"）
+// 支持 dlN（去掉最低 N 个）和 khN（保留最高 N 个），如 "4d6dl1" 与 "4d6kh3" 等价
+func ParseFormula(s string) (Formula, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if n, err := strconv.Atoi(s); err == nil {
+		return Formula{Modifier: n}, nil
+	}
+
+	m := formulaPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Formula{}, fmt.Errorf("invalid dice formula: %q", s)
+	}
+
+	f := Formula{Count: 1}
+	if m[1] != "" {
+		f.Count, _ = strconv.Atoi(m[1])
+	}
+	f.Sides, _ = strconv.Atoi(m[2])
+	if m[6] != "" {
+		f.Modifier, _ = strconv.Atoi(m[6])
+		if m[5] == "-" {
+			f.Modifier = -f.Modifier
+		}
+	}
+
+	if f.Count < 1 || f.Count > 100 || f.Sides < 1 || f.Sides > 1000 {
+		return Formula{}, fmt.Errorf("dice formula out of range: %q", s)
+	}
+
+	if m[3] != "" {
+		n, _ := strconv.Atoi(m[4])
+		if m[3] == "kh" {
+			n = f.Count - n
+		}
+		if n < 0 || n >= f.Count {
+			return Formula{}, fmt.Errorf("dice formula out of range: %q", s)
+		}
+		f.DropLowest = n
+	}
+	return f, nil
+}
+
+// Average 返回公式的平均值（向下取整），与怪物图鉴中的固定 HP 一致
+// 去掉最低骰时按保留的骰子数估算，结果偏低
+func (f Formula) Average() int {
+	return (f.Count-f.DropLowest)*(f.Sides+1)/2 + f.Modifier
+}
+
+// String 返回公式的标准写法
+func (f Formula) String() string {
+	if f.Count == 0 {
+		return strconv.Itoa(f.Modifier)
+	}
+
+	dice := fmt.Sprintf("%dd%d", f.Count, f.Sides)
+	if f.DropLowest > 0 {
+		dice += fmt.Sprintf("dl%d", f.DropLowest)
+	}
+	switch {
+	case f.Modifier > 0:
+		return fmt.Sprintf("%s+%d", dice, f.Modifier)
+	case f.Modifier < 0:
+		return fmt.Sprintf("%s%d", dice, f.Modifier)
+	default:
+		return dice
+	}
+}
+
+// Roller 掷骰器，使用相同种子时结果可复现
+type Roller struct {
+	rng *rand.Rand
+}
+
+// NewRoller 使用指定种子创建掷骰器
+func NewRoller(seed int64) *Roller {
+	return &Roller{rng: rand.New(rand.NewSource(seed))}
+}
+
+// Die 掷一个 sides 面骰
+func (r *Roller) Die(sides int) int {
+	return r.rng.Intn(sides) + 1
+}
+
+// Intn 返回 [0, n) 内的随机整数，用于按同一种子做随机选择
+func (r *Roller) Intn(n int) int {
+	return r.rng.Intn(n)
+}
+
+// Roll 按公式掷骰，返回总值和每个骰子的结果（包含被去掉的骰子）
+func (r *Roller) Roll(f Formula) (int, []int) {
+	rolls := make([]int, f.Count)
+	for i := range rolls {
+		rolls[i] = r.Die(f.Sides)
+	}
+
+	kept := append([]int(nil), rolls...)
+	sort.Ints(kept)
+
+	total := f.Modifier
+	for _, roll := range kept[f.DropLowest:] {
+		total += roll
+	}
+	return total, rolls
+}
+
+// RollResult 一次公开掷骰的结果，Rolls 为每个骰子的点数（包含被去掉的骰子）
+type RollResult struct {
+	Formula       string    `json:"formula"`
+	Label         string    `json:"label,omitempty"`
+	CharacterID   uint      `json:"character_id,omitempty"`
+	CharacterName string    `json:"character_name,omitempty"`
+	Total         int       `json:"total"`
+	Rolls         []int     `json:"rolls"`
+	Seed          int64     `json:"seed"`
+	RolledAt      time.Time `json:"rolled_at"`
+}
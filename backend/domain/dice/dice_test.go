@@ -0,0 +1,75 @@
+package dice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormula(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    string
+		average     int
+		expectError bool
+	}{
+		{input: "2d6", expected: "2d6", average: 7},
+		{input: "7d10+21", expected: "7d10+21", average: 59},
+		{input: "2d6 - 2", expected: "2d6-2", average: 5},
+		{input: "d20", expected: "1d20", average: 10},
+		{input: "12", expected: "12", average: 12},
+		{input: "4d6dl1", expected: "4d6dl1", average: 10},
+		{input: "4d6kh3", expected: "4d6dl1", average: 10},
+		{input: "0d6", expectError: true},
+		{input: "4d6dl4", expectError: true},
+		{input: "2x6", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			f, err := ParseFormula(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, f.String())
+			assert.Equal(t, tt.average, f.Average())
+		})
+	}
+}
+
+func TestRoller_Reproducible(t *testing.T) {
+	f, err := ParseFormula("4d6")
+	require.NoError(t, err)
+
+	total1, rolls1 := NewRoller(7).Roll(f)
+	total2, rolls2 := NewRoller(7).Roll(f)
+
+	assert.Equal(t, total1, total2)
+	assert.Equal(t, rolls1, rolls2)
+	for _, r := range rolls1 {
+		assert.True(t, r >= 1 && r <= 6)
+	}
+}
+
+func TestRoller_DropLowest(t *testing.T) {
+	f, err := ParseFormula("4d6dl1")
+	require.NoError(t, err)
+
+	roller := NewRoller(11)
+	for i := 0; i < 20; i++ {
+		total, rolls := roller.Roll(f)
+		require.Len(t, rolls, 4)
+
+		sum, lowest := 0, rolls[0]
+		for _, r := range rolls {
+			sum += r
+			if r < lowest {
+				lowest = r
+			}
+		}
+		assert.Equal(t, sum-lowest, total)
+	}
+}
@@ -0,0 +1,215 @@
+package encounter
+
+import (
+	"fmt"
+	"math"
+	"trpg-sync/backend/domain/monster"
+)
+
+// 难度计算模式
+const (
+	// ModeDMG 城主指南：按怪物数量乘以系数得到调整后经验值，与队伍阈值比较
+	ModeDMG = "dmg"
+	// ModeBudget 每角色预算（Xanathar 风格）：每个角色按自身等级提供预算，
+	// 不使用怪物数量系数，直接用经验值总和与队伍预算比较
+	ModeBudget = "budget"
+)
+
+// 难度评级
+const (
+	RatingTrivial = "trivial"
+	RatingEasy    = "easy"
+	RatingMedium  = "medium"
+	RatingHard    = "hard"
+	RatingDeadly  = "deadly"
+)
+
+// Thresholds 简单/中等/困难/致命的经验值阈值
+type Thresholds struct {
+	Easy   int `json:"easy"`
+	Medium int `json:"medium"`
+	Hard   int `json:"hard"`
+	Deadly int `json:"deadly"`
+}
+
+func (t Thresholds) add(o Thresholds) Thresholds {
+	return Thresholds{
+		Easy:   t.Easy + o.Easy,
+		Medium: t.Medium + o.Medium,
+		Hard:   t.Hard + o.Hard,
+		Deadly: t.Deadly + o.Deadly,
+	}
+}
+
+// Rate 返回经验值对应的难度评级
+func (t Thresholds) Rate(xp int) string {
+	switch {
+	case xp >= t.Deadly:
+		return RatingDeadly
+	case xp >= t.Hard:
+		return RatingHard
+	case xp >= t.Medium:
+		return RatingMedium
+	case xp >= t.Easy:
+		return RatingEasy
+	default:
+		return RatingTrivial
+	}
+}
+
+// levelThresholds 每个角色等级的经验值阈值（1-20 级）
+var levelThresholds = [20]Thresholds{
+	{25, 50, 75, 100},
+	{50, 100, 150, 200},
+	{75, 150, 225, 400},
+	{125, 250, 375, 500},
+	{250, 500, 750, 1100},
+	{300, 600, 900, 1400},
+	{350, 750, 1100, 1700},
+	{450, 900, 1400, 2100},
+	{550, 1100, 1600, 2400},
+	{600, 1200, 1900, 2800},
+	{800, 1600, 2400, 3600},
+	{1000, 2000, 3000, 4500},
+	{1100, 2200, 3400, 5100},
+	{1250, 2500, 3800, 5700},
+	{1400, 2800, 4300, 6400},
+	{1600, 3200, 4800, 7200},
+	{2000, 3900, 5900, 8800},
+	{2100, 4200, 6300, 9500},
+	{2400, 4900, 7300, 10900},
+	{2800, 5700, 8500, 12700},
+}
+
+// ThresholdsForLevel 返回单个角色的经验值阈值，等级超出范围时取边界值
+func ThresholdsForLevel(level int) Thresholds {
+	if level < 1 {
+		level = 1
+	}
+	if level > 20 {
+		level = 20
+	}
+	return levelThresholds[level-1]
+}
+
+// multipliers 怪物数量系数，两端的 0.5 和 5 只在按队伍人数调整时使用
+var multipliers = []float64{0.5, 1, 1.5, 2, 2.5, 3, 4, 5}
+
+// Multiplier 返回怪物数量对应的经验值系数
+// 队伍少于 3 人时使用更高一档的系数，6 人及以上时使用更低一档的系数
+func Multiplier(monsterCount, partySize int) float64 {
+	if monsterCount <= 0 {
+		return 0
+	}
+
+	var index int
+	switch {
+	case monsterCount == 1:
+		index = 1
+	case monsterCount == 2:
+		index = 2
+	case monsterCount <= 6:
+		index = 3
+	case monsterCount <= 10:
+		index = 4
+	case monsterCount <= 14:
+		index = 5
+	default:
+		index = 6
+	}
+
+	switch {
+	case partySize > 0 && partySize < 3:
+		index++
+	case partySize >= 6:
+		index--
+	}
+	return multipliers[index]
+}
+
+// MonsterGroup 同一挑战等级的一组怪物
+type MonsterGroup struct {
+	Name  string `json:"name,omitempty"`
+	CR    string `json:"cr"`
+	Count int    `json:"count"`
+}
+
+// CharacterBudget 每角色预算模式下单个角色的预算
+type CharacterBudget struct {
+	Level      int        `json:"level"`
+	Thresholds Thresholds `json:"thresholds"`
+	ShareXP    int        `json:"share_xp"`
+	Rating     string     `json:"rating"`
+}
+
+// Difficulty 遭遇难度计算结果
+type Difficulty struct {
+	Mode         string            `json:"mode"`
+	PartySize    int               `json:"party_size"`
+	PartyLevels  []int             `json:"party_levels"`
+	Thresholds   Thresholds        `json:"thresholds"`
+	MonsterCount int               `json:"monster_count"`
+	TotalXP      int               `json:"total_xp"`
+	Multiplier   float64           `json:"multiplier"`
+	AdjustedXP   int               `json:"adjusted_xp"`
+	Rating       string            `json:"rating"`
+	PerCharacter []CharacterBudget `json:"per_character,omitempty"`
+}
+
+// Evaluate 根据队伍等级和怪物列表计算遭遇难度
+func Evaluate(levels []int, groups []MonsterGroup, mode string) (*Difficulty, error) {
+	if mode == "" {
+		mode = ModeDMG
+	}
+	if mode != ModeDMG && mode != ModeBudget {
+		return nil, fmt.Errorf("unknown difficulty mode: %s", mode)
+	}
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("party has no characters")
+	}
+
+	result := &Difficulty{
+		Mode:        mode,
+		PartySize:   len(levels),
+		PartyLevels: levels,
+	}
+
+	for _, group := range groups {
+		if group.Count < 1 {
+			return nil, fmt.Errorf("invalid monster count: %d", group.Count)
+		}
+		xp, ok := monster.ChallengeRatingXP(group.CR)
+		if !ok {
+			return nil, fmt.Errorf("invalid challenge rating: %s", group.CR)
+		}
+		result.MonsterCount += group.Count
+		result.TotalXP += xp * group.Count
+	}
+
+	for _, level := range levels {
+		result.Thresholds = result.Thresholds.add(ThresholdsForLevel(level))
+	}
+
+	switch mode {
+	case ModeDMG:
+		result.Multiplier = Multiplier(result.MonsterCount, result.PartySize)
+		result.AdjustedXP = int(math.Round(float64(result.TotalXP) * result.Multiplier))
+	case ModeBudget:
+		result.Multiplier = 1
+		result.AdjustedXP = result.TotalXP
+
+		share := result.TotalXP / result.PartySize
+		for _, level := range levels {
+			thresholds := ThresholdsForLevel(level)
+			result.PerCharacter = append(result.PerCharacter, CharacterBudget{
+				Level:      level,
+				Thresholds: thresholds,
+				ShareXP:    share,
+				Rating:     thresholds.Rate(share),
+			})
+		}
+	}
+
+	result.Rating = result.Thresholds.Rate(result.AdjustedXP)
+	return result, nil
+}
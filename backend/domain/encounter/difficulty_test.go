@@ -0,0 +1,63 @@
+package encounter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiplier(t *testing.T) {
+	tests := []struct {
+		monsters  int
+		partySize int
+		expected  float64
+	}{
+		{monsters: 0, partySize: 4, expected: 0},
+		{monsters: 1, partySize: 4, expected: 1},
+		{monsters: 2, partySize: 4, expected: 1.5},
+		{monsters: 6, partySize: 4, expected: 2},
+		{monsters: 7, partySize: 4, expected: 2.5},
+		{monsters: 15, partySize: 4, expected: 4},
+		{monsters: 1, partySize: 2, expected: 1.5},
+		{monsters: 15, partySize: 2, expected: 5},
+		{monsters: 1, partySize: 6, expected: 0.5},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Multiplier(tt.monsters, tt.partySize), "monsters=%d party=%d", tt.monsters, tt.partySize)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	// 4 名 3 级角色对抗 4 只哥布林：200 XP × 2 = 400，介于困难 900 以下、中等 600 以下、简单 300 以上
+	result, err := Evaluate([]int{3, 3, 3, 3}, []MonsterGroup{{CR: "1/4", Count: 4}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, ModeDMG, result.Mode)
+	assert.Equal(t, Thresholds{Easy: 300, Medium: 600, Hard: 900, Deadly: 1600}, result.Thresholds)
+	assert.Equal(t, 200, result.TotalXP)
+	assert.Equal(t, 2.0, result.Multiplier)
+	assert.Equal(t, 400, result.AdjustedXP)
+	assert.Equal(t, RatingEasy, result.Rating)
+
+	result, err = Evaluate([]int{1, 1}, []MonsterGroup{{CR: "2", Count: 1}}, ModeDMG)
+	require.NoError(t, err)
+	assert.Equal(t, 675, result.AdjustedXP)
+	assert.Equal(t, RatingDeadly, result.Rating)
+
+	result, err = Evaluate([]int{3, 3, 3, 3}, []MonsterGroup{{CR: "1/4", Count: 4}}, ModeBudget)
+	require.NoError(t, err)
+	assert.Equal(t, 200, result.AdjustedXP)
+	assert.Equal(t, RatingTrivial, result.Rating)
+	require.Len(t, result.PerCharacter, 4)
+	assert.Equal(t, 50, result.PerCharacter[0].ShareXP)
+
+	_, err = Evaluate(nil, []MonsterGroup{{CR: "1", Count: 1}}, "")
+	assert.Error(t, err)
+
+	_, err = Evaluate([]int{1}, []MonsterGroup{{CR: "1/3", Count: 1}}, "")
+	assert.Error(t, err)
+
+	_, err = Evaluate([]int{1}, nil, "gut-feeling")
+	assert.Error(t, err)
+}
@@ -0,0 +1,129 @@
+package encounter
+
+import (
+	"sort"
+	"time"
+)
+
+// 遭遇状态
+const (
+	StatusPlanned  = "planned"
+	StatusActive   = "active"
+	StatusFinished = "finished"
+)
+
+// Encounter 房间内的一场遭遇，Round 为当前轮次（0 表示尚未开始），TurnCombatantID 为当前行动的实例
+type Encounter struct {
+	ID              uint        `json:"id" gorm:"primaryKey"`
+	RoomID          uint        `json:"room_id" gorm:"not null;index"`
+	Name            string      `json:"name" gorm:"not null"`
+	Description     string      `json:"description"`
+	Status          string      `json:"status" gorm:"not null;default:'planned'"`
+	Round           int         `json:"round" gorm:"not null;default:0"`
+	TurnCombatantID *uint       `json:"turn_combatant_id"`
+	Combatants      []Combatant `json:"combatants" gorm:"foreignKey:EncounterID;constraint:OnDelete:CASCADE"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+}
+
+func (Encounter) TableName() string {
+	return "encounters"
+}
+
+// Combatant 由数据卡生成的编号实例（如 Goblin 1），拥有独立的生命值
+type Combatant struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	EncounterID uint   `json:"encounter_id" gorm:"not null;index"`
+	StatBlockID uint   `json:"stat_block_id" gorm:"not null"`
+	Name        string `json:"name" gorm:"not null"`
+	// ChallengeRating 生成时数据卡的挑战等级，数据卡删除后仍可用于计算遭遇难度
+	ChallengeRating string    `json:"challenge_rating"`
+	Number          int       `json:"number"`
+	HP              int       `json:"hp"`
+	MaxHP           int       `json:"max_hp"`
+	TempHP          int       `json:"temp_hp"`
+	AC              int       `json:"ac"`
+	Initiative      int       `json:"initiative"`
+	Conditions      []string  `json:"conditions" gorm:"serializer:json"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (Combatant) TableName() string {
+	return "encounter_combatants"
+}
+
+// IsValidStatus 判断遭遇状态是否合法
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusPlanned, StatusActive, StatusFinished:
+		return true
+	}
+	return false
+}
+
+// TurnOrder 按先攻从高到低排列实例，先攻相同时按 ID 升序
+func (e *Encounter) TurnOrder() []Combatant {
+	order := append([]Combatant(nil), e.Combatants...)
+	sort.SliceStable(order, func(i, j int) bool {
+		return before(order[i], order[j])
+	})
+	return order
+}
+
+// NextTurn 推进到下一个行动的实例，生命值为 0 的实例跳过
+// 遭遇尚未开始时从第 1 轮的首位开始；回到队首或当前实例已被删除时轮次加一
+// 没有可行动的实例时返回 false
+func (e *Encounter) NextTurn() (*Combatant, bool) {
+	var order []Combatant
+	for _, combatant := range e.TurnOrder() {
+		if combatant.HP > 0 {
+			order = append(order, combatant)
+		}
+	}
+	if len(order) == 0 {
+		return nil, false
+	}
+
+	next := -1
+	if e.Round == 0 {
+		next = 0
+		e.Round = 1
+	} else if current := e.currentCombatant(); current != nil {
+		for i := range order {
+			if before(*current, order[i]) {
+				next = i
+				break
+			}
+		}
+	}
+	if next < 0 {
+		next = 0
+		e.Round++
+	}
+
+	e.TurnCombatantID = &order[next].ID
+	e.Status = StatusActive
+	return &order[next], true
+}
+
+// currentCombatant 当前行动的实例，未开始或已被删除时返回 nil
+func (e *Encounter) currentCombatant() *Combatant {
+	if e.TurnCombatantID == nil {
+		return nil
+	}
+	for i := range e.Combatants {
+		if e.Combatants[i].ID == *e.TurnCombatantID {
+			return &e.Combatants[i]
+		}
+	}
+	return nil
+}
+
+// before 判断 a 在先攻顺序中是否排在 b 之前
+func before(a, b Combatant) bool {
+	if a.Initiative != b.Initiative {
+		return a.Initiative > b.Initiative
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,39 @@
+package encounter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncounter_NextTurn(t *testing.T) {
+	e := &Encounter{Status: StatusPlanned, Combatants: []Combatant{
+		{ID: 1, Name: "Goblin 1", HP: 7, Initiative: 12},
+		{ID: 2, Name: "Goblin 2", HP: 0, Initiative: 18},
+		{ID: 3, Name: "Wolf 1", HP: 11, Initiative: 15},
+		{ID: 4, Name: "Goblin 3", HP: 7, Initiative: 12},
+	}}
+
+	var names []string
+	var rounds []int
+	for i := 0; i < 4; i++ {
+		current, ok := e.NextTurn()
+		require.True(t, ok)
+		names = append(names, current.Name)
+		rounds = append(rounds, e.Round)
+	}
+	assert.Equal(t, []string{"Wolf 1", "Goblin 1", "Goblin 3", "Wolf 1"}, names)
+	assert.Equal(t, []int{1, 1, 1, 2}, rounds)
+	assert.Equal(t, StatusActive, e.Status)
+
+	// 当前实例被删除后进入下一轮
+	e.Combatants = e.Combatants[:2]
+	current, ok := e.NextTurn()
+	require.True(t, ok)
+	assert.Equal(t, "Goblin 1", current.Name)
+	assert.Equal(t, 3, e.Round)
+
+	_, ok = (&Encounter{Combatants: []Combatant{{ID: 1, HP: 0}}}).NextTurn()
+	assert.False(t, ok)
+}
@@ -0,0 +1,89 @@
+package event
+
+import (
+	"log"
+	"sync"
+)
+
+// Event 领域事件，RoomID 为事件所属房间，全局自制内容等不属于任何房间的事件返回 0
+type Event interface {
+	Name() string
+	RoomID() uint
+}
+
+// Meta 事件的操作上下文，由接口层在发布时提供；ActorID 为执行操作的用户（匿名为 0），IP 为客户端地址
+type Meta struct {
+	ActorID uint
+	IP      string
+}
+
+// Handler 事件订阅者
+type Handler func(Event)
+
+// MetaHandler 需要操作上下文的订阅者，如审计日志
+type MetaHandler func(Meta, Event)
+
+// Bus 进程内事件总线，订阅者在服务启动时注册（见 main.go）
+// 事件在发布者的 goroutine 中按注册顺序同步分发，订阅者应尽快返回，耗时工作自行转到后台
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []MetaHandler
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// SubscribeAll 订阅全部事件
+func (b *Bus) SubscribeAll(handler Handler) {
+	b.SubscribeWithMeta(func(_ Meta, e Event) {
+		handler(e)
+	})
+}
+
+// SubscribeWithMeta 订阅全部事件，同时接收操作上下文
+func (b *Bus) SubscribeWithMeta(handler MetaHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Subscribe 只订阅类型为 T 的事件，如 event.Subscribe(bus, func(e event.CharacterUpdated) {...})
+func Subscribe[T Event](b *Bus, handler func(T)) {
+	b.SubscribeAll(func(e Event) {
+		if typed, ok := e.(T); ok {
+			handler(typed)
+		}
+	})
+}
+
+// Publish 分发没有操作上下文的事件（如后台任务产生的事件）
+func (b *Bus) Publish(e Event) {
+	b.PublishFrom(Meta{}, e)
+}
+
+// PublishFrom 分发事件，Bus 为 nil 时不做任何事
+// 订阅者 panic 时只记录日志，不影响其它订阅者和发布方
+func (b *Bus) PublishFrom(meta Meta, e Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		dispatch(handler, meta, e)
+	}
+}
+
+func dispatch(handler MetaHandler, meta Meta, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("event subscriber panic on %s: %v", e.Name(), r)
+		}
+	}()
+	handler(meta, e)
+}
@@ -0,0 +1,61 @@
+package event
+
+import (
+	"testing"
+
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/room"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus_Publish(t *testing.T) {
+	bus := NewBus()
+
+	var all []string
+	bus.SubscribeAll(func(e Event) {
+		all = append(all, e.Name())
+	})
+	bus.SubscribeAll(func(e Event) {
+		panic("broken subscriber")
+	})
+
+	var updated []CharacterUpdated
+	Subscribe(bus, func(e CharacterUpdated) {
+		updated = append(updated, e)
+	})
+
+	bus.Publish(RoomCreated{Room: room.Room{ID: 3}})
+	bus.Publish(CharacterUpdated{
+		Before: character.CharacterCard{RoomID: 3, Name: "Old"},
+		After:  character.CharacterCard{RoomID: 3, Name: "New"},
+	})
+
+	assert.Equal(t, []string{"room.created", "character.updated"}, all)
+	if assert.Len(t, updated, 1) {
+		assert.Equal(t, "Old", updated[0].Before.Name)
+		assert.Equal(t, uint(3), updated[0].RoomID())
+	}
+
+	var nilBus *Bus
+	nilBus.Publish(RoomCreated{})
+}
+
+func TestBus_PublishFrom(t *testing.T) {
+	bus := NewBus()
+
+	var metas []Meta
+	bus.SubscribeWithMeta(func(meta Meta, e Event) {
+		metas = append(metas, meta)
+	})
+	var plain int
+	bus.SubscribeAll(func(e Event) {
+		plain++
+	})
+
+	bus.PublishFrom(Meta{ActorID: 7, IP: "10.0.0.1"}, RoomDeleted{Room: room.Room{ID: 3}})
+	bus.Publish(RoomCreated{Room: room.Room{ID: 4}})
+
+	assert.Equal(t, []Meta{{ActorID: 7, IP: "10.0.0.1"}, {}}, metas)
+	assert.Equal(t, 2, plain)
+}
@@ -0,0 +1,409 @@
+package event
+
+import (
+	"trpg-sync/backend/domain/campaign"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/dice"
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/homebrew"
+	"trpg-sync/backend/domain/monster"
+	"trpg-sync/backend/domain/room"
+)
+
+// 以下为各类数据变更的领域事件，更新类事件同时携带修改前后的数据
+
+// RoomCreated 新建房间（包括复制房间和由模板创建）
+type RoomCreated struct {
+	Room room.Room
+}
+
+func (RoomCreated) Name() string {
+	return "room.created"
+}
+
+func (e RoomCreated) RoomID() uint {
+	return e.Room.ID
+}
+
+// RoomUpdated 修改房间信息、属性生成方式或归档状态
+type RoomUpdated struct {
+	Before room.Room
+	After  room.Room
+}
+
+func (RoomUpdated) Name() string {
+	return "room.updated"
+}
+
+func (e RoomUpdated) RoomID() uint {
+	return e.After.ID
+}
+
+// RoomDeleted 删除房间
+type RoomDeleted struct {
+	Room room.Room
+}
+
+func (RoomDeleted) Name() string {
+	return "room.deleted"
+}
+
+func (e RoomDeleted) RoomID() uint {
+	return e.Room.ID
+}
+
+// MemberJoined 用户通过邀请码加入房间
+type MemberJoined struct {
+	Member room.Member
+}
+
+func (MemberJoined) Name() string {
+	return "member.joined"
+}
+
+func (e MemberJoined) RoomID() uint {
+	return e.Member.RoomID
+}
+
+// MemberLeft 成员主动退出房间
+type MemberLeft struct {
+	Member room.Member
+}
+
+func (MemberLeft) Name() string {
+	return "member.left"
+}
+
+func (e MemberLeft) RoomID() uint {
+	return e.Member.RoomID
+}
+
+// MemberKicked DM 将成员踢出房间，KickedBy 为操作的 DM 用户 ID
+// Cards 为其人物卡的处理方式（room.KickCardsArchive 或 room.KickCardsTransfer），Characters 为受影响的人物卡 ID
+type MemberKicked struct {
+	Member     room.Member
+	KickedBy   uint
+	Cards      string
+	Characters []uint
+}
+
+func (MemberKicked) Name() string {
+	return "member.kicked"
+}
+
+func (e MemberKicked) RoomID() uint {
+	return e.Member.RoomID
+}
+
+// DMTransferRequested DM 发起转让，等待新 DM 确认
+type DMTransferRequested struct {
+	Transfer room.Transfer
+}
+
+func (DMTransferRequested) Name() string {
+	return "room.transfer_requested"
+}
+
+func (e DMTransferRequested) RoomID() uint {
+	return e.Transfer.RoomID
+}
+
+// DMTransferred 新 DM 接受转让，原 DM 成为玩家
+type DMTransferred struct {
+	Transfer room.Transfer
+}
+
+func (DMTransferred) Name() string {
+	return "room.dm_transferred"
+}
+
+func (e DMTransferred) RoomID() uint {
+	return e.Transfer.RoomID
+}
+
+// DMTransferCancelled DM 取消或新 DM 拒绝转让，CancelledBy 为操作的用户 ID
+type DMTransferCancelled struct {
+	Transfer    room.Transfer
+	CancelledBy uint
+}
+
+func (DMTransferCancelled) Name() string {
+	return "room.transfer_cancelled"
+}
+
+func (e DMTransferCancelled) RoomID() uint {
+	return e.Transfer.RoomID
+}
+
+// CharacterCreated 创建或随机生成人物卡
+type CharacterCreated struct {
+	Card character.CharacterCard
+}
+
+func (CharacterCreated) Name() string {
+	return "character.created"
+}
+
+func (e CharacterCreated) RoomID() uint {
+	return e.Card.RoomID
+}
+
+// CharacterUpdated 修改人物卡，包括掷属性骰和增删资料库引用
+type CharacterUpdated struct {
+	Before character.CharacterCard
+	After  character.CharacterCard
+}
+
+func (CharacterUpdated) Name() string {
+	return "character.updated"
+}
+
+func (e CharacterUpdated) RoomID() uint {
+	return e.After.RoomID
+}
+
+// CharacterDeleted 删除人物卡，Card 为删除前的内容，文件已不存在时为 nil
+type CharacterDeleted struct {
+	Room        uint
+	CharacterID uint
+	Card        *character.CharacterCard
+}
+
+func (CharacterDeleted) Name() string {
+	return "character.deleted"
+}
+
+func (e CharacterDeleted) RoomID() uint {
+	return e.Room
+}
+
+// RollMade 房间内的公开掷骰
+type RollMade struct {
+	Room   uint
+	Result dice.RollResult
+}
+
+func (RollMade) Name() string {
+	return "roll.made"
+}
+
+func (e RollMade) RoomID() uint {
+	return e.Room
+}
+
+// EncounterCreated 新建遭遇
+type EncounterCreated struct {
+	Encounter encounter.Encounter
+}
+
+func (EncounterCreated) Name() string {
+	return "encounter.created"
+}
+
+func (e EncounterCreated) RoomID() uint {
+	return e.Encounter.RoomID
+}
+
+// EncounterUpdated 修改遭遇信息
+type EncounterUpdated struct {
+	Before encounter.Encounter
+	After  encounter.Encounter
+}
+
+func (EncounterUpdated) Name() string {
+	return "encounter.updated"
+}
+
+func (e EncounterUpdated) RoomID() uint {
+	return e.After.RoomID
+}
+
+// EncounterDeleted 删除遭遇及其实例
+type EncounterDeleted struct {
+	Encounter encounter.Encounter
+}
+
+func (EncounterDeleted) Name() string {
+	return "encounter.deleted"
+}
+
+func (e EncounterDeleted) RoomID() uint {
+	return e.Encounter.RoomID
+}
+
+// TurnAdvanced 遭遇回合推进，Combatant 为当前行动的实例
+type TurnAdvanced struct {
+	Encounter encounter.Encounter
+	Combatant encounter.Combatant
+}
+
+func (TurnAdvanced) Name() string {
+	return "encounter.turn_advanced"
+}
+
+func (e TurnAdvanced) RoomID() uint {
+	return e.Encounter.RoomID
+}
+
+// CombatantsSpawned 由数据卡生成遭遇实例
+type CombatantsSpawned struct {
+	Room       uint
+	Combatants []encounter.Combatant
+}
+
+func (CombatantsSpawned) Name() string {
+	return "combatants.spawned"
+}
+
+func (e CombatantsSpawned) RoomID() uint {
+	return e.Room
+}
+
+// CombatantUpdated 修改实例的生命值、先攻或状态
+type CombatantUpdated struct {
+	Room   uint
+	Before encounter.Combatant
+	After  encounter.Combatant
+}
+
+func (CombatantUpdated) Name() string {
+	return "combatant.updated"
+}
+
+func (e CombatantUpdated) RoomID() uint {
+	return e.Room
+}
+
+// CombatantDeleted 删除实例
+type CombatantDeleted struct {
+	Room      uint
+	Combatant encounter.Combatant
+}
+
+func (CombatantDeleted) Name() string {
+	return "combatant.deleted"
+}
+
+func (e CombatantDeleted) RoomID() uint {
+	return e.Room
+}
+
+// StatBlockCreated 新建或复制数据卡
+type StatBlockCreated struct {
+	Block monster.StatBlock
+}
+
+func (StatBlockCreated) Name() string {
+	return "statblock.created"
+}
+
+func (e StatBlockCreated) RoomID() uint {
+	return e.Block.RoomID
+}
+
+// StatBlockUpdated 修改数据卡
+type StatBlockUpdated struct {
+	Before monster.StatBlock
+	After  monster.StatBlock
+}
+
+func (StatBlockUpdated) Name() string {
+	return "statblock.updated"
+}
+
+func (e StatBlockUpdated) RoomID() uint {
+	return e.After.RoomID
+}
+
+// StatBlockDeleted 删除数据卡
+type StatBlockDeleted struct {
+	Block monster.StatBlock
+}
+
+func (StatBlockDeleted) Name() string {
+	return "statblock.deleted"
+}
+
+func (e StatBlockDeleted) RoomID() uint {
+	return e.Block.RoomID
+}
+
+// HomebrewCreated 新建自制内容
+type HomebrewCreated struct {
+	Entry homebrew.Entry
+}
+
+func (HomebrewCreated) Name() string {
+	return "homebrew.created"
+}
+
+func (e HomebrewCreated) RoomID() uint {
+	return e.Entry.RoomID
+}
+
+// HomebrewUpdated 修改自制内容
+type HomebrewUpdated struct {
+	Before homebrew.Entry
+	After  homebrew.Entry
+}
+
+func (HomebrewUpdated) Name() string {
+	return "homebrew.updated"
+}
+
+func (e HomebrewUpdated) RoomID() uint {
+	return e.After.RoomID
+}
+
+// HomebrewDeleted 删除自制内容
+type HomebrewDeleted struct {
+	Entry homebrew.Entry
+}
+
+func (HomebrewDeleted) Name() string {
+	return "homebrew.deleted"
+}
+
+func (e HomebrewDeleted) RoomID() uint {
+	return e.Entry.RoomID
+}
+
+// HomebrewImported 导入自制内容包，Entries 为实际写入的条目
+type HomebrewImported struct {
+	Room    uint
+	Entries []homebrew.Entry
+}
+
+func (HomebrewImported) Name() string {
+	return "homebrew.imported"
+}
+
+func (e HomebrewImported) RoomID() uint {
+	return e.Room
+}
+
+// TemplateCreated 由房间保存战役模板
+type TemplateCreated struct {
+	Template campaign.Template
+}
+
+func (TemplateCreated) Name() string {
+	return "template.created"
+}
+
+func (e TemplateCreated) RoomID() uint {
+	return e.Template.SourceRoomID
+}
+
+// TemplateDeleted 删除战役模板
+type TemplateDeleted struct {
+	Template campaign.Template
+}
+
+func (TemplateDeleted) Name() string {
+	return "template.deleted"
+}
+
+func (e TemplateDeleted) RoomID() uint {
+	return e.Template.SourceRoomID
+}
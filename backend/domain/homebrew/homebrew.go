@@ -0,0 +1,99 @@
+package homebrew
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+	"trpg-sync/backend/domain/compendium"
+)
+
+// GlobalRoomID 全局共享的自制内容使用的房间 ID
+const GlobalRoomID uint = 0
+
+// DefaultSource 未指定来源时使用的来源标识
+const DefaultSource = "Homebrew"
+
+// Entry 自制内容条目，字段与资料库条目一致，额外记录所属房间和创建者
+// 全局内容只有创建者可以修改，OwnerID 为 0 表示未登录时创建
+type Entry struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	RoomID      uint            `json:"room_id" gorm:"not null;default:0;uniqueIndex:idx_homebrew_room_type_slug"`
+	OwnerID     uint            `json:"owner_id" gorm:"not null;default:0;index"`
+	Type        string          `json:"type" gorm:"not null;uniqueIndex:idx_homebrew_room_type_slug"`
+	Slug        string          `json:"slug" gorm:"not null;uniqueIndex:idx_homebrew_room_type_slug"`
+	Name        string          `json:"name" gorm:"not null"`
+	Source      string          `json:"source"`
+	Description string          `json:"description"`
+	Level       *int            `json:"level,omitempty"`
+	School      string          `json:"school,omitempty"`
+	Classes     []string        `json:"classes,omitempty" gorm:"serializer:json"`
+	Data        json.RawMessage `json:"data,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+func (Entry) TableName() string {
+	return "homebrew_entries"
+}
+
+// ToCompendium 转换为资料库条目，以便和 SRD 数据一起搜索
+func (e Entry) ToCompendium() compendium.Entry {
+	return compendium.Entry{
+		Type:        compendium.EntryType(e.Type),
+		Slug:        e.Slug,
+		Name:        e.Name,
+		Source:      e.Source,
+		Description: e.Description,
+		Level:       e.Level,
+		School:      e.School,
+		Classes:     e.Classes,
+		Data:        e.Data,
+	}
+}
+
+// FromCompendium 由资料库条目创建自制内容
+func FromCompendium(roomID uint, entry compendium.Entry) Entry {
+	e := Entry{
+		RoomID:      roomID,
+		Type:        string(entry.Type),
+		Slug:        entry.Slug,
+		Name:        entry.Name,
+		Source:      entry.Source,
+		Description: entry.Description,
+		Level:       entry.Level,
+		School:      entry.School,
+		Classes:     entry.Classes,
+		Data:        entry.Data,
+	}
+	e.Normalize()
+	return e
+}
+
+// Normalize 补全 slug 和来源
+func (e *Entry) Normalize() {
+	if e.Slug == "" {
+		e.Slug = Slugify(e.Name)
+	}
+	if e.Source == "" {
+		e.Source = DefaultSource
+	}
+}
+
+var slugInvalidChars = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// Slugify 将名称转换为 slug，保留中文等非 ASCII 字符
+func Slugify(name string) string {
+	slug := slugInvalidChars.ReplaceAllString(strings.ToLower(name), "-")
+	return strings.Trim(slug, "-")
+}
+
+// Pack 自制内容包，用于在不同群组之间导入导出
+type Pack struct {
+	Name        string             `json:"name"`
+	Version     string             `json:"version,omitempty"`
+	Author      string             `json:"author,omitempty"`
+	Description string             `json:"description,omitempty"`
+	ExportedAt  time.Time          `json:"exported_at"`
+	Entries     []compendium.Entry `json:"entries"`
+}
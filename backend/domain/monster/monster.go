@@ -0,0 +1,83 @@
+package monster
+
+import (
+	"time"
+)
+
+// Speed 各种移动方式的速度（尺）
+type Speed struct {
+	Walk   int  `json:"walk"`
+	Fly    int  `json:"fly,omitempty"`
+	Swim   int  `json:"swim,omitempty"`
+	Climb  int  `json:"climb,omitempty"`
+	Burrow int  `json:"burrow,omitempty"`
+	Hover  bool `json:"hover,omitempty"`
+}
+
+// Feature 特性、动作、传奇动作或反应
+type Feature struct {
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+}
+
+// StatBlock 房间内的怪物或 NPC 数据卡
+// JSON 字段与资料库中怪物条目的 data 保持一致，便于直接克隆
+type StatBlock struct {
+	ID                    uint           `json:"id" gorm:"primaryKey"`
+	RoomID                uint           `json:"room_id" gorm:"not null;index"`
+	Name                  string         `json:"name" gorm:"not null"`
+	IsNPC                 bool           `json:"is_npc"`
+	Size                  string         `json:"size"`
+	Type                  string         `json:"type"`
+	Alignment             string         `json:"alignment"`
+	ArmorClass            int            `json:"armor_class"`
+	ArmorDesc             string         `json:"armor_desc"`
+	HitPoints             int            `json:"hit_points"`
+	HitDice               string         `json:"hit_dice"`
+	Speed                 Speed          `json:"speed" gorm:"serializer:json"`
+	Strength              int            `json:"strength"`
+	Dexterity             int            `json:"dexterity"`
+	Constitution          int            `json:"constitution"`
+	Intelligence          int            `json:"intelligence"`
+	Wisdom                int            `json:"wisdom"`
+	Charisma              int            `json:"charisma"`
+	SavingThrows          map[string]int `json:"saving_throws" gorm:"serializer:json"`
+	Skills                map[string]int `json:"skills" gorm:"serializer:json"`
+	DamageVulnerabilities []string       `json:"damage_vulnerabilities" gorm:"serializer:json"`
+	DamageResistances     []string       `json:"damage_resistances" gorm:"serializer:json"`
+	DamageImmunities      []string       `json:"damage_immunities" gorm:"serializer:json"`
+	ConditionImmunities   []string       `json:"condition_immunities" gorm:"serializer:json"`
+	Senses                string         `json:"senses"`
+	Languages             string         `json:"languages"`
+	ChallengeRating       string         `json:"challenge_rating" gorm:"not null;default:'0'"`
+	XP                    int            `json:"xp"`
+	Traits                []Feature      `json:"traits" gorm:"serializer:json"`
+	Actions               []Feature      `json:"actions" gorm:"serializer:json"`
+	LegendaryActions      []Feature      `json:"legendary_actions" gorm:"serializer:json"`
+	Reactions             []Feature      `json:"reactions" gorm:"serializer:json"`
+	CompendiumSlug        string         `json:"compendium_slug"`
+	Notes                 string         `json:"notes"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+}
+
+func (StatBlock) TableName() string {
+	return "stat_blocks"
+}
+
+// challengeRatingXP 挑战等级对应的经验值
+var challengeRatingXP = map[string]int{
+	"0": 10, "1/8": 25, "1/4": 50, "1/2": 100,
+	"1": 200, "2": 450, "3": 700, "4": 1100, "5": 1800,
+	"6": 2300, "7": 2900, "8": 3900, "9": 5000, "10": 5900,
+	"11": 7200, "12": 8400, "13": 10000, "14": 11500, "15": 13000,
+	"16": 15000, "17": 18000, "18": 20000, "19": 22000, "20": 25000,
+	"21": 33000, "22": 41000, "23": 50000, "24": 62000, "25": 75000,
+	"26": 90000, "27": 105000, "28": 120000, "29": 135000, "30": 155000,
+}
+
+// ChallengeRatingXP 返回挑战等级对应的经验值
+func ChallengeRatingXP(cr string) (int, bool) {
+	xp, ok := challengeRatingXP[cr]
+	return xp, ok
+}
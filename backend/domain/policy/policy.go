@@ -0,0 +1,157 @@
+package policy
+
+import (
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/room"
+)
+
+// Action 需要鉴权的操作
+type Action string
+
+// 房间操作
+const (
+	// RoomView 查看房间信息、成员和队伍总览
+	RoomView Action = "room.view"
+	// RoomUpdate 修改房间设置、属性生成方式和归档状态
+	RoomUpdate Action = "room.update"
+	// RoomDelete 解散房间
+	RoomDelete Action = "room.delete"
+	// RoomExport 导出或复制房间，结果包含全部人物卡
+	RoomExport Action = "room.export"
+	// RoomManageMembers 踢出玩家、发起 DM 转让、查看和重新生成邀请码、设置房间密码
+	RoomManageMembers Action = "room.manage_members"
+	// RoomRoll 在房间中公开掷骰
+	RoomRoll Action = "room.roll"
+	// RoomManageEncounters 创建、修改和删除遭遇与怪物数据卡，生成怪物、调整参战者和推进回合
+	RoomManageEncounters Action = "room.manage_encounters"
+	// RoomManageHomebrew 创建、修改、删除和导入房间的自制内容
+	RoomManageHomebrew Action = "room.manage_homebrew"
+)
+
+// 人物卡操作
+const (
+	CharacterCreate Action = "character.create"
+	CharacterView   Action = "character.view"
+	CharacterUpdate Action = "character.update"
+	CharacterDelete Action = "character.delete"
+)
+
+// Actor 当前用户在某个房间中的身份
+// Open 为 true 表示房间没有 DM（单人本地模式或加入成员功能之前创建的房间），所有操作都不做限制
+type Actor struct {
+	UserID uint
+	Role   string
+	Open   bool
+}
+
+// IsDM 是否为房间 DM
+func (a Actor) IsDM() bool {
+	return a.Role == room.RoleDM
+}
+
+// IsMember 是否为房间成员（包括 DM）
+func (a Actor) IsMember() bool {
+	return a.Role == room.RoleDM || a.Role == room.RolePlayer
+}
+
+// Owns 人物卡是否属于当前用户；没有所有者的人物卡视为 DM 所有
+func (a Actor) Owns(card *character.CharacterCard) bool {
+	if card.OwnerID == 0 {
+		return a.IsDM()
+	}
+	return a.UserID != 0 && card.OwnerID == a.UserID
+}
+
+// Room 检查房间操作：成员可以查看房间和掷骰，其余操作只有 DM 可以执行
+func Room(actor Actor, action Action) error {
+	if actor.Open {
+		return nil
+	}
+	if err := membership(actor); err != nil {
+		return err
+	}
+	switch action {
+	case RoomView, RoomRoll:
+		return nil
+	case RoomUpdate:
+		return dmOnly(actor, "Only the room DM can change room settings")
+	case RoomDelete:
+		return dmOnly(actor, "Only the room DM can dissolve the room")
+	case RoomExport:
+		return dmOnly(actor, "Only the room DM can export or copy the room")
+	case RoomManageMembers:
+		return dmOnly(actor, "Only the room DM can manage members")
+	case RoomManageEncounters:
+		return dmOnly(actor, "Only the room DM can manage encounters and stat blocks")
+	case RoomManageHomebrew:
+		return dmOnly(actor, "Only the room DM can manage room homebrew")
+	}
+	return apperror.New(apperror.CodeForbidden, "")
+}
+
+// Character 检查人物卡操作，创建时 card 可以为 nil
+// 成员可以创建人物卡；玩家只能查看、修改和删除自己的人物卡；DM 可以查看全部人物卡，但只能修改和删除自己的
+func Character(actor Actor, action Action, card *character.CharacterCard) error {
+	if actor.Open {
+		return nil
+	}
+	if err := membership(actor); err != nil {
+		return err
+	}
+	switch action {
+	case CharacterCreate:
+		return nil
+	case CharacterView:
+		if actor.IsDM() || actor.Owns(card) {
+			return nil
+		}
+		return apperror.New(apperror.CodeForbidden, "Players can only view their own characters")
+	case CharacterUpdate:
+		if actor.Owns(card) {
+			return nil
+		}
+		return apperror.New(apperror.CodeForbidden, "Only the owner can edit this character")
+	case CharacterDelete:
+		if actor.Owns(card) {
+			return nil
+		}
+		return apperror.New(apperror.CodeForbidden, "Only the owner can delete this character")
+	}
+	return apperror.New(apperror.CodeForbidden, "")
+}
+
+// GlobalContent 检查全局内容（不属于任何房间的自制内容）的修改、删除和导入覆盖：只有创建者本人可以操作
+// 未登录时创建的内容 ownerID 为 0，只能由未登录的调用方（单人本地模式）操作
+func GlobalContent(userID, ownerID uint) error {
+	if userID == ownerID {
+		return nil
+	}
+	if userID == 0 {
+		return apperror.New(apperror.CodeUnauthorized, "")
+	}
+	return apperror.New(apperror.CodeForbidden, "Only the creator can change this global content")
+}
+
+// CanViewCharacter 与 Character(actor, CharacterView, card) 相同，用于过滤列表
+func CanViewCharacter(actor Actor, card *character.CharacterCard) bool {
+	return Character(actor, CharacterView, card) == nil
+}
+
+// membership 未登录返回 401，非成员返回 403
+func membership(actor Actor) error {
+	if actor.UserID == 0 {
+		return apperror.New(apperror.CodeUnauthorized, "")
+	}
+	if !actor.IsMember() {
+		return apperror.New(apperror.CodeForbidden, "You are not a member of this room")
+	}
+	return nil
+}
+
+func dmOnly(actor Actor, message string) error {
+	if actor.IsDM() {
+		return nil
+	}
+	return apperror.New(apperror.CodeForbidden, message)
+}
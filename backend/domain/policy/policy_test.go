@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"trpg-sync/backend/domain/apperror"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/room"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试中的身份：DM 为用户 1，玩家 2（人物卡所有者）和 3，非成员 4
+var actors = map[string]Actor{
+	"dm":        {UserID: 1, Role: room.RoleDM},
+	"owner":     {UserID: 2, Role: room.RolePlayer},
+	"player":    {UserID: 3, Role: room.RolePlayer},
+	"outsider":  {UserID: 4},
+	"anonymous": {},
+}
+
+// 期望结果：allow 允许，其余为返回的错误码
+const allow apperror.Code = ""
+
+const (
+	unauthorized = apperror.CodeUnauthorized
+	forbidden    = apperror.CodeForbidden
+)
+
+func errorCode(err error) apperror.Code {
+	var appErr *apperror.Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return allow
+}
+
+func TestRoom_Matrix(t *testing.T) {
+	matrix := map[Action]map[string]apperror.Code{
+		RoomView:             {"dm": allow, "owner": allow, "player": allow, "outsider": forbidden, "anonymous": unauthorized},
+		RoomUpdate:           {"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized},
+		RoomDelete:           {"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized},
+		RoomExport:           {"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized},
+		RoomManageMembers:    {"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized},
+		RoomRoll:             {"dm": allow, "owner": allow, "player": allow, "outsider": forbidden, "anonymous": unauthorized},
+		RoomManageEncounters: {"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized},
+		RoomManageHomebrew:   {"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized},
+	}
+	for action, expected := range matrix {
+		for name, want := range expected {
+			t.Run(fmt.Sprintf("%s/%s", action, name), func(t *testing.T) {
+				assert.Equal(t, want, errorCode(Room(actors[name], action)))
+			})
+		}
+	}
+}
+
+func TestCharacter_Matrix(t *testing.T) {
+	playerCard := &character.CharacterCard{ID: 1, OwnerID: 2}
+	dmCard := &character.CharacterCard{ID: 2, OwnerID: 1}
+	unowned := &character.CharacterCard{ID: 3}
+
+	matrix := []struct {
+		action Action
+		card   *character.CharacterCard
+		want   map[string]apperror.Code
+	}{
+		{CharacterCreate, nil, map[string]apperror.Code{"dm": allow, "owner": allow, "player": allow, "outsider": forbidden, "anonymous": unauthorized}},
+		{CharacterView, playerCard, map[string]apperror.Code{"dm": allow, "owner": allow, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized}},
+		{CharacterUpdate, playerCard, map[string]apperror.Code{"dm": forbidden, "owner": allow, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized}},
+		{CharacterDelete, playerCard, map[string]apperror.Code{"dm": forbidden, "owner": allow, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized}},
+		{CharacterView, dmCard, map[string]apperror.Code{"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized}},
+		{CharacterUpdate, dmCard, map[string]apperror.Code{"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized}},
+		{CharacterDelete, dmCard, map[string]apperror.Code{"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized}},
+		// 没有所有者的人物卡（成员功能之前创建）归 DM 管理
+		{CharacterView, unowned, map[string]apperror.Code{"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized}},
+		{CharacterUpdate, unowned, map[string]apperror.Code{"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized}},
+		{CharacterDelete, unowned, map[string]apperror.Code{"dm": allow, "owner": forbidden, "player": forbidden, "outsider": forbidden, "anonymous": unauthorized}},
+	}
+	for _, tt := range matrix {
+		for name, want := range tt.want {
+			cardID := uint(0)
+			if tt.card != nil {
+				cardID = tt.card.ID
+			}
+			t.Run(fmt.Sprintf("%s/card%d/%s", tt.action, cardID, name), func(t *testing.T) {
+				assert.Equal(t, want, errorCode(Character(actors[name], tt.action, tt.card)))
+			})
+		}
+	}
+}
+
+func TestOpenRoom_AllowsEverything(t *testing.T) {
+	card := &character.CharacterCard{OwnerID: 2}
+	for name, actor := range actors {
+		actor.Open = true
+		for _, action := range []Action{RoomView, RoomUpdate, RoomDelete, RoomExport, RoomManageMembers} {
+			assert.NoError(t, Room(actor, action), "%s %s", name, action)
+		}
+		for _, action := range []Action{CharacterCreate, CharacterView, CharacterUpdate, CharacterDelete} {
+			assert.NoError(t, Character(actor, action, card), "%s %s", name, action)
+		}
+	}
+}
+
+func TestForbiddenMessages(t *testing.T) {
+	err := Room(actors["player"], RoomDelete)
+	assert.EqualError(t, err, "FORBIDDEN: Only the room DM can dissolve the room")
+
+	err = Character(actors["dm"], CharacterDelete, &character.CharacterCard{OwnerID: 2})
+	assert.EqualError(t, err, "FORBIDDEN: Only the owner can delete this character")
+}
+
+func TestGlobalContent(t *testing.T) {
+	assert.NoError(t, GlobalContent(2, 2))
+	assert.NoError(t, GlobalContent(0, 0))
+	assert.EqualError(t, GlobalContent(1, 2), "FORBIDDEN: Only the creator can change this global content")
+	assert.EqualError(t, GlobalContent(1, 0), "FORBIDDEN: Only the creator can change this global content")
+	assert.Equal(t, unauthorized, errorCode(GlobalContent(0, 2)))
+}
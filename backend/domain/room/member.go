@@ -0,0 +1,42 @@
+package room
+
+import "time"
+
+// 房间成员角色，每个房间最多一名 DM
+const (
+	RoleDM     = "dm"
+	RolePlayer = "player"
+)
+
+// Member 房间成员，同一用户在一个房间中只有一条记录
+type Member struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RoomID    uint      `json:"room_id" gorm:"not null;uniqueIndex:idx_room_member"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_room_member;index"`
+	Role      string    `json:"role" gorm:"not null;default:'player'"`
+	JoinedAt  time.Time `json:"joined_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Member) TableName() string {
+	return "room_members"
+}
+
+// IsDM 成员是否为房间 DM
+func (m *Member) IsDM() bool {
+	return m.Role == RoleDM
+}
+
+// 踢出玩家时对其人物卡的处理方式
+const (
+	// KickCardsArchive 人物卡归档为只读，玩家重新加入后恢复
+	KickCardsArchive = "archive"
+	// KickCardsTransfer 人物卡转给 DM
+	KickCardsTransfer = "transfer"
+)
+
+// IsValidKickCards 判断人物卡处理方式是否合法
+func IsValidKickCards(mode string) bool {
+	return mode == KickCardsArchive || mode == KickCardsTransfer
+}
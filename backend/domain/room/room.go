@@ -1,18 +1,245 @@
 package room
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"strings"
 	"time"
+	"trpg-sync/backend/domain/validation"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 房间字段长度限制
+const (
+	MaxNameLength        = 100
+	MaxDescriptionLength = 2000
+	MaxCoverImageLength  = 500
+	MaxScheduleLength    = 200
+	MaxTags              = 20
+	MaxTagLength         = 30
+	MaxPlayerCount       = 50
+	MaxPasswordLength    = 72
+)
+
+// DefaultMaxPlayers 房间默认的玩家上限（不含 DM）
+const DefaultMaxPlayers = 10
+
+// 邀请码由去掉易混淆字符（0/O、1/I/L）的大写字母和数字组成
+const (
+	InviteCodeLength   = 8
+	inviteCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
 )
 
+// 房间列表排序字段
+const (
+	SortName       = "name"
+	SortCreatedAt  = "created_at"
+	SortLastPlayed = "last_played_at"
+)
+
+// 默认规则系统
+const DefaultRuleSystem = "DND5e"
+
+// 房间状态
+const (
+	StatusActive   = "active"
+	StatusPaused   = "paused"
+	StatusArchived = "archived"
+)
+
+// 人物卡属性值生成方式
+const (
+	AbilityMethodFree          = "free"
+	AbilityMethodPointBuy      = "point_buy"
+	AbilityMethodStandardArray = "standard_array"
+	AbilityMethodRolled        = "rolled"
+)
+
+// Room 房间；PlayerCount 为当前玩家数（不含 DM），由成员表统计得出，不保存在房间表中
 type Room struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	Description string    `json:"description"`
-	RuleSystem  string    `json:"rule_system" gorm:"not null;default:'DND5e'"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	Name          string     `json:"name" gorm:"not null"`
+	Description   string     `json:"description"`
+	RuleSystem    string     `json:"rule_system" gorm:"not null;default:'DND5e'"`
+	AbilityMethod string     `json:"ability_method" gorm:"not null;default:'free'"`
+	Status        string     `json:"status" gorm:"not null;default:'active';index"`
+	CoverImage    string     `json:"cover_image"`
+	Tags          []string   `json:"tags" gorm:"serializer:json"`
+	Schedule      string     `json:"schedule"`
+	PlayerCount   int        `json:"player_count" gorm:"-"`
+	MaxPlayers    int        `json:"max_players" gorm:"not null;default:10"`
+	InviteCode    string     `json:"-" gorm:"index"`
+	PasswordHash  string     `json:"-"`
+	HasPassword   bool       `json:"has_password" gorm:"not null;default:false"`
+	LastPlayedAt  *time.Time `json:"last_played_at"`
+	ArchivedAt    *time.Time `json:"archived_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 func (Room) TableName() string {
 	return "rooms"
 }
+
+// IsValidAbilityMethod 判断属性值生成方式是否合法
+func IsValidAbilityMethod(method string) bool {
+	switch method {
+	case AbilityMethodFree, AbilityMethodPointBuy, AbilityMethodStandardArray, AbilityMethodRolled:
+		return true
+	}
+	return false
+}
+
+// IsValidStatus 判断房间状态是否合法
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusActive, StatusPaused, StatusArchived:
+		return true
+	}
+	return false
+}
+
+// IsArchived 房间是否已归档，归档房间的人物卡只读
+func (r *Room) IsArchived() bool {
+	return r.Status == StatusArchived
+}
+
+// Archive 归档房间
+func (r *Room) Archive(at time.Time) {
+	r.Status = StatusArchived
+	r.ArchivedAt = &at
+}
+
+// Unarchive 取消归档，房间恢复为进行中
+func (r *Room) Unarchive() {
+	r.Status = StatusActive
+	r.ArchivedAt = nil
+}
+
+// NewInviteCode 生成随机邀请码，每位在字母表中均匀选取（字节取模会偏向字母表开头的字符）
+func NewInviteCode() string {
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+	buf := make([]byte, InviteCodeLength)
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		buf[i] = inviteCodeAlphabet[n.Int64()]
+	}
+	return string(buf)
+}
+
+// NormalizeInviteCode 去掉空白和分隔符并转为大写，便于用户手动输入
+func NormalizeInviteCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// RotateInviteCode 生成新的邀请码，旧邀请码立即失效
+func (r *Room) RotateInviteCode() {
+	r.InviteCode = NewInviteCode()
+}
+
+// MatchInviteCode 校验邀请码，房间没有邀请码时总是失败
+func (r *Room) MatchInviteCode(code string) bool {
+	code = NormalizeInviteCode(code)
+	return r.InviteCode != "" && subtle.ConstantTimeCompare([]byte(r.InviteCode), []byte(code)) == 1
+}
+
+// SetPassword 设置加入房间的密码，为空时取消密码
+func (r *Room) SetPassword(password string) error {
+	if password == "" {
+		r.PasswordHash = ""
+		r.HasPassword = false
+		return nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	r.PasswordHash = string(hash)
+	r.HasPassword = true
+	return nil
+}
+
+// CheckPassword 校验加入房间的密码，未设置密码时总是通过
+func (r *Room) CheckPassword(password string) bool {
+	if !r.HasPassword {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(r.PasswordHash), []byte(password)) == nil
+}
+
+// ValidatePassword 校验房间密码长度，bcrypt 只使用前 72 个字节
+func ValidatePassword(password string) []validation.FieldError {
+	if len(password) > MaxPasswordLength {
+		return []validation.FieldError{validation.NewError("password", validation.CodeTooLong, map[string]interface{}{"max": MaxPasswordLength})}
+	}
+	return nil
+}
+
+// NormalizeTags 去掉标签首尾空白、空标签和重复标签，保持原有顺序
+func NormalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// Validate 校验房间名称、简介长度、属性值生成方式和房间元数据
+func (r *Room) Validate() []validation.FieldError {
+	var errs []validation.FieldError
+
+	name := strings.TrimSpace(r.Name)
+	switch {
+	case name == "":
+		errs = append(errs, validation.NewError("name", validation.CodeRequired, nil))
+	case len([]rune(name)) > MaxNameLength:
+		errs = append(errs, validation.NewError("name", validation.CodeTooLong, map[string]interface{}{"max": MaxNameLength}))
+	}
+
+	if len([]rune(r.Description)) > MaxDescriptionLength {
+		errs = append(errs, validation.NewError("description", validation.CodeTooLong, map[string]interface{}{"max": MaxDescriptionLength}))
+	}
+
+	if !IsValidAbilityMethod(r.AbilityMethod) {
+		errs = append(errs, validation.NewError("ability_method", validation.CodeInvalidChoice, map[string]interface{}{
+			"choices": strings.Join([]string{AbilityMethodFree, AbilityMethodPointBuy, AbilityMethodStandardArray, AbilityMethodRolled}, ", "),
+		}))
+	}
+
+	if !IsValidStatus(r.Status) {
+		errs = append(errs, validation.NewError("status", validation.CodeInvalidChoice, map[string]interface{}{
+			"choices": strings.Join([]string{StatusActive, StatusPaused, StatusArchived}, ", "),
+		}))
+	}
+	if len(r.CoverImage) > MaxCoverImageLength {
+		errs = append(errs, validation.NewError("cover_image", validation.CodeTooLong, map[string]interface{}{"max": MaxCoverImageLength}))
+	}
+	if len([]rune(r.Schedule)) > MaxScheduleLength {
+		errs = append(errs, validation.NewError("schedule", validation.CodeTooLong, map[string]interface{}{"max": MaxScheduleLength}))
+	}
+	if len(r.Tags) > MaxTags {
+		errs = append(errs, validation.NewError("tags", validation.CodeExceedsMax, map[string]interface{}{"other": MaxTags}))
+	}
+	for i, tag := range r.Tags {
+		if len([]rune(tag)) > MaxTagLength {
+			errs = append(errs, validation.NewError(fmt.Sprintf("tags[%d]", i), validation.CodeTooLong, map[string]interface{}{"max": MaxTagLength}))
+		}
+	}
+	if r.MaxPlayers < 1 || r.MaxPlayers > MaxPlayerCount {
+		errs = append(errs, validation.NewError("max_players", validation.CodeOutOfRange, map[string]interface{}{"min": 1, "max": MaxPlayerCount}))
+	}
+	return errs
+}
@@ -0,0 +1,30 @@
+package room
+
+import "time"
+
+// TransferTTL DM 转让请求的有效期，过期后需要重新发起
+const TransferTTL = 72 * time.Hour
+
+// Transfer 待确认的 DM 转让请求，每个房间最多一条，新 DM 接受后才生效
+type Transfer struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	RoomID     uint      `json:"room_id" gorm:"not null;uniqueIndex"`
+	FromUserID uint      `json:"from_user_id" gorm:"not null"`
+	ToUserID   uint      `json:"to_user_id" gorm:"not null"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (Transfer) TableName() string {
+	return "room_transfers"
+}
+
+// NewTransfer 创建转让请求
+func NewTransfer(roomID, from, to uint, now time.Time) Transfer {
+	return Transfer{RoomID: roomID, FromUserID: from, ToUserID: to, ExpiresAt: now.Add(TransferTTL)}
+}
+
+// Expired 转让请求是否已过期
+func (t *Transfer) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
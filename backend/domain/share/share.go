@@ -0,0 +1,129 @@
+package share
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/validation"
+)
+
+// 分享链接有效期，单位为小时
+const (
+	DefaultExpiresInHours = 7 * 24
+	MaxExpiresInHours     = 90 * 24
+)
+
+// MaskableFields 可以在分享链接中隐藏的人物卡字段（JSON 字段名），名称始终可见
+var MaskableFields = []string{
+	"race", "class", "level", "background", "alignment",
+	"strength", "dexterity", "constitution", "intelligence", "wisdom", "charisma",
+	"ac", "hp", "max_hp", "speed", "proficiency",
+	"skills", "saves", "equipment", "spells", "references",
+	"ability_bonuses", "ability_roll", "conditions", "resources",
+}
+
+// tokenHintLength 列表中展示的令牌开头长度，便于用户辨认
+const tokenHintLength = 6
+
+// alwaysHidden 分享页面不公开的内部字段
+var alwaysHidden = []string{"owner_id", "archived_at"}
+
+// Link 人物卡分享链接，令牌为随机字符串，数据库只保存其 SHA-256 摘要，不依赖服务器密钥；撤销后令牌立即失效
+// HiddenFields 为对外隐藏的字段；Hint 为令牌开头几位
+type Link struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	RoomID       uint       `json:"room_id" gorm:"not null;index:idx_share_character"`
+	CharacterID  uint       `json:"character_id" gorm:"not null;index:idx_share_character"`
+	CreatedBy    uint       `json:"created_by" gorm:"not null;default:0"`
+	Hint         string     `json:"hint" gorm:"size:16;not null;default:''"`
+	Hash         string     `json:"-" gorm:"size:64;not null;default:'';index"`
+	HiddenFields []string   `json:"hidden_fields" gorm:"serializer:json"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (Link) TableName() string {
+	return "share_links"
+}
+
+// IssueToken 生成随机令牌并记录摘要，返回只展示一次的明文
+func (l *Link) IssueToken() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	plain := base64.RawURLEncoding.EncodeToString(buf)
+	l.Hint = plain[:tokenHintLength]
+	l.Hash = HashToken(plain)
+	return plain
+}
+
+// HashToken 计算令牌摘要；令牌为 256 位随机数，无需加盐和慢哈希，按摘要直接查找
+func HashToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// Active 链接未撤销且未过期
+func (l *Link) Active(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
+
+// Hides 是否隐藏指定字段
+func (l *Link) Hides(field string) bool {
+	return slices.Contains(alwaysHidden, field) || slices.Contains(l.HiddenFields, field)
+}
+
+// NormalizeHiddenFields 去掉空白和重复项
+func NormalizeHiddenFields(fields []string) []string {
+	normalized := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field != "" && !slices.Contains(normalized, field) {
+			normalized = append(normalized, field)
+		}
+	}
+	return normalized
+}
+
+// Validate 校验有效期（小时）和隐藏字段
+func Validate(expiresInHours int, hiddenFields []string) []validation.FieldError {
+	var errs []validation.FieldError
+	if expiresInHours < 1 || expiresInHours > MaxExpiresInHours {
+		errs = append(errs, validation.NewError("expires_in_hours", validation.CodeOutOfRange, map[string]interface{}{"min": 1, "max": MaxExpiresInHours}))
+	}
+	for _, field := range hiddenFields {
+		if !slices.Contains(MaskableFields, field) {
+			errs = append(errs, validation.NewError("hidden_fields", validation.CodeInvalidChoice, map[string]interface{}{
+				"choices": strings.Join(MaskableFields, ", "),
+			}))
+			break
+		}
+	}
+	return errs
+}
+
+// Mask 返回去掉隐藏字段后的人物卡内容
+func (l *Link) Mask(card *character.CharacterCard) (map[string]interface{}, error) {
+	data, err := json.Marshal(card)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for field := range fields {
+		if l.Hides(field) {
+			delete(fields, field)
+		}
+	}
+	return fields, nil
+}
@@ -0,0 +1,55 @@
+package share
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"trpg-sync/backend/domain/character"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLink_Mask(t *testing.T) {
+	now := time.Now()
+	card := &character.CharacterCard{ID: 1, RoomID: 2, OwnerID: 3, Name: "Vex", Class: "Ranger", Background: "Secret past", Level: 5, ArchivedAt: &now}
+	link := Link{HiddenFields: []string{"background", "level"}}
+
+	fields, err := link.Mask(card)
+	require.NoError(t, err)
+	assert.Equal(t, "Vex", fields["name"])
+	assert.Equal(t, "Ranger", fields["class"])
+	for _, hidden := range []string{"background", "level", "owner_id", "archived_at"} {
+		assert.NotContains(t, fields, hidden)
+	}
+}
+
+func TestLink_Active(t *testing.T) {
+	now := time.Now()
+	link := Link{ExpiresAt: now.Add(time.Hour)}
+	assert.True(t, link.Active(now))
+	assert.False(t, link.Active(now.Add(time.Hour)))
+
+	link.RevokedAt = &now
+	assert.False(t, link.Active(now))
+}
+
+func TestLink_IssueToken(t *testing.T) {
+	var link Link
+	plain := link.IssueToken()
+	assert.Equal(t, HashToken(plain), link.Hash)
+	assert.NotContains(t, link.Hash, plain)
+	assert.True(t, strings.HasPrefix(plain, link.Hint))
+
+	var other Link
+	assert.NotEqual(t, plain, other.IssueToken())
+}
+
+func TestValidate(t *testing.T) {
+	assert.Empty(t, Validate(DefaultExpiresInHours, []string{"background", "spells"}))
+	assert.Len(t, Validate(0, nil), 1)
+	assert.Len(t, Validate(MaxExpiresInHours+1, nil), 1)
+	assert.Len(t, Validate(1, []string{"name"}), 1)
+	assert.Equal(t, []string{"background", "spells"}, NormalizeHiddenFields([]string{" Background", "spells", "", "background"}))
+}
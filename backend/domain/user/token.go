@@ -0,0 +1,162 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+	"trpg-sync/backend/domain/validation"
+)
+
+// API 令牌的权限范围，格式为 资源:操作；读权限对应 GET 请求，写权限对应其它请求
+// rooms:admin 用于解散房间、踢出成员、转移 DM、修改密码和邀请码等不可撤销或影响他人的操作
+const (
+	ScopeRoomsRead       = "rooms:read"
+	ScopeRoomsWrite      = "rooms:write"
+	ScopeRoomsAdmin      = "rooms:admin"
+	ScopeMembersRead     = "members:read"
+	ScopeMembersWrite    = "members:write"
+	ScopeCharactersRead  = "characters:read"
+	ScopeCharactersWrite = "characters:write"
+	ScopeEncountersRead  = "encounters:read"
+	ScopeEncountersWrite = "encounters:write"
+	ScopeRollsWrite      = "rolls:write"
+	ScopeCompendiumRead  = "compendium:read"
+	ScopeCompendiumWrite = "compendium:write"
+	ScopeAuditRead       = "audit:read"
+)
+
+// Scopes 全部可授予的权限范围
+var Scopes = []string{
+	ScopeRoomsRead, ScopeRoomsWrite, ScopeRoomsAdmin,
+	ScopeMembersRead, ScopeMembersWrite,
+	ScopeCharactersRead, ScopeCharactersWrite,
+	ScopeEncountersRead, ScopeEncountersWrite,
+	ScopeRollsWrite,
+	ScopeCompendiumRead, ScopeCompendiumWrite,
+	ScopeAuditRead,
+}
+
+// APITokenPrefix API 令牌的固定前缀，认证中间件据此区分 API 令牌和登录签发的 JWT
+const APITokenPrefix = "tpat_"
+
+// API 令牌字段限制，有效期单位为天
+const (
+	MaxTokenNameLength    = 100
+	DefaultTokenExpiresIn = 30
+	MaxTokenExpiresIn     = 365
+	// tokenHintLength 列表中展示的令牌开头长度，便于用户辨认
+	tokenHintLength = len(APITokenPrefix) + 6
+)
+
+// APIToken 用户创建的个人 API 令牌，供脚本调用接口
+// 数据库只保存令牌的 SHA-256 摘要，明文只在创建时返回一次；Hint 为令牌开头几位
+type APIToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	Hint       string     `json:"hint" gorm:"size:16;not null"`
+	Hash       string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// NewAPIToken 生成随机令牌，返回待保存的记录和只展示一次的明文
+func NewAPIToken(userID uint, name string, scopes []string, expiresAt time.Time) (APIToken, string) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	plain := APITokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return APIToken{
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Hint:      plain[:tokenHintLength],
+		Hash:      HashAPIToken(plain),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}, plain
+}
+
+// HashAPIToken 计算令牌摘要；令牌为 256 位随机数，无需加盐和慢哈希，按摘要直接查找
+func HashAPIToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken 判断 Bearer 令牌是否为 API 令牌
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// Expired 令牌是否已过期
+func (t *APIToken) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// HasScope 令牌是否被授予指定权限范围
+func (t *APIToken) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// NormalizeScopes 去掉空白和重复项并按 Scopes 的顺序排列，未知的权限范围原样保留供校验报错
+func NormalizeScopes(scopes []string) []string {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if scope != "" && !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	slices.SortStableFunc(normalized, func(a, b string) int {
+		return scopeOrder(a) - scopeOrder(b)
+	})
+	return normalized
+}
+
+func scopeOrder(scope string) int {
+	if i := slices.Index(Scopes, scope); i >= 0 {
+		return i
+	}
+	return len(Scopes)
+}
+
+// ValidateAPIToken 校验令牌名称、权限范围和有效期（天）
+func ValidateAPIToken(name string, scopes []string, expiresInDays int) []validation.FieldError {
+	var errs []validation.FieldError
+
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		errs = append(errs, validation.NewError("name", validation.CodeRequired, nil))
+	case len([]rune(name)) > MaxTokenNameLength:
+		errs = append(errs, validation.NewError("name", validation.CodeTooLong, map[string]interface{}{"max": MaxTokenNameLength}))
+	}
+
+	if len(scopes) == 0 {
+		errs = append(errs, validation.NewError("scopes", validation.CodeRequired, nil))
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			errs = append(errs, validation.NewError("scopes", validation.CodeInvalidChoice, map[string]interface{}{
+				"choices": strings.Join(Scopes, ", "),
+			}))
+			break
+		}
+	}
+
+	if expiresInDays < 1 || expiresInDays > MaxTokenExpiresIn {
+		errs = append(errs, validation.NewError("expires_in_days", validation.CodeOutOfRange, map[string]interface{}{"min": 1, "max": MaxTokenExpiresIn}))
+	}
+	return errs
+}
@@ -0,0 +1,72 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"trpg-sync/backend/domain/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIToken(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	token, plain := NewAPIToken(1, "  initiative bot ", []string{ScopeRollsWrite}, expiresAt)
+
+	assert.True(t, IsAPIToken(plain))
+	assert.Equal(t, "initiative bot", token.Name)
+	assert.Equal(t, HashAPIToken(plain), token.Hash)
+	assert.NotContains(t, token.Hash, plain)
+	assert.True(t, strings.HasPrefix(plain, token.Hint))
+	assert.Len(t, token.Hint, len(APITokenPrefix)+6)
+
+	_, other := NewAPIToken(1, "other", nil, expiresAt)
+	assert.NotEqual(t, plain, other)
+}
+
+func TestAPIToken_ExpiredAndScopes(t *testing.T) {
+	now := time.Now()
+	token := APIToken{ExpiresAt: now.Add(time.Hour), Scopes: []string{ScopeRoomsRead}}
+	assert.False(t, token.Expired(now))
+	assert.True(t, token.Expired(now.Add(time.Hour)))
+	assert.True(t, token.HasScope(ScopeRoomsRead))
+	assert.False(t, token.HasScope(ScopeRoomsWrite))
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	assert.Equal(t,
+		[]string{ScopeRoomsRead, ScopeCharactersWrite, ScopeRollsWrite, "unknown"},
+		NormalizeScopes([]string{" Rolls:Write", "unknown", "rooms:read", "characters:write", "rooms:read", ""}))
+}
+
+func TestValidateAPIToken(t *testing.T) {
+	scopes := []string{ScopeRoomsRead}
+	tests := []struct {
+		name   string
+		token  string
+		scopes []string
+		days   int
+		field  string
+		code   string
+	}{
+		{"valid", "bot", scopes, DefaultTokenExpiresIn, "", ""},
+		{"blank name", " ", scopes, 1, "name", validation.CodeRequired},
+		{"long name", strings.Repeat("n", MaxTokenNameLength+1), scopes, 1, "name", validation.CodeTooLong},
+		{"no scopes", "bot", nil, 1, "scopes", validation.CodeRequired},
+		{"unknown scope", "bot", []string{"rooms:delete"}, 1, "scopes", validation.CodeInvalidChoice},
+		{"expiry too long", "bot", scopes, MaxTokenExpiresIn + 1, "expires_in_days", validation.CodeOutOfRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateAPIToken(tt.token, tt.scopes, tt.days)
+			if tt.field == "" {
+				assert.Empty(t, errs)
+				return
+			}
+			require.Len(t, errs, 1)
+			assert.Equal(t, tt.field, errs[0].Field)
+			assert.Equal(t, tt.code, errs[0].Code)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package user
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+	"trpg-sync/backend/domain/validation"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 用户字段限制，bcrypt 只使用密码的前 72 个字节
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+	MaxNicknameLength = 50
+	MaxAvatarLength   = 500
+	MaxEmailLength    = 254
+)
+
+// PasswordCost bcrypt 计算强度
+const PasswordCost = bcrypt.DefaultCost
+
+type User struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Email     string    `json:"email" gorm:"uniqueIndex;not null"`
+	Password  string    `json:"-" gorm:"not null"`
+	Nickname  string    `json:"nickname" gorm:"not null"`
+	Avatar    string    `json:"avatar"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (User) TableName() string {
+	return "users"
+}
+
+// NormalizeEmail 去掉首尾空白并转为小写，注册和登录都按规范化后的邮箱匹配
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ValidatePassword 校验明文密码长度
+func ValidatePassword(field, password string) []validation.FieldError {
+	switch {
+	case len([]rune(password)) < MinPasswordLength:
+		return []validation.FieldError{validation.NewError(field, validation.CodeTooSmall, map[string]interface{}{"min": MinPasswordLength})}
+	case len(password) > MaxPasswordLength:
+		return []validation.FieldError{validation.NewError(field, validation.CodeTooLong, map[string]interface{}{"max": MaxPasswordLength})}
+	}
+	return nil
+}
+
+// Validate 校验邮箱、昵称和头像，密码在设置时由 ValidatePassword 校验
+func (u *User) Validate() []validation.FieldError {
+	var errs []validation.FieldError
+
+	switch {
+	case u.Email == "":
+		errs = append(errs, validation.NewError("email", validation.CodeRequired, nil))
+	case len(u.Email) > MaxEmailLength:
+		errs = append(errs, validation.NewError("email", validation.CodeTooLong, map[string]interface{}{"max": MaxEmailLength}))
+	case !isEmail(u.Email):
+		errs = append(errs, validation.NewError("email", validation.CodeInvalidEmail, nil))
+	}
+
+	nickname := strings.TrimSpace(u.Nickname)
+	switch {
+	case nickname == "":
+		errs = append(errs, validation.NewError("nickname", validation.CodeRequired, nil))
+	case len([]rune(nickname)) > MaxNicknameLength:
+		errs = append(errs, validation.NewError("nickname", validation.CodeTooLong, map[string]interface{}{"max": MaxNicknameLength}))
+	}
+
+	if len(u.Avatar) > MaxAvatarLength {
+		errs = append(errs, validation.NewError("avatar", validation.CodeTooLong, map[string]interface{}{"max": MaxAvatarLength}))
+	}
+	return errs
+}
+
+// isEmail 只接受不带显示名的纯地址，如 a@example.com
+func isEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email && strings.Contains(email[strings.LastIndex(email, "@"):], ".")
+}
+
+// SetPassword 使用 bcrypt 保存密码哈希
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hash)
+	return nil
+}
+
+// CheckPassword 校验明文密码是否与哈希匹配
+func (u *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) == nil
+}
+
+// dummyHash 用户不存在时也做一次 bcrypt 比较，避免通过响应时间判断邮箱是否已注册
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("trpg-sync-dummy-password"), PasswordCost)
+
+// CompareDummy 对不存在的用户执行一次等价耗时的密码比较，结果总是 false
+func CompareDummy(password string) bool {
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+	return false
+}
@@ -0,0 +1,67 @@
+package user
+
+import (
+	"strings"
+	"testing"
+	"trpg-sync/backend/domain/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUser_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  User
+		field string
+		code  string
+	}{
+		{"valid", User{Email: "player@example.com", Nickname: "Player"}, "", ""},
+		{"missing email", User{Nickname: "Player"}, "email", validation.CodeRequired},
+		{"invalid email", User{Email: "player", Nickname: "Player"}, "email", validation.CodeInvalidEmail},
+		{"display name", User{Email: "Player <player@example.com>", Nickname: "Player"}, "email", validation.CodeInvalidEmail},
+		{"no domain dot", User{Email: "player@localhost", Nickname: "Player"}, "email", validation.CodeInvalidEmail},
+		{"blank nickname", User{Email: "player@example.com", Nickname: "  "}, "nickname", validation.CodeRequired},
+		{"long nickname", User{Email: "player@example.com", Nickname: strings.Repeat("龙", MaxNicknameLength+1)}, "nickname", validation.CodeTooLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := tt.user.Validate()
+			if tt.field == "" {
+				assert.Empty(t, errs)
+				return
+			}
+			require.Len(t, errs, 1)
+			assert.Equal(t, tt.field, errs[0].Field)
+			assert.Equal(t, tt.code, errs[0].Code)
+		})
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	assert.Empty(t, ValidatePassword("password", "correct horse"))
+
+	errs := ValidatePassword("password", "short")
+	require.Len(t, errs, 1)
+	assert.Equal(t, validation.CodeTooSmall, errs[0].Code)
+
+	// bcrypt 只使用前 72 个字节，按字节计算上限
+	errs = ValidatePassword("password", strings.Repeat("密", 25))
+	require.Len(t, errs, 1)
+	assert.Equal(t, validation.CodeTooLong, errs[0].Code)
+}
+
+func TestUser_Password(t *testing.T) {
+	u := User{}
+	require.NoError(t, u.SetPassword("correct horse"))
+	assert.NotEqual(t, "correct horse", u.Password)
+	assert.True(t, strings.HasPrefix(u.Password, "$2a$10$"))
+
+	assert.True(t, u.CheckPassword("correct horse"))
+	assert.False(t, u.CheckPassword("wrong horse"))
+	assert.False(t, CompareDummy("correct horse"))
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "player@example.com", NormalizeEmail("  Player@Example.COM "))
+}
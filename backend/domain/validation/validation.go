@@ -0,0 +1,141 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 校验错误码，前端根据错误码和字段名高亮输入框
+const (
+	CodeRequired              = "required"
+	CodeInvalidJSON           = "invalid_json"
+	CodeInvalidType           = "invalid_type"
+	CodeInvalidChoice         = "invalid_choice"
+	CodeOutOfRange            = "out_of_range"
+	CodeTooSmall              = "too_small"
+	CodeTooLong               = "too_long"
+	CodeExceedsMax            = "exceeds_max"
+	CodeUnknownAbility        = "unknown_ability"
+	CodePointBuyRange         = "point_buy_range"
+	CodePointBuyBudget        = "point_buy_budget"
+	CodeStandardArrayMismatch = "standard_array_mismatch"
+	CodeRollMissing           = "roll_missing"
+	CodeRollMismatch          = "roll_mismatch"
+	CodeInvalidCursor         = "invalid_cursor"
+	CodeInvalidEmail          = "invalid_email"
+	CodeBonusChoices          = "bonus_choices"
+	CodeUnsupported           = "unsupported"
+)
+
+// 支持的语言
+const (
+	LangEN = "en"
+	LangZH = "zh"
+)
+
+// FieldError 单个字段的校验错误，Params 为消息模板中的参数
+type FieldError struct {
+	Field   string                 `json:"field"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+}
+
+// NewError 创建字段错误，消息默认使用英文
+func NewError(field, code string, params map[string]interface{}) FieldError {
+	err := FieldError{Field: field, Code: code, Params: params}
+	err.Message = message(LangEN, code, params)
+	return err
+}
+
+// Localize 按语言重新生成错误消息
+func Localize(errs []FieldError, lang string) []FieldError {
+	result := make([]FieldError, len(errs))
+	for i, err := range errs {
+		err.Message = message(lang, err.Code, err.Params)
+		result[i] = err
+	}
+	return result
+}
+
+// ParseLanguage 根据 Accept-Language 请求头选择语言，默认英文
+func ParseLanguage(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		switch {
+		case strings.HasPrefix(tag, LangZH):
+			return LangZH
+		case strings.HasPrefix(tag, LangEN):
+			return LangEN
+		}
+	}
+	return LangEN
+}
+
+// Summary 返回校验失败时的总体消息
+func Summary(lang string) string {
+	if lang == LangZH {
+		return "参数校验失败"
+	}
+	return "Validation failed"
+}
+
+var messages = map[string]map[string]string{
+	LangEN: {
+		CodeRequired:              "is required",
+		CodeInvalidJSON:           "request body is not valid JSON",
+		CodeInvalidType:           "must be of type {type}",
+		CodeInvalidChoice:         "must be one of {choices}",
+		CodeOutOfRange:            "must be between {min} and {max}",
+		CodeTooSmall:              "must be at least {min}",
+		CodeTooLong:               "must be at most {max} characters",
+		CodeExceedsMax:            "must not exceed {other}",
+		CodeUnknownAbility:        "is not a known ability",
+		CodePointBuyRange:         "base score must be between 8 and 15 for point buy",
+		CodePointBuyBudget:        "point buy costs {cost} points, budget is {budget}",
+		CodeStandardArrayMismatch: "base scores must be the standard array 15, 14, 13, 12, 10, 8",
+		CodeRollMissing:           "character has no server ability roll",
+		CodeRollMismatch:          "base scores must be an arrangement of the rolled values {values}",
+		CodeInvalidCursor:         "is not a valid pagination cursor",
+		CodeInvalidEmail:          "is not a valid email address",
+		CodeBonusChoices:          "the race allows only {choices} freely assigned +1 ability bonuses",
+		CodeUnsupported:           "is not supported yet",
+	},
+	LangZH: {
+		CodeRequired:              "不能为空",
+		CodeInvalidJSON:           "请求体不是合法的 JSON",
+		CodeInvalidType:           "类型必须为 {type}",
+		CodeInvalidChoice:         "必须是 {choices} 之一",
+		CodeOutOfRange:            "必须在 {min} 到 {max} 之间",
+		CodeTooSmall:              "不能小于 {min}",
+		CodeTooLong:               "长度不能超过 {max} 个字符",
+		CodeExceedsMax:            "不能超过 {other}",
+		CodeUnknownAbility:        "不是有效的属性",
+		CodePointBuyRange:         "购点法的基础属性值必须在 8 到 15 之间",
+		CodePointBuyBudget:        "购点花费 {cost} 点，超出预算 {budget} 点",
+		CodeStandardArrayMismatch: "基础属性值必须是标准属性组 15、14、13、12、10、8",
+		CodeRollMissing:           "人物卡没有服务器掷骰记录",
+		CodeRollMismatch:          "基础属性值必须是掷骰结果 {values} 的重新排列",
+		CodeInvalidCursor:         "不是有效的分页游标",
+		CodeInvalidEmail:          "不是有效的邮箱地址",
+		CodeBonusChoices:          "种族只允许自选 {choices} 项 +1 属性加值",
+		CodeUnsupported:           "暂不支持",
+	},
+}
+
+// message 按语言和错误码生成消息，未知错误码直接返回错误码
+func message(lang, code string, params map[string]interface{}) string {
+	catalog, ok := messages[lang]
+	if !ok {
+		catalog = messages[LangEN]
+	}
+	template, ok := catalog[code]
+	if !ok {
+		return code
+	}
+
+	for key, value := range params {
+		template = strings.ReplaceAll(template, "{"+key+"}", fmt.Sprint(value))
+	}
+	return template
+}
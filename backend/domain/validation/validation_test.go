@@ -0,0 +1,28 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLanguage(t *testing.T) {
+	assert.Equal(t, LangZH, ParseLanguage("zh-CN,zh;q=0.9,en;q=0.8"))
+	assert.Equal(t, LangEN, ParseLanguage("en-GB,zh;q=0.5"))
+	assert.Equal(t, LangZH, ParseLanguage("fr-FR, zh-TW;q=0.7"))
+	assert.Equal(t, LangEN, ParseLanguage(""))
+}
+
+func TestLocalize(t *testing.T) {
+	errs := []FieldError{
+		NewError("level", CodeOutOfRange, map[string]interface{}{"min": 1, "max": 20}),
+		NewError("custom", "custom_code", nil),
+	}
+	assert.Equal(t, "must be between 1 and 20", errs[0].Message)
+
+	zh := Localize(errs, LangZH)
+	assert.Equal(t, "必须在 1 到 20 之间", zh[0].Message)
+	assert.Equal(t, "custom_code", zh[1].Message)
+	// 原列表不受影响
+	assert.Equal(t, "must be between 1 and 20", errs[0].Message)
+}
@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"log"
+	"time"
+	"trpg-sync/backend/domain/audit"
+	"trpg-sync/backend/domain/event"
+
+	"gorm.io/gorm"
+)
+
+// pruneInterval 按保留期限清理审计日志的间隔
+const pruneInterval = 24 * time.Hour
+
+// Record 订阅领域事件总线，把房间、成员和人物卡的变更写入审计日志
+// 写入失败只记录日志，不影响已完成的操作
+func Record(bus *event.Bus, db *gorm.DB) {
+	bus.SubscribeWithMeta(func(meta event.Meta, e event.Event) {
+		entry, ok := entryFor(meta, e)
+		if !ok {
+			return
+		}
+		if err := db.Create(&entry).Error; err != nil {
+			log.Printf("audit: failed to record %s: %v", e.Name(), err)
+		}
+	})
+}
+
+// entryFor 把领域事件转换为审计记录，不需要记录的事件返回 false
+func entryFor(meta event.Meta, e event.Event) (audit.Entry, bool) {
+	entry := audit.Entry{RoomID: e.RoomID(), ActorID: meta.ActorID, Action: e.Name(), IP: meta.IP}
+	var before, after interface{}
+	switch e := e.(type) {
+	case event.RoomCreated:
+		entry.EntityType, entry.EntityID = audit.EntityRoom, e.Room.ID
+		after = e.Room
+	case event.RoomUpdated:
+		entry.EntityType, entry.EntityID = audit.EntityRoom, e.After.ID
+		before, after = e.Before, e.After
+	case event.RoomDeleted:
+		entry.EntityType, entry.EntityID = audit.EntityRoom, e.Room.ID
+		before = e.Room
+	case event.DMTransferRequested:
+		entry.ActorID = e.Transfer.FromUserID
+		entry.EntityType, entry.EntityID = audit.EntityRoom, e.Transfer.RoomID
+		entry.Details = transferDetails(e.Transfer.FromUserID, e.Transfer.ToUserID)
+	case event.DMTransferCancelled:
+		entry.ActorID = e.CancelledBy
+		entry.EntityType, entry.EntityID = audit.EntityRoom, e.Transfer.RoomID
+		entry.Details = transferDetails(e.Transfer.FromUserID, e.Transfer.ToUserID)
+	case event.DMTransferred:
+		entry.ActorID = e.Transfer.ToUserID
+		entry.EntityType, entry.EntityID = audit.EntityRoom, e.Transfer.RoomID
+		entry.Details = transferDetails(e.Transfer.FromUserID, e.Transfer.ToUserID)
+	case event.MemberJoined:
+		entry.EntityType, entry.EntityID = audit.EntityMember, e.Member.UserID
+		after = e.Member
+	case event.MemberLeft:
+		entry.EntityType, entry.EntityID = audit.EntityMember, e.Member.UserID
+		before = e.Member
+	case event.MemberKicked:
+		entry.ActorID = e.KickedBy
+		entry.EntityType, entry.EntityID = audit.EntityMember, e.Member.UserID
+		before = e.Member
+		entry.Details = map[string]interface{}{
+			"cards":      e.Cards,
+			"characters": e.Characters,
+		}
+	case event.CharacterCreated:
+		entry.EntityType, entry.EntityID = audit.EntityCharacter, e.Card.ID
+		after = e.Card
+	case event.CharacterUpdated:
+		entry.EntityType, entry.EntityID = audit.EntityCharacter, e.After.ID
+		before, after = e.Before, e.After
+	case event.CharacterDeleted:
+		entry.EntityType, entry.EntityID = audit.EntityCharacter, e.CharacterID
+		if e.Card != nil {
+			before = e.Card
+		}
+	default:
+		return entry, false
+	}
+
+	if before != nil || after != nil {
+		changes, err := audit.Diff(before, after)
+		if err != nil {
+			log.Printf("audit: failed to diff %s: %v", e.Name(), err)
+		}
+		entry.Changes = changes
+	}
+	return entry, true
+}
+
+func transferDetails(from, to uint) map[string]interface{} {
+	return map[string]interface{}{"from_user_id": from, "to_user_id": to}
+}
+
+// Prune 删除早于 before 的审计日志，返回删除的条数
+func Prune(db *gorm.DB, before time.Time) (int64, error) {
+	result := db.Where("created_at < ?", before).Delete(&audit.Entry{})
+	return result.RowsAffected, result.Error
+}
+
+// StartRetention 在后台按保留期限定期清理审计日志，启动时先清理一次
+// retention 小于等于 0 表示永久保留，不启动清理
+func StartRetention(db *gorm.DB, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+	go func() {
+		for {
+			if count, err := Prune(db, time.Now().Add(-retention)); err != nil {
+				log.Printf("audit: failed to prune entries: %v", err)
+			} else if count > 0 {
+				log.Printf("audit: pruned %d entries older than %s", count, retention)
+			}
+			time.Sleep(pruneInterval)
+		}
+	}()
+}
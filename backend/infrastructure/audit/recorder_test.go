@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"trpg-sync/backend/domain/audit"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&audit.Entry{}))
+
+	bus := event.NewBus()
+	Record(bus, db)
+
+	meta := event.Meta{ActorID: 4, IP: "203.0.113.9"}
+	bus.PublishFrom(meta, event.RoomUpdated{Before: room.Room{ID: 2, Name: "Old"}, After: room.Room{ID: 2, Name: "New"}})
+	// 不属于房间和人物卡的事件不记录
+	bus.PublishFrom(meta, event.RollMade{Room: 2})
+
+	var entries []audit.Entry
+	require.NoError(t, db.Find(&entries).Error)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "room.updated", entries[0].Action)
+	assert.Equal(t, uint(4), entries[0].ActorID)
+	assert.Equal(t, "203.0.113.9", entries[0].IP)
+	assert.Equal(t, map[string]audit.Change{"name": {Before: "Old", After: "New"}}, entries[0].Changes)
+}
+
+func TestPrune(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&audit.Entry{}))
+
+	now := time.Now()
+	require.NoError(t, db.Create(&[]audit.Entry{
+		{Action: "room.created", EntityType: audit.EntityRoom, CreatedAt: now.Add(-100 * 24 * time.Hour)},
+		{Action: "room.updated", EntityType: audit.EntityRoom, CreatedAt: now},
+	}).Error)
+
+	count, err := Prune(db, now.Add(-90*24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	var remaining []string
+	db.Model(&audit.Entry{}).Pluck("action", &remaining)
+	assert.Equal(t, []string{"room.updated"}, remaining)
+}
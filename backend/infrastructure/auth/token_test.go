@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssuer_IssueAndParse(t *testing.T) {
+	issuer := NewIssuer([]byte("secret"), time.Hour, 48*time.Hour)
+	pair, err := issuer.Issue(42)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", pair.TokenType)
+
+	claims, err := issuer.Parse(pair.AccessToken, TokenAccess)
+	require.NoError(t, err)
+	id, err := claims.UserID()
+	require.NoError(t, err)
+	assert.Equal(t, uint(42), id)
+	assert.Equal(t, claims.IssuedAt+3600, claims.ExpiresAt)
+
+	claims, err = issuer.Parse(pair.RefreshToken, TokenRefresh)
+	require.NoError(t, err)
+	assert.Equal(t, claims.IssuedAt+48*3600, claims.ExpiresAt)
+
+	// 令牌类型不能混用
+	_, err = issuer.Parse(pair.RefreshToken, TokenAccess)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = issuer.Parse(pair.AccessToken, TokenRefresh)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestIssuer_Parse_Rejects(t *testing.T) {
+	issuer := NewIssuer([]byte("secret"), time.Hour, 0)
+	pair, err := issuer.Issue(1)
+	require.NoError(t, err)
+
+	parts := strings.Split(pair.AccessToken, ".")
+	tests := map[string]string{
+		"empty":          "",
+		"malformed":      "abc.def",
+		"other secret":   mustIssue(t, NewIssuer([]byte("other"), time.Hour, 0)),
+		"tampered body":  parts[0] + "." + parts[1] + "x." + parts[2],
+		"none algorithm": "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0." + parts[1] + ".",
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := issuer.Parse(token, TokenAccess)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestIssuer_Parse_Expired(t *testing.T) {
+	issuer := NewIssuer([]byte("secret"), time.Hour, 0)
+	start := time.Now()
+	issuer.now = func() time.Time { return start }
+	pair, err := issuer.Issue(1)
+	require.NoError(t, err)
+
+	issuer.now = func() time.Time { return start.Add(time.Hour) }
+	_, err = issuer.Parse(pair.AccessToken, TokenAccess)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	_, err = issuer.Parse(pair.RefreshToken, TokenRefresh)
+	assert.NoError(t, err)
+}
+
+func mustIssue(t *testing.T, issuer *Issuer) string {
+	t.Helper()
+	pair, err := issuer.Issue(1)
+	require.NoError(t, err)
+	return pair.AccessToken
+}
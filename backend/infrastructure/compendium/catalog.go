@@ -0,0 +1,71 @@
+package compendium
+
+import (
+	"fmt"
+	"trpg-sync/backend/domain/compendium"
+	"trpg-sync/backend/domain/homebrew"
+
+	"gorm.io/gorm"
+)
+
+// Catalog 合并内置 SRD 数据与数据库中的自制内容
+type Catalog struct {
+	store *Store
+	db    *gorm.DB
+}
+
+func NewCatalog(store *Store, db *gorm.DB) *Catalog {
+	return &Catalog{store: store, db: db}
+}
+
+// List 返回指定类型的全部条目：SRD 数据、全局自制内容以及房间自制内容
+// roomID 为 0 时只包含全局自制内容
+func (c *Catalog) List(t compendium.EntryType, roomID uint) ([]compendium.Entry, error) {
+	brews, err := c.homebrew(t, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	srd := c.store.List(t)
+	entries := make([]compendium.Entry, 0, len(srd)+len(brews))
+	entries = append(entries, srd...)
+	for _, brew := range brews {
+		entries = append(entries, brew.ToCompendium())
+	}
+	return entries, nil
+}
+
+// Get 按 slug 获取条目，优先级：房间自制内容 > 全局自制内容 > SRD
+func (c *Catalog) Get(t compendium.EntryType, slug string, roomID uint) (compendium.Entry, bool, error) {
+	var brews []homebrew.Entry
+	if err := c.db.Where("type = ? AND slug = ? AND room_id IN ?", string(t), slug, roomScope(roomID)).
+		Order("room_id DESC").
+		Limit(1).
+		Find(&brews).Error; err != nil {
+		return compendium.Entry{}, false, fmt.Errorf("failed to query homebrew: %w", err)
+	}
+	if len(brews) > 0 {
+		return brews[0].ToCompendium(), true, nil
+	}
+
+	entry, ok := c.store.Get(t, slug)
+	return entry, ok, nil
+}
+
+func (c *Catalog) homebrew(t compendium.EntryType, roomID uint) ([]homebrew.Entry, error) {
+	var brews []homebrew.Entry
+	if err := c.db.Where("type = ? AND room_id IN ?", string(t), roomScope(roomID)).
+		Order("id").
+		Find(&brews).Error; err != nil {
+		return nil, fmt.Errorf("failed to query homebrew: %w", err)
+	}
+	return brews, nil
+}
+
+// roomScope 返回查询自制内容时可见的房间范围
+func roomScope(roomID uint) []uint {
+	if roomID == homebrew.GlobalRoomID {
+		return []uint{homebrew.GlobalRoomID}
+	}
+	return []uint{homebrew.GlobalRoomID, roomID}
+}
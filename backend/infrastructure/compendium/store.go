@@ -0,0 +1,106 @@
+package compendium
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"trpg-sync/backend/domain/compendium"
+)
+
+// srdFS 内置的 SRD 5.1 数据，每种条目类型一个 JSON 文件
+//
+//go:embed srd/*.json
+var srdFS embed.FS
+
+// Store 内存中的只读资料库
+type Store struct {
+	entries map[compendium.EntryType][]compendium.Entry
+	index   map[compendium.EntryType]map[string]int
+}
+
+var (
+	defaultStore *Store
+	defaultOnce  sync.Once
+)
+
+// Default 返回加载了内置 SRD 数据的全局资料库
+func Default() *Store {
+	defaultOnce.Do(func() {
+		store, err := LoadSRD()
+		if err != nil {
+			log.Fatalf("Failed to load SRD compendium: %v", err)
+		}
+		defaultStore = store
+	})
+	return defaultStore
+}
+
+// LoadSRD 从内置文件加载 SRD 数据
+func LoadSRD() (*Store, error) {
+	store := &Store{
+		entries: make(map[compendium.EntryType][]compendium.Entry),
+		index:   make(map[compendium.EntryType]map[string]int),
+	}
+
+	for _, t := range compendium.Types() {
+		data, err := srdFS.ReadFile("srd/" + string(t) + ".json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", t, err)
+		}
+
+		var entries []compendium.Entry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", t, err)
+		}
+
+		if err := store.add(t, entries); err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+// add 写入条目并补全类型和来源
+func (s *Store) add(t compendium.EntryType, entries []compendium.Entry) error {
+	index := make(map[string]int, len(entries))
+	for i := range entries {
+		entries[i].Type = t
+		if entries[i].Source == "" {
+			entries[i].Source = compendium.SourceSRD
+		}
+		if _, exists := index[entries[i].Slug]; exists {
+			return fmt.Errorf("duplicate %s slug: %s", t, entries[i].Slug)
+		}
+		index[entries[i].Slug] = i
+	}
+
+	s.entries[t] = entries
+	s.index[t] = index
+	return nil
+}
+
+// List 返回指定类型的全部条目
+func (s *Store) List(t compendium.EntryType) []compendium.Entry {
+	return s.entries[t]
+}
+
+// Get 按 slug 获取条目
+func (s *Store) Get(t compendium.EntryType, slug string) (compendium.Entry, bool) {
+	i, ok := s.index[t][slug]
+	if !ok {
+		return compendium.Entry{}, false
+	}
+	return s.entries[t][i], true
+}
+
+// Counts 返回每种类型的条目数量
+func (s *Store) Counts() map[compendium.EntryType]int {
+	counts := make(map[compendium.EntryType]int, len(s.entries))
+	for t, entries := range s.entries {
+		counts[t] = len(entries)
+	}
+	return counts
+}
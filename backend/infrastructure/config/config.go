@@ -3,15 +3,18 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server ServerConfig
+	Server   ServerConfig
 	Database DatabaseConfig
-	CORS    CORSConfig
-	Log     LogConfig
+	CORS     CORSConfig
+	Log      LogConfig
+	Auth     AuthConfig
+	Audit    AuditConfig
 }
 
 type ServerConfig struct {
@@ -32,6 +35,20 @@ type LogConfig struct {
 	Format string
 }
 
+// AuthConfig 登录认证配置，Enabled 为 false 时为单人本地模式，接口不要求登录
+// 开启认证时必须配置 JWTSecret，否则服务重启后已签发的令牌全部失效
+type AuthConfig struct {
+	Enabled    bool
+	JWTSecret  string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// AuditConfig 审计日志配置，Retention 为保留期限，小于等于 0 表示永久保留
+type AuditConfig struct {
+	Retention time.Duration
+}
+
 func LoadConfig() (*Config, error) {
 	viper.SetConfigFile(".env")
 	viper.SetConfigType("env")
@@ -57,6 +74,15 @@ func LoadConfig() (*Config, error) {
 			Level:  getEnv("LOG_LEVEL", "debug"),
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
+		Auth: AuthConfig{
+			Enabled:    getEnvBool("AUTH_ENABLED", false),
+			JWTSecret:  getEnv("JWT_SECRET", ""),
+			AccessTTL:  getEnvDuration("JWT_ACCESS_TTL", 24*time.Hour),
+			RefreshTTL: getEnvDuration("JWT_REFRESH_TTL", 30*24*time.Hour),
+		},
+		Audit: AuditConfig{
+			Retention: getEnvDuration("AUDIT_RETENTION", 90*24*time.Hour),
+		},
 	}
 
 	return cfg, nil
@@ -77,3 +103,21 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if durationVal, err := time.ParseDuration(value); err == nil {
+			return durationVal
+		}
+	}
+	return defaultValue
+}
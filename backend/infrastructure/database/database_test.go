@@ -1,11 +1,18 @@
 package database
 
 import (
+	"path/filepath"
 	"testing"
+	"time"
 
+	"trpg-sync/backend/domain/audit"
+	"trpg-sync/backend/domain/campaign"
+	"trpg-sync/backend/domain/room"
 	"trpg-sync/backend/infrastructure/config"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 func TestInitDB(t *testing.T) {
@@ -19,23 +26,20 @@ func TestInitDB(t *testing.T) {
 			name: "成功初始化数据库",
 			cfg: &config.Config{
 				Database: config.DatabaseConfig{
-					Host:     "localhost",
-					Port:     "5432",
-					User:     "test",
-					Password: "test",
-					DBName:   "testdb",
-					SSLMode:  "disable",
+					Path: filepath.Join(t.TempDir(), "test.db"),
 				},
 				Log: config.LogConfig{
 					Level: "silent",
 				},
 			},
-			expectError: true, // 会失败，因为没有实际的 PostgreSQL 实例
+			expectError: false,
 		},
 		{
-			name: "配置为空",
+			name: "数据库目录不存在",
 			cfg: &config.Config{
-				Database: config.DatabaseConfig{},
+				Database: config.DatabaseConfig{
+					Path: filepath.Join(t.TempDir(), "missing", "test.db"),
+				},
 			},
 			expectError: true,
 		},
@@ -55,3 +59,117 @@ func TestInitDB(t *testing.T) {
 		})
 	}
 }
+
+func TestMigrate_TemplateOwners(t *testing.T) {
+	db, err := InitDB(&config.Config{
+		Database: config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "templates.db")},
+		Log:      config.LogConfig{Level: "silent"},
+	})
+	require.NoError(t, err)
+
+	// 旧版本的模板没有所有者；房间 1 有 DM，房间 2 没有
+	require.NoError(t, db.Exec(`CREATE TABLE room_templates (
+		id integer PRIMARY KEY AUTOINCREMENT,
+		name text NOT NULL,
+		source_room_id integer
+	)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO room_templates (name, source_room_id) VALUES ('Owned', 1), ('Open', 2)`).Error)
+	require.NoError(t, db.AutoMigrate(&room.Member{}))
+	require.NoError(t, db.Create(&[]room.Member{
+		{RoomID: 1, UserID: 7, Role: room.RoleDM},
+		{RoomID: 1, UserID: 8, Role: room.RolePlayer},
+		{RoomID: 2, UserID: 8, Role: room.RolePlayer},
+	}).Error)
+
+	require.NoError(t, Migrate(db))
+
+	var templates []campaign.Template
+	require.NoError(t, db.Order("id").Find(&templates).Error)
+	require.Len(t, templates, 2)
+	assert.Equal(t, uint(7), templates[0].OwnerID)
+	assert.Zero(t, templates[1].OwnerID)
+}
+
+func TestMigrate_LegacyRooms(t *testing.T) {
+	db, err := InitDB(&config.Config{
+		Database: config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "legacy.db")},
+		Log:      config.LogConfig{Level: "silent"},
+	})
+	require.NoError(t, err)
+
+	// 旧版本的房间表，没有状态、标签等元数据列
+	require.NoError(t, db.Exec(`CREATE TABLE rooms (
+		id integer PRIMARY KEY AUTOINCREMENT,
+		name text NOT NULL,
+		description text,
+		rule_system text NOT NULL DEFAULT 'DND5e',
+		ability_method text NOT NULL DEFAULT 'free',
+		created_at datetime,
+		updated_at datetime
+	)`).Error)
+	require.NoError(t, db.Exec(`INSERT INTO rooms (name, description) VALUES ('Old Campaign', 'legacy')`).Error)
+
+	require.NoError(t, Migrate(db))
+	// 重复执行不会重复应用迁移
+	require.NoError(t, Migrate(db))
+
+	var migrated room.Room
+	require.NoError(t, db.First(&migrated).Error)
+	assert.Equal(t, "Old Campaign", migrated.Name)
+	assert.Equal(t, room.StatusActive, migrated.Status)
+	assert.Equal(t, []string{}, migrated.Tags)
+	assert.Equal(t, 0, migrated.PlayerCount)
+	assert.Nil(t, migrated.LastPlayedAt)
+	assert.Equal(t, room.DefaultMaxPlayers, migrated.MaxPlayers)
+	assert.Len(t, migrated.InviteCode, room.InviteCodeLength)
+	assert.False(t, migrated.HasPassword)
+
+	var records []SchemaMigration
+	require.NoError(t, db.Find(&records).Error)
+	require.Len(t, records, len(migrations))
+	assert.Equal(t, 1, records[0].Version)
+	assert.Equal(t, "room_metadata", records[0].Name)
+}
+
+func TestMigrate_AuditLogAppendOnly(t *testing.T) {
+	db, err := InitDB(&config.Config{
+		Database: config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "audit.db")},
+		Log:      config.LogConfig{Level: "silent"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, Migrate(db))
+
+	entry := audit.Entry{RoomID: 1, Action: "room.deleted", EntityType: audit.EntityRoom, EntityID: 1}
+	require.NoError(t, db.Create(&entry).Error)
+
+	err = db.Model(&entry).Update("actor_id", 99).Error
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "append-only")
+
+	// 超过保留期限的记录可以删除
+	require.NoError(t, db.Where("created_at < ?", time.Now().Add(time.Hour)).Delete(&audit.Entry{}).Error)
+	var count int64
+	db.Model(&audit.Entry{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestRunMigrations_RollsBackFailedVersion(t *testing.T) {
+	db, err := InitDB(&config.Config{
+		Database: config.DatabaseConfig{Path: filepath.Join(t.TempDir(), "failed.db")},
+		Log:      config.LogConfig{Level: "silent"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&SchemaMigration{}))
+
+	err = runMigrations(db, []Migration{
+		{Version: 1, Name: "broken", Up: func(tx *gorm.DB) error {
+			return tx.Exec("UPDATE missing_table SET x = 1").Error
+		}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 1 (broken) failed")
+
+	var count int64
+	db.Model(&SchemaMigration{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
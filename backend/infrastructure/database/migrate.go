@@ -0,0 +1,186 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"trpg-sync/backend/domain/audit"
+	"trpg-sync/backend/domain/campaign"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/homebrew"
+	"trpg-sync/backend/domain/monster"
+	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/share"
+	"trpg-sync/backend/domain/user"
+	"trpg-sync/backend/infrastructure/search"
+	"trpg-sync/backend/infrastructure/storage"
+
+	"gorm.io/gorm"
+)
+
+// Migration 带版本号的数据迁移，在 AutoMigrate 同步表结构之后按版本顺序执行，每个版本只执行一次
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Models 返回需要同步表结构的模型（人物卡以 JSON 文件存储，不在数据库中）
+func Models() []interface{} {
+	return []interface{}{
+		&room.Room{},
+		&character.IndexEntry{},
+		&character.MemberRoll{},
+		&homebrew.Entry{},
+		&monster.StatBlock{},
+		&encounter.Encounter{},
+		&encounter.Combatant{},
+		&campaign.Template{},
+		&user.User{},
+		&room.Member{},
+		&room.Transfer{},
+		&audit.Entry{},
+		&share.Link{},
+		&user.APIToken{},
+	}
+}
+
+// migrations 按版本号递增排列，已发布的迁移不要修改，只追加新版本
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "room_metadata",
+		Up: func(tx *gorm.DB) error {
+			// 旧房间补齐状态和标签，新增列的默认值不会覆盖已有的空字符串
+			if err := tx.Model(&room.Room{}).
+				Where("status IS NULL OR status = ''").
+				Update("status", room.StatusActive).Error; err != nil {
+				return err
+			}
+			return tx.Model(&room.Room{}).
+				Where("tags IS NULL OR tags = ''").
+				Update("tags", "[]").Error
+		},
+	},
+	{
+		Version: 2,
+		Name:    "character_index",
+		Up: func(tx *gorm.DB) error {
+			// 从已有的人物卡文件生成索引
+			count, err := storage.NewCharacterIndex(tx).Rebuild(storage.NewCharacterStorage())
+			if err != nil {
+				return err
+			}
+			log.Printf("Indexed %d characters", count)
+			return nil
+		},
+	},
+	{
+		Version: 3,
+		Name:    "search_index",
+		Up: func(tx *gorm.DB) error {
+			// 全文索引：人物卡已在版本 2 重建人物卡索引时写入，这里只补齐房间
+			var rooms []room.Room
+			if err := tx.Find(&rooms).Error; err != nil {
+				return err
+			}
+			index := search.NewIndex(tx)
+			for i := range rooms {
+				if err := index.Put(search.RoomDocument(&rooms[i])); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 4,
+		Name:    "room_invite_codes",
+		Up: func(tx *gorm.DB) error {
+			// 旧房间生成邀请码
+			var rooms []room.Room
+			if err := tx.Where("invite_code IS NULL OR invite_code = ''").Find(&rooms).Error; err != nil {
+				return err
+			}
+			for i := range rooms {
+				rooms[i].RotateInviteCode()
+				if err := tx.Model(&rooms[i]).Update("invite_code", rooms[i].InviteCode).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 5,
+		Name:    "audit_log_append_only",
+		Up: func(tx *gorm.DB) error {
+			// 审计日志只追加，禁止修改；按保留期限删除不受影响
+			return tx.Exec(`CREATE TRIGGER IF NOT EXISTS audit_log_no_update
+				BEFORE UPDATE ON audit_log
+				BEGIN
+					SELECT RAISE(ABORT, 'audit_log is append-only');
+				END`).Error
+		},
+	},
+	{
+		Version: 6,
+		Name:    "template_owners",
+		Up: func(tx *gorm.DB) error {
+			// 旧模板没有所有者，源房间仍有 DM 时归该 DM 所有，否则保持所有人可见
+			return tx.Exec(`UPDATE room_templates SET owner_id = (
+					SELECT user_id FROM room_members
+					WHERE room_members.room_id = room_templates.source_room_id AND room_members.role = ?)
+				WHERE owner_id = 0 AND source_room_id IN (SELECT room_id FROM room_members WHERE role = ?)`,
+				room.RoleDM, room.RoleDM).Error
+		},
+	},
+}
+
+// Migrate 同步表结构并执行尚未执行的版本迁移
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(append(Models(), &SchemaMigration{})...); err != nil {
+		return fmt.Errorf("failed to auto migrate: %w", err)
+	}
+	return runMigrations(db, migrations)
+}
+
+func runMigrations(db *gorm.DB, list []Migration) error {
+	var applied []int
+	if err := db.Model(&SchemaMigration{}).Pluck("version", &applied).Error; err != nil {
+		return fmt.Errorf("failed to load applied migrations: %w", err)
+	}
+	done := make(map[int]bool, len(applied))
+	for _, version := range applied {
+		done[version] = true
+	}
+
+	for _, m := range list {
+		if done[m.Version] {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		log.Printf("Applied migration %d: %s", m.Version, m.Name)
+	}
+	return nil
+}
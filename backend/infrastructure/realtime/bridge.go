@@ -0,0 +1,64 @@
+package realtime
+
+import (
+	"trpg-sync/backend/domain/encounter"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/domain/room"
+)
+
+// TurnPayload 回合推进事件推送给客户端的内容
+type TurnPayload struct {
+	EncounterID uint                `json:"encounter_id"`
+	Round       int                 `json:"round"`
+	Combatant   encounter.Combatant `json:"combatant"`
+}
+
+// KickPayload 踢出玩家事件推送给客户端的内容，成员字段之外附带人物卡的处理方式
+type KickPayload struct {
+	room.Member
+	Cards      string `json:"cards"`
+	Characters []uint `json:"characters"`
+}
+
+// Forward 订阅领域事件总线，把客户端关心的事件转发到房间连接
+// 未列出的领域事件（如数据卡、自制内容变更）目前不推送；人物卡事件只推送 ID，同一房间的成员能查看的人物卡不同，
+// 客户端收到后按自己的权限通过接口重新获取；成员被踢出或退出后断开其连接
+func Forward(bus *event.Bus, hub *Hub) {
+	bus.SubscribeAll(func(e event.Event) {
+		switch e := e.(type) {
+		case event.CharacterCreated:
+			hub.Publish(e.RoomID(), EventCharacterCreated, map[string]uint{"id": e.Card.ID})
+		case event.CharacterUpdated:
+			hub.Publish(e.RoomID(), EventCharacterUpdated, map[string]uint{"id": e.After.ID})
+		case event.CharacterDeleted:
+			hub.Publish(e.RoomID(), EventCharacterDeleted, map[string]uint{"id": e.CharacterID})
+		case event.RollMade:
+			hub.Publish(e.RoomID(), EventRoll, e.Result)
+		case event.TurnAdvanced:
+			hub.Publish(e.RoomID(), EventEncounterTurn, TurnPayload{
+				EncounterID: e.Encounter.ID,
+				Round:       e.Encounter.Round,
+				Combatant:   e.Combatant,
+			})
+		case event.RoomUpdated:
+			hub.Publish(e.RoomID(), EventRoomUpdated, e.After)
+		case event.RoomDeleted:
+			hub.Publish(e.RoomID(), EventRoomDeleted, map[string]uint{"id": e.Room.ID})
+			hub.CloseRoom(e.RoomID())
+		case event.MemberJoined:
+			hub.Publish(e.RoomID(), EventMemberJoined, e.Member)
+		case event.MemberLeft:
+			hub.Publish(e.RoomID(), EventMemberLeft, e.Member)
+			hub.Disconnect(e.RoomID(), e.Member.UserID)
+		case event.MemberKicked:
+			hub.Publish(e.RoomID(), EventMemberKicked, KickPayload{Member: e.Member, Cards: e.Cards, Characters: e.Characters})
+			hub.Disconnect(e.RoomID(), e.Member.UserID)
+		case event.DMTransferRequested:
+			hub.Publish(e.RoomID(), EventTransferRequested, e.Transfer)
+		case event.DMTransferCancelled:
+			hub.Publish(e.RoomID(), EventTransferCancelled, e.Transfer)
+		case event.DMTransferred:
+			hub.Publish(e.RoomID(), EventDMTransferred, e.Transfer)
+		}
+	})
+}
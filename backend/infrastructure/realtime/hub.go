@@ -0,0 +1,213 @@
+package realtime
+
+import (
+	"sync"
+	"time"
+)
+
+// 房间事件类型
+const (
+	EventCharacterCreated  = "character.created"
+	EventCharacterUpdated  = "character.updated"
+	EventCharacterDeleted  = "character.deleted"
+	EventRoll              = "roll"
+	EventEncounterTurn     = "encounter.turn"
+	EventRoomUpdated       = "room.updated"
+	EventRoomDeleted       = "room.deleted"
+	EventMemberJoined      = "member.joined"
+	EventMemberLeft        = "member.left"
+	EventMemberKicked      = "member.kicked"
+	EventTransferRequested = "room.transfer_requested"
+	EventTransferCancelled = "room.transfer_cancelled"
+	EventDMTransferred     = "room.dm_transferred"
+)
+
+const (
+	// DefaultHistory 每个房间保留用于断线重放的事件数
+	DefaultHistory = 256
+	// subscriberBuffer 订阅者的发送缓冲，写满说明客户端跟不上，直接断开让其重连重放
+	subscriberBuffer = 64
+)
+
+// Event 房间内广播的事件，Seq 在房间内从 1 开始单调递增
+type Event struct {
+	Seq    uint64      `json:"seq"`
+	RoomID uint        `json:"room_id"`
+	Type   string      `json:"type"`
+	Data   interface{} `json:"data,omitempty"`
+	At     time.Time   `json:"at"`
+}
+
+// Replay 订阅时补发的事件
+// Complete 为 false 表示请求的序号已不在缓冲内（或服务重启过），客户端需要重新拉取完整状态
+type Replay struct {
+	Seq      uint64  `json:"seq"`
+	Events   []Event `json:"-"`
+	Complete bool    `json:"complete"`
+}
+
+// Hub 按房间分发事件，并为每个房间保留最近的事件用于重连后重放
+// 序号只保存在内存中，服务重启后从 1 重新计数
+type Hub struct {
+	mu      sync.Mutex
+	history int
+	rooms   map[uint]*channel
+}
+
+type channel struct {
+	seq         uint64
+	events      []Event
+	subscribers map[*Subscription]struct{}
+}
+
+// NewHub 创建事件中心，history 为每个房间保留的事件数
+func NewHub(history int) *Hub {
+	if history < 1 {
+		history = DefaultHistory
+	}
+	return &Hub{history: history, rooms: make(map[uint]*channel)}
+}
+
+func (h *Hub) room(roomID uint) *channel {
+	ch, ok := h.rooms[roomID]
+	if !ok {
+		ch = &channel{subscribers: make(map[*Subscription]struct{})}
+		h.rooms[roomID] = ch
+	}
+	return ch
+}
+
+// Publish 为事件分配序号并广播给房间内所有订阅者，Hub 为 nil 时不做任何事
+func (h *Hub) Publish(roomID uint, eventType string, data interface{}) Event {
+	if h == nil {
+		return Event{}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := h.room(roomID)
+	ch.seq++
+	event := Event{Seq: ch.seq, RoomID: roomID, Type: eventType, Data: data, At: time.Now()}
+
+	ch.events = append(ch.events, event)
+	if len(ch.events) > h.history {
+		ch.events = append([]Event(nil), ch.events[len(ch.events)-h.history:]...)
+	}
+
+	for sub := range ch.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			delete(ch.subscribers, sub)
+			sub.close()
+		}
+	}
+	return event
+}
+
+// Subscribe 以用户 userID（匿名为 0）的身份订阅房间事件，并在同一把锁内取出序号 since 之后的事件，保证重放与实时事件之间不丢不重
+func (h *Hub) Subscribe(roomID, userID uint, since uint64) (*Subscription, Replay) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := h.room(roomID)
+	sub := &Subscription{RoomID: roomID, UserID: userID, hub: h, events: make(chan Event, subscriberBuffer)}
+	ch.subscribers[sub] = struct{}{}
+
+	replay := Replay{Seq: ch.seq, Complete: true}
+	if since == 0 || since == ch.seq {
+		return sub, replay
+	}
+	if since > ch.seq || (len(ch.events) > 0 && since+1 < ch.events[0].Seq) {
+		replay.Complete = false
+		return sub, replay
+	}
+	for _, event := range ch.events {
+		if event.Seq > since {
+			replay.Events = append(replay.Events, event)
+		}
+	}
+	return sub, replay
+}
+
+// CloseRoom 断开房间内所有订阅并丢弃事件缓冲，用于房间被删除后
+func (h *Hub) CloseRoom(roomID uint) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch, ok := h.rooms[roomID]
+	if !ok {
+		return
+	}
+	for sub := range ch.subscribers {
+		sub.close()
+	}
+	delete(h.rooms, roomID)
+}
+
+// Disconnect 断开用户在房间内的全部订阅，用于成员被踢出或退出房间后；已发送的事件仍会先送达
+func (h *Hub) Disconnect(roomID, userID uint) {
+	if h == nil || userID == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch, ok := h.rooms[roomID]
+	if !ok {
+		return
+	}
+	for sub := range ch.subscribers {
+		if sub.UserID == userID {
+			delete(ch.subscribers, sub)
+			sub.close()
+		}
+	}
+}
+
+// Subscribers 返回房间当前的订阅数
+func (h *Hub) Subscribers(roomID uint) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if ch, ok := h.rooms[roomID]; ok {
+		return len(ch.subscribers)
+	}
+	return 0
+}
+
+// Subscription 一个房间订阅，Events 在订阅被关闭（取消订阅、发送缓冲写满或房间删除）时关闭
+type Subscription struct {
+	RoomID uint
+	UserID uint
+	hub    *Hub
+	events chan Event
+	once   sync.Once
+}
+
+// Events 返回实时事件通道
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if ch, ok := s.hub.rooms[s.RoomID]; ok {
+		delete(ch.subscribers, s)
+	}
+	s.close()
+}
+
+// close 关闭事件通道，调用方需持有 hub 的锁
+func (s *Subscription) close() {
+	s.once.Do(func() { close(s.events) })
+}
@@ -0,0 +1,85 @@
+package realtime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_Replay(t *testing.T) {
+	hub := NewHub(3)
+	for i := 0; i < 5; i++ {
+		hub.Publish(1, EventRoll, i)
+	}
+
+	sub, replay := hub.Subscribe(1, 0, 3)
+	defer sub.Close()
+	assert.True(t, replay.Complete)
+	assert.Equal(t, uint64(5), replay.Seq)
+	require.Len(t, replay.Events, 2)
+	assert.Equal(t, uint64(4), replay.Events[0].Seq)
+
+	// 缓冲只保留 3..5，序号 1 之后的事件已丢失
+	_, replay = hub.Subscribe(1, 0, 1)
+	assert.False(t, replay.Complete)
+	_, replay = hub.Subscribe(1, 0, 2)
+	assert.True(t, replay.Complete)
+	assert.Len(t, replay.Events, 3)
+
+	_, replay = hub.Subscribe(2, 0, 0)
+	assert.True(t, replay.Complete)
+	assert.Equal(t, uint64(0), replay.Seq)
+}
+
+func TestHub_SlowSubscriber(t *testing.T) {
+	hub := NewHub(DefaultHistory)
+	slow, _ := hub.Subscribe(1, 0, 0)
+	other, _ := hub.Subscribe(2, 0, 0)
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.Publish(1, EventRoll, i)
+	}
+
+	received := 0
+	for range slow.Events() {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+	assert.Equal(t, 0, hub.Subscribers(1))
+	assert.Equal(t, 1, hub.Subscribers(2))
+
+	hub.CloseRoom(2)
+	_, open := <-other.Events()
+	assert.False(t, open)
+	other.Close()
+
+	var nilHub *Hub
+	assert.Equal(t, Event{}, nilHub.Publish(1, EventRoll, nil))
+}
+
+func TestHub_Disconnect(t *testing.T) {
+	hub := NewHub(DefaultHistory)
+	kicked, _ := hub.Subscribe(1, 7, 0)
+	kickedElsewhere, _ := hub.Subscribe(2, 7, 0)
+	anonymous, _ := hub.Subscribe(1, 0, 0)
+	defer anonymous.Close()
+	defer kickedElsewhere.Close()
+
+	hub.Publish(1, EventMemberKicked, nil)
+	hub.Disconnect(1, 7)
+	hub.Disconnect(1, 0)
+
+	event, open := <-kicked.Events()
+	assert.True(t, open)
+	assert.Equal(t, EventMemberKicked, event.Type)
+	_, open = <-kicked.Events()
+	assert.False(t, open)
+	kicked.Close()
+
+	assert.Equal(t, 1, hub.Subscribers(1))
+	assert.Equal(t, 1, hub.Subscribers(2))
+
+	var nilHub *Hub
+	nilHub.Disconnect(1, 7)
+}
@@ -0,0 +1,267 @@
+package search
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"sync"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/domain/room"
+
+	"gorm.io/gorm"
+)
+
+// 索引中的实体类型
+const (
+	TypeRoom      = "room"
+	TypeCharacter = "character"
+)
+
+// Types 搜索结果分组的顺序
+var Types = []string{TypeRoom, TypeCharacter}
+
+// 高亮标记先用控制字符占位，转义 HTML 后再替换为 <mark>
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+const tableName = "search_index"
+
+// Document 全文索引中的一条记录，ID 在同一实体类型内唯一
+type Document struct {
+	Type   string
+	ID     string
+	RoomID uint
+	Title  string
+	Body   string
+}
+
+// Hit 搜索命中，Title 和 Snippet 为转义后的 HTML，命中的词用 <mark> 包裹
+type Hit struct {
+	ID       string  `json:"id"`
+	RoomID   uint    `json:"room_id"`
+	RoomName string  `json:"room_name"`
+	Title    string  `json:"title"`
+	Snippet  string  `json:"snippet"`
+	Rank     float64 `json:"rank"`
+}
+
+// Group 同一实体类型的搜索结果，Total 为该类型的全部命中数
+type Group struct {
+	Type  string `json:"type"`
+	Total int64  `json:"total"`
+	Hits  []Hit  `json:"hits"`
+}
+
+// Scope 按实体类型限定可见的索引记录，值为附加在查询上的条件，未给出的类型不做限制
+// 房间记录的查询已连接 rooms 表，人物卡记录的查询还连接了 character_index 表
+type Scope map[string]func(*gorm.DB) *gorm.DB
+
+// Index 基于 SQLite FTS5 的全文索引，首次使用时创建虚拟表
+// 只索引房间和人物卡；仓库中还没有会话笔记，笔记接入后再加入索引
+type Index struct {
+	db   *gorm.DB
+	once sync.Once
+	err  error
+}
+
+func NewIndex(db *gorm.DB) *Index {
+	return &Index{db: db}
+}
+
+// EnsureSchema 创建 FTS5 虚拟表（已存在时跳过）
+func (i *Index) EnsureSchema() error {
+	i.once.Do(func() {
+		i.err = i.db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS ` + tableName + ` USING fts5(
+			entity_type UNINDEXED,
+			entity_id UNINDEXED,
+			room_id UNINDEXED,
+			title,
+			body,
+			tokenize = 'unicode61 remove_diacritics 2'
+		)`).Error
+		if i.err != nil {
+			i.err = fmt.Errorf("failed to create search index: %w", i.err)
+		}
+	})
+	return i.err
+}
+
+// Put 写入或替换一条索引记录
+func (i *Index) Put(doc Document) error {
+	if err := i.EnsureSchema(); err != nil {
+		return err
+	}
+	return i.db.Transaction(func(tx *gorm.DB) error {
+		return put(tx, doc)
+	})
+}
+
+// Remove 删除一条索引记录
+func (i *Index) Remove(entityType, id string) error {
+	if err := i.EnsureSchema(); err != nil {
+		return err
+	}
+	return remove(i.db, entityType, id)
+}
+
+// RemoveRoom 删除房间及房间内所有人物卡的索引
+func (i *Index) RemoveRoom(roomID uint) error {
+	if err := i.EnsureSchema(); err != nil {
+		return err
+	}
+	if err := i.db.Exec(`DELETE FROM `+tableName+` WHERE room_id = ?`, roomID).Error; err != nil {
+		return fmt.Errorf("failed to remove room %d from search index: %w", roomID, err)
+	}
+	return nil
+}
+
+// Rebuild 清空索引并写入给定的全部记录
+func (i *Index) Rebuild(docs []Document) error {
+	if err := i.EnsureSchema(); err != nil {
+		return err
+	}
+	return i.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`DELETE FROM ` + tableName).Error; err != nil {
+			return fmt.Errorf("failed to clear search index: %w", err)
+		}
+		for _, doc := range docs {
+			if err := put(tx, doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Search 按相关度搜索，每个实体类型最多返回 limit 条，命中数和结果都只包含 scope 可见的记录
+// 查询文本按空白拆分为关键词，每个关键词做前缀匹配，全部关键词都需命中
+func (i *Index) Search(text string, types []string, limit int, scope Scope) ([]Group, error) {
+	if err := i.EnsureSchema(); err != nil {
+		return nil, err
+	}
+
+	query := MatchQuery(text)
+	groups := make([]Group, 0, len(types))
+	if query == "" {
+		return groups, nil
+	}
+
+	for _, entityType := range types {
+		group := Group{Type: entityType, Hits: []Hit{}}
+
+		base := i.db.Table(tableName).
+			Joins("JOIN rooms ON rooms.id = "+tableName+".room_id").
+			Where(tableName+" MATCH ? AND "+tableName+".entity_type = ?", query, entityType)
+		if entityType == TypeCharacter {
+			base = base.Joins("JOIN character_index ON character_index.room_id = " + tableName + ".room_id" +
+				" AND character_index.room_id || '/' || character_index.character_id = " + tableName + ".entity_id")
+		}
+		if visible := scope[entityType]; visible != nil {
+			base = base.Scopes(visible)
+		}
+		if err := base.Session(&gorm.Session{}).Count(&group.Total).Error; err != nil {
+			return nil, fmt.Errorf("failed to count search results: %w", err)
+		}
+
+		var hits []Hit
+		if err := base.Session(&gorm.Session{}).
+			Select(tableName+".entity_id AS id, "+tableName+".room_id AS room_id, rooms.name AS room_name, "+
+				"highlight("+tableName+", 3, ?, ?) AS title, "+
+				"snippet("+tableName+", 4, ?, ?, '…', 16) AS snippet, "+
+				"bm25("+tableName+", 10.0, 1.0) AS rank", markStart, markEnd, markStart, markEnd).
+			Order("rank").
+			Limit(limit).
+			Scan(&hits).Error; err != nil {
+			return nil, fmt.Errorf("failed to search: %w", err)
+		}
+		for j := range hits {
+			hits[j].Title = markHTML(hits[j].Title)
+			hits[j].Snippet = markHTML(hits[j].Snippet)
+		}
+		if hits != nil {
+			group.Hits = hits
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// MatchQuery 将用户输入转换为 FTS5 查询，每个关键词加引号避免被解析为查询语法
+func MatchQuery(text string) string {
+	terms := strings.Fields(text)
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		parts = append(parts, `"`+strings.ReplaceAll(term, `"`, `""`)+`"*`)
+	}
+	return strings.Join(parts, " ")
+}
+
+func put(db *gorm.DB, doc Document) error {
+	if err := remove(db, doc.Type, doc.ID); err != nil {
+		return err
+	}
+	if err := db.Exec(`INSERT INTO `+tableName+` (entity_type, entity_id, room_id, title, body) VALUES (?, ?, ?, ?, ?)`,
+		doc.Type, doc.ID, doc.RoomID, doc.Title, doc.Body).Error; err != nil {
+		return fmt.Errorf("failed to index %s %s: %w", doc.Type, doc.ID, err)
+	}
+	return nil
+}
+
+func remove(db *gorm.DB, entityType, id string) error {
+	if err := db.Exec(`DELETE FROM `+tableName+` WHERE entity_type = ? AND entity_id = ?`, entityType, id).Error; err != nil {
+		return fmt.Errorf("failed to remove %s %s from search index: %w", entityType, id, err)
+	}
+	return nil
+}
+
+// markHTML 转义 HTML 并把高亮占位符替换为 <mark>
+func markHTML(text string) string {
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, markStart, "<mark>")
+	return strings.ReplaceAll(text, markEnd, "</mark>")
+}
+
+// RoomDocument 房间的索引内容：名称和简介
+func RoomDocument(r *room.Room) Document {
+	return Document{
+		Type:   TypeRoom,
+		ID:     RoomID(r.ID),
+		RoomID: r.ID,
+		Title:  r.Name,
+		Body:   r.Description,
+	}
+}
+
+// CharacterDocument 人物卡的索引内容：名称、背景、装备和法术
+func CharacterDocument(card *character.CharacterCard) Document {
+	return Document{
+		Type:   TypeCharacter,
+		ID:     CharacterID(card.RoomID, card.ID),
+		RoomID: card.RoomID,
+		Title:  card.Name,
+		Body:   joinNonEmpty([]string{card.Background, card.Equipment, card.Spells}, " · "),
+	}
+}
+
+func joinNonEmpty(parts []string, sep string) string {
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return strings.Join(result, sep)
+}
+
+// RoomID 房间在索引中的 ID
+func RoomID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// CharacterID 人物卡在索引中的 ID，格式为 "房间ID/人物卡ID"
+func CharacterID(roomID, characterID uint) string {
+	return RoomID(roomID) + "/" + strconv.FormatUint(uint64(characterID), 10)
+}
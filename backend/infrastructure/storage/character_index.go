@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"trpg-sync/backend/domain/character"
+	"trpg-sync/backend/infrastructure/search"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CharacterIndex 维护数据库中的人物卡索引表和全文索引
+type CharacterIndex struct {
+	db     *gorm.DB
+	search *search.Index
+}
+
+func NewCharacterIndex(db *gorm.DB) *CharacterIndex {
+	return &CharacterIndex{db: db, search: search.NewIndex(db)}
+}
+
+// Put 新增或更新人物卡的索引
+func (i *CharacterIndex) Put(card *character.CharacterCard) error {
+	entry := character.NewIndexEntry(card)
+	if err := i.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to index character: %w", err)
+	}
+	return i.search.Put(search.CharacterDocument(card))
+}
+
+// Remove 删除人物卡的索引
+func (i *CharacterIndex) Remove(roomID, characterID uint) error {
+	if err := i.db.Where("room_id = ? AND character_id = ?", roomID, characterID).
+		Delete(&character.IndexEntry{}).Error; err != nil {
+		return fmt.Errorf("failed to remove character index: %w", err)
+	}
+	return i.search.Remove(search.TypeCharacter, search.CharacterID(roomID, characterID))
+}
+
+// Rebuild 清空索引并从人物卡文件重新生成，用于首次启用索引或索引与文件不一致时
+func (i *CharacterIndex) Rebuild(s *CharacterStorage) (int, error) {
+	roomDirs, err := os.ReadDir(s.GetRoomsPath())
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read rooms directory: %w", err)
+	}
+
+	count := 0
+	err = i.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&character.IndexEntry{}).Error; err != nil {
+			return err
+		}
+		index := NewCharacterIndex(tx)
+		for _, dir := range roomDirs {
+			roomID, err := strconv.ParseUint(dir.Name(), 10, 64)
+			if !dir.IsDir() || err != nil {
+				continue
+			}
+			cards, err := s.GetRoomCharacters(uint(roomID))
+			if err != nil {
+				return err
+			}
+			for j := range cards {
+				if err := index.Put(&cards[j]); err != nil {
+					return err
+				}
+				count++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to rebuild character index: %w", err)
+	}
+	return count, nil
+}
@@ -7,14 +7,19 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"trpg-sync/backend/domain/apperror"
 	"trpg-sync/backend/domain/character"
 )
 
 const DataDir = "data"
 const RoomsDir = "rooms"
 
+// ErrNotFound 人物卡文件不存在，调用方可用 errors.Is 判断，接口层返回 404
+var ErrNotFound = apperror.New(apperror.CodeCharacterNotFound, "")
+
 type CharacterStorage struct {
 	basePath string
+	index    *CharacterIndex
 }
 
 func NewCharacterStorage() *CharacterStorage {
@@ -23,9 +28,25 @@ func NewCharacterStorage() *CharacterStorage {
 	}
 }
 
+// WithIndex 保存和删除人物卡时同步更新索引
+func (s *CharacterStorage) WithIndex(index *CharacterIndex) *CharacterStorage {
+	s.index = index
+	return s
+}
+
+// GetRoomsPath 获取所有房间数据的根目录
+func (s *CharacterStorage) GetRoomsPath() string {
+	return filepath.Join(s.basePath, RoomsDir)
+}
+
+// GetRoomPath 获取房间的数据目录
+func (s *CharacterStorage) GetRoomPath(roomID uint) string {
+	return filepath.Join(s.GetRoomsPath(), strconv.FormatUint(uint64(roomID), 10))
+}
+
 // GetRoomCharactersPath 获取房间的人物卡目录
 func (s *CharacterStorage) GetRoomCharactersPath(roomID uint) string {
-	return filepath.Join(s.basePath, RoomsDir, strconv.FormatUint(uint64(roomID), 10), "characters")
+	return filepath.Join(s.GetRoomPath(roomID), "characters")
 }
 
 // GetCharacterFilePath 获取人物卡文件路径
@@ -54,6 +75,9 @@ func (s *CharacterStorage) SaveCharacter(char *character.CharacterCard) error {
 		return fmt.Errorf("failed to write character file: %w", err)
 	}
 
+	if s.index != nil {
+		return s.index.Put(char)
+	}
 	return nil
 }
 
@@ -63,6 +87,9 @@ func (s *CharacterStorage) LoadCharacter(roomID uint, characterID uint) (*charac
 
 	data, err := os.ReadFile(charPath)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("character %d in room %d: %w", characterID, roomID, ErrNotFound)
+		}
 		return nil, fmt.Errorf("failed to read character file: %w", err)
 	}
 
@@ -79,9 +106,28 @@ func (s *CharacterStorage) DeleteCharacter(roomID uint, characterID uint) error
 	charPath := s.GetCharacterFilePath(roomID, characterID)
 
 	if err := os.Remove(charPath); err != nil {
+		if os.IsNotExist(err) {
+			// 文件已不存在时顺带清理残留的索引
+			if s.index != nil {
+				_ = s.index.Remove(roomID, characterID)
+			}
+			return fmt.Errorf("character %d in room %d: %w", characterID, roomID, ErrNotFound)
+		}
 		return fmt.Errorf("failed to delete character file: %w", err)
 	}
 
+	if s.index != nil {
+		return s.index.Remove(roomID, characterID)
+	}
+	return nil
+}
+
+// DeleteRoom 删除房间的数据目录及其中全部人物卡文件，目录不存在时不报错
+// 人物卡索引不在这里清理，由调用方在删除房间的事务中一并删除
+func (s *CharacterStorage) DeleteRoom(roomID uint) error {
+	if err := os.RemoveAll(s.GetRoomPath(roomID)); err != nil {
+		return fmt.Errorf("failed to delete room directory: %w", err)
+	}
 	return nil
 }
 
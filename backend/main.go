@@ -7,9 +7,12 @@ import (
 	"net/http"
 	"trpg-sync/backend/api/middleware"
 	"trpg-sync/backend/api/v1"
-	"trpg-sync/backend/domain/room"
+	"trpg-sync/backend/domain/event"
+	"trpg-sync/backend/infrastructure/audit"
+	"trpg-sync/backend/infrastructure/auth"
 	"trpg-sync/backend/infrastructure/config"
 	"trpg-sync/backend/infrastructure/database"
+	"trpg-sync/backend/infrastructure/realtime"
 
 	"github.com/gin-gonic/gin"
 )
@@ -28,19 +31,49 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
-	// 自动同步表结构（只迁移 Room 表）
-	if err := db.AutoMigrate(&room.Room{}); err != nil {
+	// 同步表结构并执行版本迁移
+	if err := database.Migrate(db); err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
-	r := gin.Default()
+	// 领域事件总线及其订阅者，处理器只负责发布，其它模块在这里注册订阅
+	bus := event.NewBus()
+	hub := realtime.NewHub(realtime.DefaultHistory)
+	realtime.Forward(bus, hub)
+	audit.Record(bus, db)
+	audit.StartRetention(db, cfg.Audit.Retention)
+	if cfg.Log.Level == "debug" {
+		bus.SubscribeAll(func(e event.Event) {
+			log.Printf("event %s room=%d", e.Name(), e.RoomID())
+		})
+	}
+
+	// 开启认证时必须配置签名密钥；本地模式未配置时使用随机密钥，重启后需要重新登录
+	secret := []byte(cfg.Auth.JWTSecret)
+	if len(secret) == 0 {
+		if cfg.Auth.Enabled {
+			log.Fatalf("JWT_SECRET is required when AUTH_ENABLED is true")
+		}
+		secret = auth.RandomSecret()
+	}
+	tokens := auth.NewIssuer(secret, cfg.Auth.AccessTTL, cfg.Auth.RefreshTTL)
+
+	// 不使用 gin.Default 自带的日志，它会原样记录查询参数中的令牌
+	r := gin.New()
 
 	r.Use(middleware.CORS())
 	r.Use(middleware.Logger())
 	r.Use(middleware.Recovery())
 
 	// 注册API路由
-	v1.SetupRoutes(r, db)
+	v1.SetupRoutes(r, v1.Dependencies{
+		DB:             db,
+		Bus:            bus,
+		Hub:            hub,
+		Tokens:         tokens,
+		AuthRequired:   cfg.Auth.Enabled,
+		AllowedOrigins: cfg.CORS.AllowedOrigins,
+	})
 
 	// 获取前端静态文件系统
 	distFS, err := fs.Sub(frontendFS, "dist")
@@ -3,6 +3,8 @@ package testutil
 import (
 	"testing"
 
+	"trpg-sync/backend/infrastructure/database"
+
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -29,6 +31,15 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 	return db
 }
 
+// SetupMigratedDB 创建测试数据库并执行与正式环境相同的迁移，迁移失败时测试立即失败
+func SetupMigratedDB(t *testing.T) *gorm.DB {
+	db := SetupTestDB(t)
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	return db
+}
+
 // CleanupTestDB 清理测试数据
 func CleanupTestDB(db *gorm.DB, tables []interface{}) error {
 	for _, table := range tables {